@@ -0,0 +1,20 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+const (
+	// enableBlobFilesCFOptName enables key-value separation, values of at least min_blob_size are stored in blob files,
+	// so they aren't rewritten by every compaction of SST files
+	enableBlobFilesCFOptName = "enable_blob_files"
+	// minBlobSizeCFOptName is a minimum size of value which is stored in blob file
+	minBlobSizeCFOptName = "min_blob_size"
+	// blobFileSizeCFOptName is a target size of blob file
+	blobFileSizeCFOptName = "blob_file_size"
+	// blobCompressionTypeCFOptName is a compression type of blob files, accepts the same values as compression option
+	blobCompressionTypeCFOptName = "blob_compression_type"
+	// enableBlobGarbageCollectionCFOptName enables relocation of valid blobs from the oldest blob files during compaction
+	enableBlobGarbageCollectionCFOptName = "enable_blob_garbage_collection"
+	// blobGarbageCollectionAgeCutoffCFOptName is a fraction of the oldest blob files which are garbage collected
+	blobGarbageCollectionAgeCutoffCFOptName = "blob_garbage_collection_age_cutoff"
+)
@@ -0,0 +1,118 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/linxGnu/grocksdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverrideBlobOpts(t *testing.T) {
+	defaultOpts := newDefaultOptions()
+
+	for _, tc := range []struct {
+		desc                string
+		mockAppOptions      *mockAppOptions
+		blobFilesEnabled    bool
+		minBlobSize         uint64
+		blobCompressionType grocksdb.CompressionType
+		blobGCEnabled       bool
+		blobGCAgeCutoff     float64
+		success             bool
+	}{
+		{
+			desc:                "override nothing",
+			mockAppOptions:      newMockAppOptions(map[string]interface{}{}),
+			blobFilesEnabled:    defaultOpts.IsBlobFilesEnabled(),
+			minBlobSize:         defaultOpts.GetMinBlobSize(),
+			blobCompressionType: defaultOpts.GetBlobCompressionType(),
+			blobGCEnabled:       defaultOpts.IsBlobGCEnabled(),
+			blobGCAgeCutoff:     defaultOpts.GetBlobGCAgeCutoff(),
+			success:             true,
+		},
+		{
+			desc: "override blob options",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				enableBlobFilesCFOptName:                true,
+				minBlobSizeCFOptName:                    4096,
+				blobFileSizeCFOptName:                   256 << 20,
+				blobCompressionTypeCFOptName:            "lz4",
+				enableBlobGarbageCollectionCFOptName:    true,
+				blobGarbageCollectionAgeCutoffCFOptName: 0.5,
+			}),
+			blobFilesEnabled:    true,
+			minBlobSize:         4096,
+			blobCompressionType: grocksdb.LZ4Compression,
+			blobGCEnabled:       true,
+			blobGCAgeCutoff:     0.5,
+			success:             true,
+		},
+		{
+			desc: "invalid blob compression type",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				blobCompressionTypeCFOptName: "gzip",
+			}),
+			success: false,
+		},
+		{
+			desc: "blob gc age cutoff out of range",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				blobGarbageCollectionAgeCutoffCFOptName: 1.5,
+			}),
+			success: false,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			cfOpts, err := overrideCFOpts(newDefaultOptions(), tc.mockAppOptions)
+			if !tc.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.blobFilesEnabled, cfOpts.IsBlobFilesEnabled())
+			require.Equal(t, tc.minBlobSize, cfOpts.GetMinBlobSize())
+			require.Equal(t, tc.blobCompressionType, cfOpts.GetBlobCompressionType())
+			require.Equal(t, tc.blobGCEnabled, cfOpts.IsBlobGCEnabled())
+			require.Equal(t, tc.blobGCAgeCutoff, cfOpts.GetBlobGCAgeCutoff())
+		})
+	}
+}
+
+func TestOpenRocksdbWithBlobFiles(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	mockAppOpts := newMockAppOptions(map[string]interface{}{
+		enableBlobFilesCFOptName: true,
+		minBlobSizeCFOptName:     1024,
+	})
+	db, err := openRocksdb(dir, defaultDBName, mockAppOpts, MetricsConfig{})
+	require.NoError(t, err)
+
+	require.NoError(t, db.Set([]byte("key"), make([]byte, 2048)))
+	flushOpts := grocksdb.NewDefaultFlushOptions()
+	defer flushOpts.Destroy()
+	require.NoError(t, db.db.Flush(flushOpts))
+
+	// value exceeds min_blob_size, so it's stored in blob file
+	props, err := newPropsLoader(db.propsGetter(), false).load()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), props.NumBlobFiles)
+	require.NotZero(t, props.LiveBlobFileSize)
+	require.NoError(t, db.Close())
+
+	_, cfOpts, err := LoadLatestOptions(filepath.Join(dir, "application.db"))
+	require.NoError(t, err)
+	require.True(t, cfOpts.IsBlobFilesEnabled())
+	require.Equal(t, uint64(1024), cfOpts.GetMinBlobSize())
+}
@@ -0,0 +1,122 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"fmt"
+
+	"github.com/linxGnu/grocksdb"
+)
+
+const (
+	// blockCacheTypeOptName is a type of block cache: lru (default) or hyper_clock,
+	// hyper_clock cache is lock-free, so it reduces mutex contention on high-core machines
+	blockCacheTypeOptName = "block_cache_type"
+	lruBlockCacheType     = "lru"
+	hyperClockCacheType   = "hyper_clock"
+	// blockCacheNumShardBitsOptName is a number of bits used to choose cache shard, -1 (default) lets rocksdb choose it
+	blockCacheNumShardBitsOptName = "block_cache_num_shard_bits"
+	// blockCacheEstimatedEntryChargeOptName is an estimated average size of hyper_clock cache entry,
+	// it's block_size by default
+	blockCacheEstimatedEntryChargeOptName = "block_cache_estimated_entry_charge"
+
+	// strictCapacityLimitOptName makes lru cache fail inserts instead of exceeding its capacity,
+	// it can't be used together with block_cache_num_shard_bits
+	strictCapacityLimitOptName = "strict_capacity_limit"
+
+	// options below aren't supported, see Block cache type in README,
+	// they are rejected instead of being silently ignored
+	highPriPoolRatioOptName             = "high_pri_pool_ratio"
+	compressedSecondaryCacheSizeOptName = "compressed_secondary_cache_size"
+
+	// defaultBlockCacheNumShardBits lets rocksdb choose number of shards based on capacity
+	defaultBlockCacheNumShardBits = -1
+	// defaultBlockSize is a default rocksdb block size, it's used as hyper_clock estimated entry charge
+	// if block_size isn't specified
+	defaultBlockSize = 4 * 1024
+)
+
+// blockCacheOpts contains options of block cache except its capacity
+type blockCacheOpts struct {
+	cacheType            string
+	numShardBits         int
+	estimatedEntryCharge int
+	strictCapacityLimit  bool
+}
+
+func blockCacheOptsFromAppOpts(appOpts AppOptions) (blockCacheOpts, error) {
+	for _, optName := range []string{highPriPoolRatioOptName, compressedSecondaryCacheSizeOptName} {
+		if appOpts.Get(optName) != nil {
+			return blockCacheOpts{}, fmt.Errorf("%v isn't supported", optName)
+		}
+	}
+
+	cacheType, _, err := optValue(appOpts, blockCacheTypeOptName)
+	if err != nil {
+		return blockCacheOpts{}, err
+	}
+	numShardBits, _, err := optValue(appOpts, blockCacheNumShardBitsOptName)
+	if err != nil {
+		return blockCacheOpts{}, err
+	}
+	strictCapacityLimit, _, err := optValue(appOpts, strictCapacityLimitOptName)
+	if err != nil {
+		return blockCacheOpts{}, err
+	}
+	opts := blockCacheOpts{
+		cacheType:            cacheType.stringVal,
+		numShardBits:         int(numShardBits.intVal),
+		estimatedEntryCharge: defaultBlockSize,
+		strictCapacityLimit:  strictCapacityLimit.boolVal,
+	}
+
+	if opts.strictCapacityLimit && opts.cacheType != lruBlockCacheType {
+		return blockCacheOpts{}, fmt.Errorf("%v is supported only by %v cache", strictCapacityLimitOptName, lruBlockCacheType)
+	}
+	if opts.strictCapacityLimit && opts.numShardBits != defaultBlockCacheNumShardBits {
+		return blockCacheOpts{}, fmt.Errorf(
+			"%v can't be used together with %v, rocksdb C API creates cache with strict capacity limit only with default sharding",
+			strictCapacityLimitOptName, blockCacheNumShardBitsOptName,
+		)
+	}
+
+	blockSize, ok, err := sizeOptValue(appOpts, blockSizeBBTOOptName)
+	if err != nil {
+		return blockCacheOpts{}, err
+	}
+	if ok {
+		opts.estimatedEntryCharge = int(blockSize)
+	}
+	estimatedEntryCharge, ok, err := sizeOptValue(appOpts, blockCacheEstimatedEntryChargeOptName)
+	if err != nil {
+		return blockCacheOpts{}, err
+	}
+	if ok {
+		opts.estimatedEntryCharge = int(estimatedEntryCharge)
+	}
+
+	return opts, nil
+}
+
+// newCache creates block cache of provided capacity
+func (o blockCacheOpts) newCache(capacity uint64) *grocksdb.Cache {
+	if o.cacheType == hyperClockCacheType {
+		cacheOpts := grocksdb.NewHyperClockCacheOptions(int(capacity), o.estimatedEntryCharge)
+		defer cacheOpts.Destroy()
+		cacheOpts.SetNumShardBits(o.numShardBits)
+
+		return grocksdb.NewHyperClockCacheWithOpts(cacheOpts)
+	}
+
+	if o.strictCapacityLimit {
+		return newLRUCacheWithStrictCapacityLimit(capacity)
+	}
+
+	cacheOpts := grocksdb.NewLRUCacheOptions()
+	defer cacheOpts.Destroy()
+	cacheOpts.SetCapacity(uint(capacity))
+	cacheOpts.SetNumShardBits(o.numShardBits)
+
+	return grocksdb.NewLRUCacheWithOptions(cacheOpts)
+}
@@ -0,0 +1,34 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+// rocksdb library is linked by grocksdb, so only header is needed here
+
+// #include "rocksdb/c.h"
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/linxGnu/grocksdb"
+)
+
+// nativeCache has the same layout as grocksdb.Cache, which doesn't have exported constructor from C cache
+type nativeCache struct {
+	c *C.rocksdb_cache_t
+}
+
+// build fails if size of grocksdb.Cache differs from size of nativeCache, layout of fields is checked by tests
+var (
+	_ [unsafe.Sizeof(grocksdb.Cache{}) - unsafe.Sizeof(nativeCache{})]struct{}
+	_ [unsafe.Sizeof(nativeCache{}) - unsafe.Sizeof(grocksdb.Cache{})]struct{}
+)
+
+// newLRUCacheWithStrictCapacityLimit creates LRU cache which fails inserts instead of exceeding capacity,
+// grocksdb doesn't wrap this function of rocksdb C API, so the cache is created directly,
+// it has default number of shards, because C API doesn't accept options together with strict capacity limit
+func newLRUCacheWithStrictCapacityLimit(capacity uint64) *grocksdb.Cache {
+	cache := &nativeCache{c: C.rocksdb_cache_create_lru_with_strict_capacity_limit(C.size_t(capacity))}
+	return (*grocksdb.Cache)(unsafe.Pointer(cache))
+}
@@ -0,0 +1,167 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/linxGnu/grocksdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockCacheOptsFromAppOpts(t *testing.T) {
+	for _, tc := range []struct {
+		desc           string
+		mockAppOptions *mockAppOptions
+		expectedOpts   blockCacheOpts
+		success        bool
+	}{
+		{
+			desc:           "default options",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{}),
+			expectedOpts: blockCacheOpts{
+				cacheType:            lruBlockCacheType,
+				numShardBits:         defaultBlockCacheNumShardBits,
+				estimatedEntryCharge: defaultBlockSize,
+			},
+			success: true,
+		},
+		{
+			desc: "hyper clock cache with block size as estimated entry charge",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				blockCacheTypeOptName:         hyperClockCacheType,
+				blockCacheNumShardBitsOptName: 8,
+				blockSizeBBTOOptName:          16384,
+			}),
+			expectedOpts: blockCacheOpts{
+				cacheType:            hyperClockCacheType,
+				numShardBits:         8,
+				estimatedEntryCharge: 16384,
+			},
+			success: true,
+		},
+		{
+			desc: "hyper clock cache with estimated entry charge",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				blockCacheTypeOptName:                 hyperClockCacheType,
+				blockSizeBBTOOptName:                  16384,
+				blockCacheEstimatedEntryChargeOptName: 8192,
+			}),
+			expectedOpts: blockCacheOpts{
+				cacheType:            hyperClockCacheType,
+				numShardBits:         defaultBlockCacheNumShardBits,
+				estimatedEntryCharge: 8192,
+			},
+			success: true,
+		},
+		{
+			desc: "unknown cache type",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				blockCacheTypeOptName: "clock",
+			}),
+			success: false,
+		},
+		{
+			desc: "lru cache with strict capacity limit",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				strictCapacityLimitOptName: true,
+			}),
+			expectedOpts: blockCacheOpts{
+				cacheType:            lruBlockCacheType,
+				numShardBits:         defaultBlockCacheNumShardBits,
+				estimatedEntryCharge: defaultBlockSize,
+				strictCapacityLimit:  true,
+			},
+			success: true,
+		},
+		{
+			desc: "invalid strict capacity limit",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				strictCapacityLimitOptName: "yes",
+			}),
+			success: false,
+		},
+		{
+			desc: "strict capacity limit of hyper clock cache",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				blockCacheTypeOptName:      hyperClockCacheType,
+				strictCapacityLimitOptName: true,
+			}),
+			success: false,
+		},
+		{
+			desc: "strict capacity limit with number of shard bits",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				blockCacheNumShardBitsOptName: 4,
+				strictCapacityLimitOptName:    true,
+			}),
+			success: false,
+		},
+		{
+			desc: "high priority pool ratio isn't supported",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				highPriPoolRatioOptName: 0.5,
+			}),
+			success: false,
+		},
+		{
+			desc: "compressed secondary cache isn't supported",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				compressedSecondaryCacheSizeOptName: 1 << 30,
+			}),
+			success: false,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			opts, err := blockCacheOptsFromAppOpts(tc.mockAppOptions)
+			if !tc.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedOpts, opts)
+		})
+	}
+}
+
+func TestBlockCacheOptsNewCache(t *testing.T) {
+	for _, cacheType := range []string{lruBlockCacheType, hyperClockCacheType} {
+		t.Run(cacheType, func(t *testing.T) {
+			cache := blockCacheOpts{
+				cacheType:            cacheType,
+				numShardBits:         4,
+				estimatedEntryCharge: defaultBlockSize,
+			}.newCache(1 << 20)
+			defer cache.Destroy()
+
+			require.Equal(t, uint64(1<<20), cache.GetCapacity())
+		})
+	}
+}
+
+func TestBlockCacheOptsNewCacheWithStrictCapacityLimit(t *testing.T) {
+	cache := blockCacheOpts{
+		cacheType:           lruBlockCacheType,
+		numShardBits:        defaultBlockCacheNumShardBits,
+		strictCapacityLimit: true,
+	}.newCache(1 << 20)
+	defer cache.Destroy()
+
+	require.Equal(t, uint64(1<<20), cache.GetCapacity())
+}
+
+func TestNativeCacheLayout(t *testing.T) {
+	// newLRUCacheWithStrictCapacityLimit converts nativeCache to grocksdb.Cache,
+	// so grocksdb.Cache should contain the only pointer to C cache
+	cacheType := reflect.TypeOf(grocksdb.Cache{})
+	nativeCacheType := reflect.TypeOf(nativeCache{})
+	require.Equal(t, nativeCacheType.Size(), cacheType.Size())
+	require.Equal(t, nativeCacheType.NumField(), cacheType.NumField())
+	for i := 0; i < cacheType.NumField(); i++ {
+		require.Equal(t, nativeCacheType.Field(i).Offset, cacheType.Field(i).Offset)
+		require.Equal(t, nativeCacheType.Field(i).Type.Kind(), cacheType.Field(i).Type.Kind())
+	}
+}
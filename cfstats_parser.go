@@ -0,0 +1,208 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	levelHeaderColumn    = "Level"
+	filesHeaderColumn    = "Files"
+	sizeHeaderColumn     = "Size"
+	scoreHeaderColumn    = "Score"
+	writeAmpHeaderColumn = "W-Amp"
+
+	sumLevelRow = "Sum"
+	// sumLevelLabel is used instead of level number for totals over all levels
+	sumLevelLabel = "sum"
+)
+
+// sizeUnits maps units used by rocksdb in human-readable sizes to number of bytes
+var sizeUnits = map[string]float64{
+	"B":  1,
+	"KB": 1 << 10,
+	"MB": 1 << 20,
+	"GB": 1 << 30,
+	"TB": 1 << 40,
+}
+
+// levelCompactionStats represents one row of compaction stats table from rocksdb.cfstats property
+type levelCompactionStats struct {
+	NumFiles           uint64
+	NumCompactingFiles uint64
+	SizeBytes          float64
+	Score              float64
+	WriteAmp           float64
+}
+
+// parseCompactionStats parses compaction stats by level from serialized rocksdb.cfstats property,
+// it returns map of compaction stats where key is a level number or sumLevelLabel for totals over all levels
+// example of compaction stats table:
+//
+//	Level    Files   Size     Score Read(GB)  Rn(GB) Rnp1(GB) Write(GB) Wnew(GB) Moved(GB) W-Amp ...
+//	------------------------------------------------------------------------------------------------ ...
+//	  L0      2/0    2.10 KB   0.5      0.0     0.0      0.0       0.0      0.0       0.0   1.0 ...
+//	 Sum      2/0    2.10 KB   0.0      0.0     0.0      0.0       0.0      0.0       0.0   1.0 ...
+//	 Int      0/0    0.00 KB   0.0      0.0     0.0      0.0       0.0      0.0       0.0   0.0 ...
+//
+// other tables (for example compaction stats by priority) and interval stats are skipped
+func parseCompactionStats(cfstats string) (map[string]*levelCompactionStats, error) {
+	compactionStats := make(map[string]*levelCompactionStats)
+
+	var header []string
+	for _, line := range strings.Split(cfstats, "\n") {
+		tokens := strings.Fields(line)
+		if len(tokens) == 0 {
+			// empty line ends the table
+			header = nil
+			continue
+		}
+
+		if tokens[0] == levelHeaderColumn {
+			header = tokens
+			continue
+		}
+		if header == nil {
+			continue
+		}
+
+		level, ok := levelLabel(tokens[0])
+		if !ok {
+			continue
+		}
+
+		levelStats, err := parseLevelCompactionStats(header, tokens)
+		if err != nil {
+			return nil, fmt.Errorf("invalid compaction stats of level %v: %v", tokens[0], err)
+		}
+
+		compactionStats[level] = levelStats
+	}
+
+	return compactionStats, nil
+}
+
+// levelLabel converts name of compaction stats row into level label, for example:
+// L0 -> 0, Sum -> sum
+// it returns false for rows which don't represent level, for example: Int
+func levelLabel(rowName string) (string, bool) {
+	if rowName == sumLevelRow {
+		return sumLevelLabel, true
+	}
+
+	level, ok := strings.CutPrefix(rowName, "L")
+	if !ok {
+		return "", false
+	}
+	if _, err := strconv.ParseUint(level, 10, 64); err != nil {
+		return "", false
+	}
+
+	return level, true
+}
+
+// parseLevelCompactionStats parses one row of compaction stats table
+// NOTE: size is printed as two tokens (value and unit), so every column after size is shifted by one token
+func parseLevelCompactionStats(header, tokens []string) (*levelCompactionStats, error) {
+	if len(tokens) != len(header)+1 {
+		return nil, fmt.Errorf("invalid number of tokens: %v, header: %v", len(tokens), header)
+	}
+
+	sizeIdx := slices.Index(header, sizeHeaderColumn)
+	if sizeIdx < 0 {
+		return nil, fmt.Errorf("column %v doesn't exist", sizeHeaderColumn)
+	}
+	// column returns value of column by its name
+	column := func(name string) (string, error) {
+		idx := slices.Index(header, name)
+		if idx < 0 {
+			return "", fmt.Errorf("column %v doesn't exist", name)
+		}
+		if idx > sizeIdx {
+			idx++
+		}
+
+		return tokens[idx], nil
+	}
+
+	files, err := column(filesHeaderColumn)
+	if err != nil {
+		return nil, err
+	}
+	numFiles, numCompactingFiles, err := parseFiles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	sizeBytes, err := parseSize(tokens[sizeIdx], tokens[sizeIdx+1])
+	if err != nil {
+		return nil, err
+	}
+
+	score, err := column(scoreHeaderColumn)
+	if err != nil {
+		return nil, err
+	}
+	scoreVal, err := strconv.ParseFloat(score, 64)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse score: %v", err)
+	}
+
+	writeAmp, err := column(writeAmpHeaderColumn)
+	if err != nil {
+		return nil, err
+	}
+	writeAmpVal, err := strconv.ParseFloat(writeAmp, 64)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse write amplification: %v", err)
+	}
+
+	return &levelCompactionStats{
+		NumFiles:           numFiles,
+		NumCompactingFiles: numCompactingFiles,
+		SizeBytes:          sizeBytes,
+		Score:              scoreVal,
+		WriteAmp:           writeAmpVal,
+	}, nil
+}
+
+// parseFiles parses files column which contains total number of files and number of files being compacted
+// example: 2/1
+func parseFiles(files string) (uint64, uint64, error) {
+	total, compacting, ok := strings.Cut(files, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid number of files: %v", files)
+	}
+
+	totalVal, err := strconv.ParseUint(total, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("can't parse number of files: %v", err)
+	}
+	compactingVal, err := strconv.ParseUint(compacting, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("can't parse number of compacting files: %v", err)
+	}
+
+	return totalVal, compactingVal, nil
+}
+
+// parseSize parses human-readable size into number of bytes
+// example: 2.10 KB
+func parseSize(value, unit string) (float64, error) {
+	multiplier, ok := sizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit: %v", unit)
+	}
+
+	valueFloat, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("can't parse size: %v", err)
+	}
+
+	return valueFloat * multiplier, nil
+}
@@ -0,0 +1,117 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testCFStats = `
+** Compaction Stats [default] **
+Level    Files   Size     Score Read(GB)  Rn(GB) Rnp1(GB) Write(GB) Wnew(GB) Moved(GB) W-Amp Rd(MB/s) Wr(MB/s) Comp(sec) CompMergeCPU(sec) Comp(cnt) Avg(sec) KeyIn KeyDrop Rblob(GB) Wblob(GB)
+------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------
+  L0      2/1    2.00 KB   0.5      0.0     0.0      0.0       0.0      0.0       0.0   1.0      0.0      0.4      0.01              0.00         2    0.003       0      0       0.0       0.0
+  L6      1/0    1.50 MB   0.0      0.0     0.0      0.0       0.0      0.0       0.0   2.5      0.0      0.4      0.01              0.00         2    0.003       0      0       0.0       0.0
+ Sum      3/1    1.50 MB   0.0      0.0     0.0      0.0       0.0      0.0       0.0   3.5      0.0      0.4      0.01              0.00         2    0.003       0      0       0.0       0.0
+ Int      0/0    0.00 KB   0.0      0.0     0.0      0.0       0.0      0.0       0.0   0.0      0.0      0.0      0.00              0.00         0    0.000       0      0       0.0       0.0
+
+** Compaction Stats [default] **
+Priority    Files   Size     Score Read(GB)  Rn(GB) Rnp1(GB) Write(GB) Wnew(GB) Moved(GB) W-Amp Rd(MB/s) Wr(MB/s) Comp(sec) CompMergeCPU(sec) Comp(cnt) Avg(sec) KeyIn KeyDrop Rblob(GB) Wblob(GB)
+---------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------
+High      0/0    0.00 KB   0.0      0.0     0.0      0.0       0.0      0.0       0.0   0.0      0.0      0.4      0.01              0.00         2    0.003       0      0       0.0       0.0
+
+Blob file count: 0, total size: 0.0 GB, garbage size: 0.0 GB, space amp: 0.0
+
+Uptime(secs): 0.1 total, 0.1 interval
+Flush(GB): cumulative 0.000, interval 0.000
+`
+
+func TestParseCompactionStats(t *testing.T) {
+	for _, tc := range []struct {
+		desc                    string
+		cfStats                 string
+		expectedCompactionStats map[string]*levelCompactionStats
+		errMsg                  string
+	}{
+		{
+			desc:    "success case",
+			cfStats: testCFStats,
+			expectedCompactionStats: map[string]*levelCompactionStats{
+				"0": {
+					NumFiles:           2,
+					NumCompactingFiles: 1,
+					SizeBytes:          2 * 1024,
+					Score:              0.5,
+					WriteAmp:           1,
+				},
+				"6": {
+					NumFiles:           1,
+					NumCompactingFiles: 0,
+					SizeBytes:          1.5 * 1024 * 1024,
+					Score:              0,
+					WriteAmp:           2.5,
+				},
+				"sum": {
+					NumFiles:           3,
+					NumCompactingFiles: 1,
+					SizeBytes:          1.5 * 1024 * 1024,
+					Score:              0,
+					WriteAmp:           3.5,
+				},
+			},
+			errMsg: "",
+		},
+		{
+			desc: "invalid number of files",
+			cfStats: `Level    Files   Size     Score W-Amp
+  L0      2    2.00 KB   0.5   1.0
+`,
+			expectedCompactionStats: nil,
+			errMsg:                  "invalid number of files",
+		},
+		{
+			desc: "unknown size unit",
+			cfStats: `Level    Files   Size     Score W-Amp
+  L0      2/0    2.00 PB   0.5   1.0
+`,
+			expectedCompactionStats: nil,
+			errMsg:                  "unknown size unit",
+		},
+		{
+			desc: "invalid number of tokens",
+			cfStats: `Level    Files   Size     Score W-Amp
+  L0      2/0    2.00 KB   0.5
+`,
+			expectedCompactionStats: nil,
+			errMsg:                  "invalid number of tokens",
+		},
+		{
+			desc: "missing column",
+			cfStats: `Level    Files   Size     Score
+  L0      2/0    2.00 KB   0.5
+`,
+			expectedCompactionStats: nil,
+			errMsg:                  "column W-Amp doesn't exist",
+		},
+		{
+			desc:                    "empty cfstats",
+			cfStats:                 ``,
+			expectedCompactionStats: make(map[string]*levelCompactionStats),
+			errMsg:                  "",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			actualCompactionStats, err := parseCompactionStats(tc.cfStats)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.errMsg)
+			}
+			require.Equal(t, tc.expectedCompactionStats, actualCompactionStats)
+		})
+	}
+}
@@ -0,0 +1,23 @@
+//go:build rocksdb
+// +build rocksdb
+
+package main
+
+import (
+	"github.com/spf13/viper"
+
+	"github.com/Kava-Labs/opendb"
+)
+
+// loadAppOptions loads app.toml with viper like cosmos-sdk applications do, so options are resolved
+// exactly as in opendb of the node
+func loadAppOptions(path string) (opendb.AppOptions, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
@@ -0,0 +1,95 @@
+//go:build rocksdb
+// +build rocksdb
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAppOptions(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	for _, tc := range []struct {
+		desc     string
+		content  string
+		expected map[string]interface{}
+		success  bool
+	}{
+		{
+			desc: "rocksdb tables",
+			content: `# This is a TOML config file.
+minimum-gas-prices = "0.001ukava"
+
+[rocksdb]
+max-open-files = 16_384 # comment
+column-families = ["nodes", 'orphans',]
+bloom-bits-per-key = 10.5
+Use-Direct-Reads = true
+
+[rocksdb.application]
+max-open-files = 4096
+cf.nodes.write-buffer-size = "128 # MiB"
+compression-per-level = [
+  "none", # level 0
+  "lz4",
+]
+
+[ "rocksdb" . "tx_index" ]
+wal_dir = """/mnt/wal"dir"""
+`,
+			expected: map[string]interface{}{
+				"minimum-gas-prices":                             "0.001ukava",
+				"rocksdb.max-open-files":                         int64(16384),
+				"rocksdb.column-families":                        []interface{}{"nodes", "orphans"},
+				"rocksdb.bloom-bits-per-key":                     10.5,
+				"rocksdb.use-direct-reads":                       true,
+				"rocksdb.application.max-open-files":             int64(4096),
+				"rocksdb.application.cf.nodes.write-buffer-size": "128 # MiB",
+				"rocksdb.application.compression-per-level":      []interface{}{"none", "lz4"},
+				"rocksdb.tx_index.wal_dir":                       `/mnt/wal"dir`,
+				// keys are case-insensitive like in opendb of the node
+				"rocksdb.Application.max-open-files": int64(4096),
+			},
+			success: true,
+		},
+		{
+			desc:    "invalid option",
+			content: "[rocksdb]\nmax-open-files\n",
+			success: false,
+		},
+		{
+			desc:    "unterminated array",
+			content: "[rocksdb]\ncolumn-families = [\n  \"nodes\",\n",
+			success: false,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			path := filepath.Join(dir, "app.toml")
+			require.NoError(t, os.WriteFile(path, []byte(tc.content), 0o644))
+
+			opts, err := loadAppOptions(path)
+			if !tc.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			for key, value := range tc.expected {
+				require.Equal(t, value, opts.Get(key), key)
+			}
+		})
+	}
+
+	_, err = loadAppOptions(filepath.Join(dir, "missing.toml"))
+	require.Error(t, err)
+}
@@ -0,0 +1,45 @@
+//go:build rocksdb
+// +build rocksdb
+
+// opendb inspects rocksdb databases of a node without opening them, so it can be used while node is running.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Kava-Labs/opendb"
+)
+
+const usage = `usage: opendb <command> [flags]
+
+commands:
+  effective-options  print options which opendb applies to databases, run with -h for flags`
+
+func main() {
+	err := run(os.Args[1:], os.Stdout)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run runs command of args and writes its output to w
+func run(args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	switch args[0] {
+	case opendb.EffectiveOptionsCmdName:
+		return opendb.RunEffectiveOptionsCmd(args[1:], w, loadAppOptions)
+	default:
+		return fmt.Errorf("unknown command: %v\n%v", args[0], usage)
+	}
+}
@@ -0,0 +1,47 @@
+//go:build rocksdb
+// +build rocksdb
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	home, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(home)
+		require.NoError(t, err)
+	}()
+
+	dbPath := filepath.Join(home, "data", "application.db")
+	require.NoError(t, os.MkdirAll(dbPath, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dbPath, "OPTIONS-000007"), []byte(`[DBOptions]
+  max_open_files=4096
+
+[CFOptions "default"]
+  write_buffer_size=67108864
+`), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(home, "config"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(home, "config", "app.toml"), []byte(`[rocksdb]
+max-open-files = 8192
+
+[rocksdb.application]
+write-buffer-size = "64MiB"
+`), 0o644))
+
+	var b bytes.Buffer
+	require.NoError(t, run([]string{"effective-options", "-home", home, "-diff"}, &b))
+	require.Equal(t, `[application.db]
+max-open-files = { value = "8192", source = "[rocksdb]", key = "rocksdb.max-open-files", options_file_value = "4096", differs_from_options_file = true, mutable = false }
+`, b.String())
+
+	require.Error(t, run(nil, &b))
+	require.Error(t, run([]string{"options"}, &b))
+}
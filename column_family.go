@@ -0,0 +1,379 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	dbm "github.com/cometbft/cometbft-db"
+	"github.com/linxGnu/grocksdb"
+)
+
+var (
+	ErrColumnFamilyNotFound = errors.New("column family not found")
+
+	errKeyEmpty    = errors.New("key cannot be empty")
+	errValueNil    = errors.New("value cannot be nil")
+	errBatchClosed = errors.New("batch has been written or closed")
+	errDBClosed    = errors.New("database is closed")
+)
+
+// RocksDB is a rocksdb database which may contain multiple column families.
+// It implements dbm.DB interface, methods of dbm.DB read and write data from/to the default column family.
+// Other column families can be accessed with ColumnFamily method.
+type RocksDB struct {
+	*dbm.RocksDB
+
+	db        *grocksdb.DB
+	ro        *grocksdb.ReadOptions
+	wo        *grocksdb.WriteOptions
+	woSync    *grocksdb.WriteOptions
+	cfNames   []string
+	cfHandles map[string]*grocksdb.ColumnFamilyHandle
+
+	// name and path of the database, path is a directory which contains OPTIONS files
+	name string
+	path string
+	// optsMtx protects appOpts, which are the latest appOpts applied by openRocksdb or SetOptions
+	optsMtx sync.Mutex
+	appOpts AppOptions
+	logger  Logger
+
+	// stopOptionsWatcher stops watching options file and waits until it's stopped, it's protected by optsMtx,
+	// because options file can be watched concurrently with Close
+	watchingOptions    bool
+	stopOptionsWatcher func()
+	// closed is true if database is closed, it's protected by optsMtx
+	closed bool
+	// stopMetrics stops metrics reporting and waits until it's stopped
+	stopMetrics func()
+	// releaseFuncs release rocksdb objects created by opendb, they are called after database is closed
+	releaseFuncs []func()
+}
+
+var _ dbm.DB = (*RocksDB)(nil)
+
+func newRocksDB(
+	db *grocksdb.DB,
+	cfNames []string,
+	cfHandles []*grocksdb.ColumnFamilyHandle,
+	ro *grocksdb.ReadOptions,
+	wo *grocksdb.WriteOptions,
+	woSync *grocksdb.WriteOptions,
+) *RocksDB {
+	handles := make(map[string]*grocksdb.ColumnFamilyHandle, len(cfNames))
+	for i, cfName := range cfNames {
+		handles[cfName] = cfHandles[i]
+	}
+
+	return &RocksDB{
+		RocksDB:   dbm.NewRocksDBWithRawDB(db, ro, wo, woSync),
+		db:        db,
+		ro:        ro,
+		wo:        wo,
+		woSync:    woSync,
+		cfNames:   cfNames,
+		cfHandles: handles,
+
+		appOpts: noAppOptions{},
+		logger:  nopLogger{},
+
+		stopOptionsWatcher: func() {},
+		stopMetrics:        func() {},
+	}
+}
+
+// ColumnFamilyNames returns names of all opened column families
+func (db *RocksDB) ColumnFamilyNames() []string {
+	cfNames := make([]string, len(db.cfNames))
+	copy(cfNames, db.cfNames)
+
+	return cfNames
+}
+
+// ColumnFamily returns dbm.DB which reads and writes data from/to column family with provided name
+// returned dbm.DB shares underlying database, so it shouldn't be used after RocksDB is closed
+func (db *RocksDB) ColumnFamily(name string) (dbm.DB, error) {
+	handle, ok := db.cfHandles[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrColumnFamilyNotFound, name)
+	}
+
+	return newColumnFamilyDB(db.db, handle, db.ro, db.wo, db.woSync), nil
+}
+
+// Close implements dbm.DB.
+// Options watcher and metrics reporting have to be stopped and column family handles have to be destroyed
+// before database is closed, rocksdb objects created by opendb are released after database is closed.
+// Database which is already closed isn't closed again.
+func (db *RocksDB) Close() error {
+	db.optsMtx.Lock()
+	if db.closed {
+		db.optsMtx.Unlock()
+		return nil
+	}
+	db.closed = true
+	stopOptionsWatcher := db.stopOptionsWatcher
+	db.stopOptionsWatcher = func() {}
+	db.optsMtx.Unlock()
+
+	// watcher applies options under optsMtx, so it's stopped without holding it
+	stopOptionsWatcher()
+	db.stopMetrics()
+	db.stopMetrics = func() {}
+
+	for _, handle := range db.cfHandles {
+		handle.Destroy()
+	}
+
+	// closes database and destroys read and write options
+	err := db.RocksDB.Close()
+
+	for i := len(db.releaseFuncs) - 1; i >= 0; i-- {
+		db.releaseFuncs[i]()
+	}
+	db.releaseFuncs = nil
+
+	return err
+}
+
+// releaseOnClose registers functions which release rocksdb objects after database is closed
+func (db *RocksDB) releaseOnClose(releaseFuncs ...func()) {
+	db.releaseFuncs = append(db.releaseFuncs, releaseFuncs...)
+}
+
+// propsGetter returns getter of properties of the database, it must not be used after database is closed
+func (db *RocksDB) propsGetter() cfPropsGetter {
+	cfHandles := make([]*grocksdb.ColumnFamilyHandle, 0, len(db.cfNames))
+	for _, cfName := range db.cfNames {
+		cfHandles = append(cfHandles, db.cfHandles[cfName])
+	}
+
+	return cfPropsGetter{DB: db.db, cfHandles: cfHandles}
+}
+
+// columnFamilyDB implements dbm.DB interface for one column family of rocksdb database
+type columnFamilyDB struct {
+	db     *grocksdb.DB
+	cf     *grocksdb.ColumnFamilyHandle
+	ro     *grocksdb.ReadOptions
+	wo     *grocksdb.WriteOptions
+	woSync *grocksdb.WriteOptions
+}
+
+var _ dbm.DB = (*columnFamilyDB)(nil)
+
+func newColumnFamilyDB(
+	db *grocksdb.DB,
+	cf *grocksdb.ColumnFamilyHandle,
+	ro *grocksdb.ReadOptions,
+	wo *grocksdb.WriteOptions,
+	woSync *grocksdb.WriteOptions,
+) *columnFamilyDB {
+	return &columnFamilyDB{
+		db:     db,
+		cf:     cf,
+		ro:     ro,
+		wo:     wo,
+		woSync: woSync,
+	}
+}
+
+// Get implements dbm.DB.
+func (db *columnFamilyDB) Get(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, errKeyEmpty
+	}
+	res, err := db.db.GetCF(db.ro, db.cf, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return moveSliceToBytes(res), nil
+}
+
+// Has implements dbm.DB.
+func (db *columnFamilyDB) Has(key []byte) (bool, error) {
+	bytes, err := db.Get(key)
+	if err != nil {
+		return false, err
+	}
+
+	return bytes != nil, nil
+}
+
+// Set implements dbm.DB.
+func (db *columnFamilyDB) Set(key []byte, value []byte) error {
+	return db.set(db.wo, key, value)
+}
+
+// SetSync implements dbm.DB.
+func (db *columnFamilyDB) SetSync(key []byte, value []byte) error {
+	return db.set(db.woSync, key, value)
+}
+
+func (db *columnFamilyDB) set(wo *grocksdb.WriteOptions, key []byte, value []byte) error {
+	if len(key) == 0 {
+		return errKeyEmpty
+	}
+	if value == nil {
+		return errValueNil
+	}
+
+	return db.db.PutCF(wo, db.cf, key, value)
+}
+
+// Delete implements dbm.DB.
+func (db *columnFamilyDB) Delete(key []byte) error {
+	return db.delete(db.wo, key)
+}
+
+// DeleteSync implements dbm.DB.
+func (db *columnFamilyDB) DeleteSync(key []byte) error {
+	return db.delete(db.woSync, key)
+}
+
+func (db *columnFamilyDB) delete(wo *grocksdb.WriteOptions, key []byte) error {
+	if len(key) == 0 {
+		return errKeyEmpty
+	}
+
+	return db.db.DeleteCF(wo, db.cf, key)
+}
+
+// Iterator implements dbm.DB.
+func (db *columnFamilyDB) Iterator(start, end []byte) (dbm.Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, errKeyEmpty
+	}
+	itr := db.db.NewIteratorCF(db.ro, db.cf)
+
+	return newRocksDBIterator(itr, start, end, false), nil
+}
+
+// ReverseIterator implements dbm.DB.
+func (db *columnFamilyDB) ReverseIterator(start, end []byte) (dbm.Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, errKeyEmpty
+	}
+	itr := db.db.NewIteratorCF(db.ro, db.cf)
+
+	return newRocksDBIterator(itr, start, end, true), nil
+}
+
+// Close implements dbm.DB.
+// It's a no-op, column family is closed together with RocksDB it belongs to.
+func (db *columnFamilyDB) Close() error {
+	return nil
+}
+
+// NewBatch implements dbm.DB.
+func (db *columnFamilyDB) NewBatch() dbm.Batch {
+	return newColumnFamilyBatch(db)
+}
+
+// Print implements dbm.DB.
+func (db *columnFamilyDB) Print() error {
+	itr, err := db.Iterator(nil, nil)
+	if err != nil {
+		return err
+	}
+	defer itr.Close()
+	for ; itr.Valid(); itr.Next() {
+		key := itr.Key()
+		value := itr.Value()
+		fmt.Printf("[%X]:\t[%X]\n", key, value)
+	}
+
+	return nil
+}
+
+// Stats implements dbm.DB.
+func (db *columnFamilyDB) Stats() map[string]string {
+	keys := []string{"rocksdb.stats"}
+	stats := make(map[string]string, len(keys))
+	for _, key := range keys {
+		stats[key] = db.db.GetPropertyCF(key, db.cf)
+	}
+
+	return stats
+}
+
+// columnFamilyBatch implements dbm.Batch interface for one column family of rocksdb database
+type columnFamilyBatch struct {
+	db    *columnFamilyDB
+	batch *grocksdb.WriteBatch
+}
+
+var _ dbm.Batch = (*columnFamilyBatch)(nil)
+
+func newColumnFamilyBatch(db *columnFamilyDB) *columnFamilyBatch {
+	return &columnFamilyBatch{
+		db:    db,
+		batch: grocksdb.NewWriteBatch(),
+	}
+}
+
+// Set implements dbm.Batch.
+func (b *columnFamilyBatch) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return errKeyEmpty
+	}
+	if value == nil {
+		return errValueNil
+	}
+	if b.batch == nil {
+		return errBatchClosed
+	}
+	b.batch.PutCF(b.db.cf, key, value)
+
+	return nil
+}
+
+// Delete implements dbm.Batch.
+func (b *columnFamilyBatch) Delete(key []byte) error {
+	if len(key) == 0 {
+		return errKeyEmpty
+	}
+	if b.batch == nil {
+		return errBatchClosed
+	}
+	b.batch.DeleteCF(b.db.cf, key)
+
+	return nil
+}
+
+// Write implements dbm.Batch.
+func (b *columnFamilyBatch) Write() error {
+	return b.write(b.db.wo)
+}
+
+// WriteSync implements dbm.Batch.
+func (b *columnFamilyBatch) WriteSync() error {
+	return b.write(b.db.woSync)
+}
+
+func (b *columnFamilyBatch) write(wo *grocksdb.WriteOptions) error {
+	if b.batch == nil {
+		return errBatchClosed
+	}
+	if err := b.db.db.Write(wo, b.batch); err != nil {
+		return err
+	}
+
+	// Make sure batch cannot be used afterwards. Callers should still call Close(), for errors.
+	return b.Close()
+}
+
+// Close implements dbm.Batch.
+func (b *columnFamilyBatch) Close() error {
+	if b.batch != nil {
+		b.batch.Destroy()
+		b.batch = nil
+	}
+
+	return nil
+}
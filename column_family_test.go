@@ -0,0 +1,87 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnFamilyDB(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	mockAppOpts := newMockAppOptions(map[string]interface{}{
+		columnFamiliesOptName: []string{"nodes"},
+	})
+	db, err := openRocksdb(dir, defaultDBName, mockAppOpts, MetricsConfig{})
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	_, err = db.ColumnFamily("unknown")
+	require.ErrorIs(t, err, ErrColumnFamilyNotFound)
+
+	nodesDB, err := db.ColumnFamily("nodes")
+	require.NoError(t, err)
+
+	// writes to column family aren't visible in default column family and vice versa
+	require.NoError(t, nodesDB.Set([]byte("key1"), []byte("nodes-value1")))
+	require.NoError(t, db.Set([]byte("key1"), []byte("default-value1")))
+
+	value, err := nodesDB.Get([]byte("key1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("nodes-value1"), value)
+
+	value, err = db.Get([]byte("key1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("default-value1"), value)
+
+	// batch writes to column family
+	batch := nodesDB.NewBatch()
+	require.NoError(t, batch.Set([]byte("key2"), []byte("nodes-value2")))
+	require.NoError(t, batch.Set([]byte("key3"), []byte("nodes-value3")))
+	require.NoError(t, batch.Delete([]byte("key1")))
+	require.NoError(t, batch.WriteSync())
+	require.NoError(t, batch.Close())
+
+	has, err := nodesDB.Has([]byte("key1"))
+	require.NoError(t, err)
+	require.False(t, has)
+
+	has, err = db.Has([]byte("key2"))
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// iterate over column family
+	itr, err := nodesDB.Iterator(nil, nil)
+	require.NoError(t, err)
+	var keys []string
+	for ; itr.Valid(); itr.Next() {
+		keys = append(keys, string(itr.Key()))
+	}
+	require.NoError(t, itr.Close())
+	require.Equal(t, []string{"key2", "key3"}, keys)
+
+	itr, err = nodesDB.ReverseIterator(nil, []byte("key3"))
+	require.NoError(t, err)
+	keys = nil
+	for ; itr.Valid(); itr.Next() {
+		keys = append(keys, string(itr.Key()))
+	}
+	require.NoError(t, itr.Close())
+	require.Equal(t, []string{"key2"}, keys)
+
+	require.NoError(t, nodesDB.DeleteSync([]byte("key2")))
+	value, err = nodesDB.Get([]byte("key2"))
+	require.NoError(t, err)
+	require.Nil(t, value)
+}
@@ -0,0 +1,150 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/linxGnu/grocksdb"
+)
+
+const (
+	compactionStyleCFOptName = "compaction_style"
+
+	// universal compaction options, they are applied only if compaction_style is universal
+	universalSizeRatioCFOptName                   = "universal_size_ratio"
+	universalMinMergeWidthCFOptName               = "universal_min_merge_width"
+	universalMaxMergeWidthCFOptName               = "universal_max_merge_width"
+	universalMaxSizeAmplificationPercentCFOptName = "universal_max_size_amplification_percent"
+
+	// fifo compaction options, they are applied only if compaction_style is fifo
+	fifoMaxTableFilesSizeCFOptName = "fifo_max_table_files_size"
+	fifoAllowCompactionCFOptName   = "fifo_allow_compaction"
+
+	// ttlCFOptName is a time to live of data in seconds, with fifo compaction files older than ttl are deleted,
+	// with level compaction files older than ttl are compacted
+	ttlCFOptName = "ttl"
+)
+
+// compactionStyles maps compaction style names accepted in appOpts to rocksdb compaction styles
+var compactionStyles = map[string]grocksdb.CompactionStyle{
+	"level":     grocksdb.LevelCompactionStyle,
+	"universal": grocksdb.UniversalCompactionStyle,
+	"fifo":      grocksdb.FIFOCompactionStyle,
+}
+
+// parseCompactionStyle converts compaction style name into rocksdb compaction style, name is case-insensitive
+func parseCompactionStyle(name string) (grocksdb.CompactionStyle, error) {
+	compactionStyle, ok := compactionStyles[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(compactionStyles))
+		for name := range compactionStyles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return 0, fmt.Errorf("unknown compaction style: %v, should be one of: %v", name, strings.Join(names, ", "))
+	}
+
+	return compactionStyle, nil
+}
+
+// compactionStyleNames maps rocksdb compaction styles to their names in rocksdb options string
+var compactionStyleNames = map[grocksdb.CompactionStyle]string{
+	grocksdb.LevelCompactionStyle:     "kCompactionStyleLevel",
+	grocksdb.UniversalCompactionStyle: "kCompactionStyleUniversal",
+	grocksdb.FIFOCompactionStyle:      "kCompactionStyleFIFO",
+}
+
+// formatCompactionStyle formats compaction style as its name in rocksdb options string
+func formatCompactionStyle(value optionValue) (string, error) {
+	compactionStyle, err := parseCompactionStyle(value.stringVal)
+	if err != nil {
+		return "", err
+	}
+
+	return compactionStyleNames[compactionStyle], nil
+}
+
+func setCompactionStyle(cfOpts *grocksdb.Options, value optionValue) error {
+	compactionStyle, err := parseCompactionStyle(value.stringVal)
+	if err != nil {
+		return err
+	}
+	cfOpts.SetCompactionStyle(compactionStyle)
+
+	return nil
+}
+
+// structField maps option to field of struct option of rocksdb options string, for example size_ratio
+// of compaction_options_universal
+type structField struct {
+	name      string
+	fieldName string
+}
+
+// universalCompactionFields are fields of compaction_options_universal
+var universalCompactionFields = []structField{
+	{name: universalSizeRatioCFOptName, fieldName: "size_ratio"},
+	{name: universalMinMergeWidthCFOptName, fieldName: "min_merge_width"},
+	{name: universalMaxMergeWidthCFOptName, fieldName: "max_merge_width"},
+	{name: universalMaxSizeAmplificationPercentCFOptName, fieldName: "max_size_amplification_percent"},
+}
+
+// fifoCompactionFields are fields of compaction_options_fifo
+var fifoCompactionFields = []structField{
+	{name: fifoMaxTableFilesSizeCFOptName, fieldName: "max_table_files_size"},
+	{name: fifoAllowCompactionCFOptName, fieldName: "allow_compaction"},
+}
+
+// overrideCompactionOpts merges options of compaction style of cfOpts and appOpts, appOpts takes precedence,
+// compaction style itself is applied by its option definition before
+// universal and fifo options are applied by creating new options from string, because rocksdb C API sets fields
+// of their structs only together, while options string updates only specified fields, so fields which aren't specified
+// keep values loaded from OPTIONS file, ttl can be set only by options string as well
+// it returns new options if any of the options is specified, cfOpts are destroyed in such case
+func overrideCompactionOpts(cfOpts *grocksdb.Options, appOpts AppOptions) (*grocksdb.Options, error) {
+	var opts []string
+	structName, fields := "", []structField(nil)
+	switch cfOpts.GetCompactionStyle() {
+	case grocksdb.UniversalCompactionStyle:
+		structName, fields = "compaction_options_universal", universalCompactionFields
+	case grocksdb.FIFOCompactionStyle:
+		structName, fields = "compaction_options_fifo", fifoCompactionFields
+	}
+	var structFields []string
+	for _, field := range fields {
+		value, ok, err := optValue(appOpts, field.name)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			structFields = append(structFields, fmt.Sprintf("%v=%v", field.fieldName, optionDefsByName[field.name].formatValue(value)))
+		}
+	}
+	if len(structFields) != 0 {
+		opts = append(opts, fmt.Sprintf("%v={%v}", structName, strings.Join(structFields, ";")))
+	}
+
+	ttl, ok, err := optValue(appOpts, ttlCFOptName)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		opts = append(opts, fmt.Sprintf("%v=%d", ttlCFOptName, uint64(ttl.duration/time.Second)))
+	}
+
+	if len(opts) == 0 {
+		return cfOpts, nil
+	}
+	newCFOpts, err := optionsFromString(cfOpts, strings.Join(opts, ";"))
+	if err != nil {
+		return nil, fmt.Errorf("can't set compaction options: %w", err)
+	}
+
+	return newCFOpts, nil
+}
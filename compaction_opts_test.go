@@ -0,0 +1,157 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/linxGnu/grocksdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCompactionStyle(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		compactionStyle grocksdb.CompactionStyle
+		success         bool
+	}{
+		{name: "level", compactionStyle: grocksdb.LevelCompactionStyle, success: true},
+		{name: "universal", compactionStyle: grocksdb.UniversalCompactionStyle, success: true},
+		{name: "FIFO", compactionStyle: grocksdb.FIFOCompactionStyle, success: true},
+		{name: "tiered", success: false},
+		{name: "", success: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			compactionStyle, err := parseCompactionStyle(tc.name)
+			if !tc.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.compactionStyle, compactionStyle)
+		})
+	}
+}
+
+func TestOverrideCompactionOpts(t *testing.T) {
+	defaultOpts := newDefaultOptions()
+
+	for _, tc := range []struct {
+		desc            string
+		mockAppOptions  *mockAppOptions
+		compactionStyle grocksdb.CompactionStyle
+		success         bool
+	}{
+		{
+			desc:            "override nothing",
+			mockAppOptions:  newMockAppOptions(map[string]interface{}{}),
+			compactionStyle: defaultOpts.GetCompactionStyle(),
+			success:         true,
+		},
+		{
+			desc: "universal compaction",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				compactionStyleCFOptName:                      "universal",
+				universalSizeRatioCFOptName:                   10,
+				universalMinMergeWidthCFOptName:               4,
+				universalMaxMergeWidthCFOptName:               16,
+				universalMaxSizeAmplificationPercentCFOptName: 150,
+			}),
+			compactionStyle: grocksdb.UniversalCompactionStyle,
+			success:         true,
+		},
+		{
+			desc: "fifo compaction with ttl",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				compactionStyleCFOptName:       "fifo",
+				fifoMaxTableFilesSizeCFOptName: 1 << 30,
+				fifoAllowCompactionCFOptName:   true,
+				ttlCFOptName:                   86400,
+			}),
+			compactionStyle: grocksdb.FIFOCompactionStyle,
+			success:         true,
+		},
+		{
+			desc: "invalid compaction style",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				compactionStyleCFOptName: "tiered",
+			}),
+			success: false,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			cfOpts, err := overrideCFOpts(newDefaultOptions(), tc.mockAppOptions)
+			if !tc.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.compactionStyle, cfOpts.GetCompactionStyle())
+		})
+	}
+}
+
+func TestOpenRocksdbWithFIFOCompaction(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	mockAppOpts := newMockAppOptions(map[string]interface{}{
+		compactionStyleCFOptName:       "fifo",
+		numLevelsCFOptName:             1,
+		fifoMaxTableFilesSizeCFOptName: 1 << 30,
+		ttlCFOptName:                   86400,
+	})
+	db, err := openRocksdb(dir, defaultDBName, mockAppOpts, MetricsConfig{})
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	_, cfOpts, err := LoadLatestOptions(filepath.Join(dir, "application.db"))
+	require.NoError(t, err)
+	require.Equal(t, grocksdb.FIFOCompactionStyle, cfOpts.GetCompactionStyle())
+}
+
+func TestCompactionOptsKeepPersistedValues(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	universalOpts := func(appOpts *mockAppOptions) map[string]string {
+		db, err := openRocksdb(dir, defaultDBName, appOpts, MetricsConfig{})
+		require.NoError(t, err)
+		require.NoError(t, db.Close())
+
+		file, err := loadLatestOptionsFile(filepath.Join(dir, defaultDBName+".db"))
+		require.NoError(t, err)
+
+		return parseOptionsStruct(file.cfOptions(DefaultColumnFamilyName)["compaction_options_universal"])
+	}
+
+	opts := universalOpts(newMockAppOptions(map[string]interface{}{
+		compactionStyleCFOptName:        "universal",
+		universalSizeRatioCFOptName:     10,
+		universalMinMergeWidthCFOptName: 4,
+	}))
+	require.Equal(t, "10", opts["size_ratio"])
+	require.Equal(t, "4", opts["min_merge_width"])
+
+	// size ratio and min merge width are kept if only max size amplification percent is changed
+	opts = universalOpts(newMockAppOptions(map[string]interface{}{
+		compactionStyleCFOptName:                      "universal",
+		universalMaxSizeAmplificationPercentCFOptName: 150,
+	}))
+	require.Equal(t, "10", opts["size_ratio"])
+	require.Equal(t, "4", opts["min_merge_width"])
+	require.Equal(t, "150", opts["max_size_amplification_percent"])
+}
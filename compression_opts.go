@@ -0,0 +1,165 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/linxGnu/grocksdb"
+)
+
+const (
+	compressionCFOptName           = "compression"
+	bottommostCompressionCFOptName = "bottommost_compression"
+	// compressionPerLevelCFOptName is a list of compression types per level, for example:
+	// ["none", "none", "lz4", "lz4", "lz4", "lz4", "zstd"]
+	// it takes precedence over compression option, but not over bottommost_compression
+	compressionPerLevelCFOptName = "compression_per_level"
+	// compressionLevelCFOptName is a compression level, for example ZSTD level,
+	// it's applied to both compression and bottommost_compression
+	compressionLevelCFOptName = "compression_level"
+	// maxDictBytesCFOptName is a maximum size of dictionary used by dictionary compression, 0 disables it
+	maxDictBytesCFOptName = "max_dict_bytes"
+	// zstdMaxTrainBytesCFOptName is a maximum size of data used by ZSTD to train dictionary, 0 disables training
+	zstdMaxTrainBytesCFOptName = "zstd_max_train_bytes"
+)
+
+// compressionTypes maps compression names accepted in appOpts to rocksdb compression types
+var compressionTypes = map[string]grocksdb.CompressionType{
+	"none":   grocksdb.NoCompression,
+	"snappy": grocksdb.SnappyCompression,
+	"zlib":   grocksdb.ZLibCompression,
+	"bz2":    grocksdb.Bz2Compression,
+	"lz4":    grocksdb.LZ4Compression,
+	"lz4hc":  grocksdb.LZ4HCCompression,
+	"xpress": grocksdb.XpressCompression,
+	"zstd":   grocksdb.ZSTDCompression,
+}
+
+// parseCompressionType converts compression name into rocksdb compression type, name is case-insensitive
+func parseCompressionType(name string) (grocksdb.CompressionType, error) {
+	compressionType, ok := compressionTypes[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(compressionTypes))
+		for name := range compressionTypes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return 0, fmt.Errorf("unknown compression type: %v, should be one of: %v", name, strings.Join(names, ", "))
+	}
+
+	return compressionType, nil
+}
+
+// setCompressionType returns setter of compression type option
+func setCompressionType(set func(*grocksdb.Options, grocksdb.CompressionType)) func(*grocksdb.Options, optionValue) error {
+	return func(opts *grocksdb.Options, value optionValue) error {
+		compressionType, err := parseCompressionType(value.stringVal)
+		if err != nil {
+			return err
+		}
+		set(opts, compressionType)
+		return nil
+	}
+}
+
+// parseCompressionPerLevel converts compression names of levels into rocksdb compression types
+func parseCompressionPerLevel(names []string) ([]grocksdb.CompressionType, error) {
+	compressionTypes := make([]grocksdb.CompressionType, len(names))
+	for i, name := range names {
+		compressionType, err := parseCompressionType(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid compression of level %v: %w", i, err)
+		}
+		compressionTypes[i] = compressionType
+	}
+
+	return compressionTypes, nil
+}
+
+// formatCompressionPerLevel formats compression types of levels as value of rocksdb options string,
+// for example kNoCompression:kLZ4Compression
+func formatCompressionPerLevel(value optionValue) (string, error) {
+	compressionTypes, err := parseCompressionPerLevel(value.sliceVal)
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, len(compressionTypes))
+	for i, compressionType := range compressionTypes {
+		names[i] = compressionTypeNames[compressionType]
+	}
+
+	return strings.Join(names, ":"), nil
+}
+
+func validateCompressionPerLevel(value optionValue) error {
+	_, err := parseCompressionPerLevel(value.sliceVal)
+	return err
+}
+
+func setCompressionPerLevel(cfOpts *grocksdb.Options, value optionValue) error {
+	compressionTypes, err := parseCompressionPerLevel(value.sliceVal)
+	if err != nil {
+		return err
+	}
+	cfOpts.SetCompressionPerLevel(compressionTypes)
+
+	return nil
+}
+
+// overrideCompressionOpts merges compression level and dictionary options of cfOpts and appOpts, appOpts takes precedence,
+// compression types are applied by their option definitions
+// options are applied to compression_opts and bottommost_compression_opts structs by creating new options from string,
+// because rocksdb C API sets fields of the structs only together, while options string updates only specified fields,
+// so fields which aren't specified keep values loaded from OPTIONS file
+// bottommost_compression_opts are enabled if bottommost_compression is specified, otherwise they're enabled as persisted
+// it returns new options if any of the options is specified, cfOpts are destroyed in such case
+func overrideCompressionOpts(cfOpts *grocksdb.Options, appOpts AppOptions) (*grocksdb.Options, error) {
+	var fields []string
+	compressionLevel, ok, err := optValue(appOpts, compressionLevelCFOptName)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		fields = append(fields, fmt.Sprintf("level=%d", compressionLevel.intVal))
+	}
+	for _, opt := range []struct {
+		name      string
+		fieldName string
+	}{
+		{name: maxDictBytesCFOptName, fieldName: "max_dict_bytes"},
+		{name: zstdMaxTrainBytesCFOptName, fieldName: "zstd_max_train_bytes"},
+	} {
+		value, ok, err := optValue(appOpts, opt.name)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			fields = append(fields, fmt.Sprintf("%v=%d", opt.fieldName, value.uintVal))
+		}
+	}
+	if len(fields) == 0 {
+		return cfOpts, nil
+	}
+
+	bottommostFields := fields
+	if appOpts.Get(bottommostCompressionCFOptName) != nil {
+		bottommostFields = append(slices.Clip(fields), "enabled=true")
+	}
+	optsStr := fmt.Sprintf(
+		"compression_opts={%v};bottommost_compression_opts={%v}",
+		strings.Join(fields, ";"), strings.Join(bottommostFields, ";"),
+	)
+	newCFOpts, err := optionsFromString(cfOpts, optsStr)
+	if err != nil {
+		return nil, fmt.Errorf("can't set compression options: %w", err)
+	}
+
+	return newCFOpts, nil
+}
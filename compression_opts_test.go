@@ -0,0 +1,166 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linxGnu/grocksdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCompressionType(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		compressionType grocksdb.CompressionType
+		success         bool
+	}{
+		{name: "none", compressionType: grocksdb.NoCompression, success: true},
+		{name: "snappy", compressionType: grocksdb.SnappyCompression, success: true},
+		{name: "lz4", compressionType: grocksdb.LZ4Compression, success: true},
+		{name: "LZ4HC", compressionType: grocksdb.LZ4HCCompression, success: true},
+		{name: "zstd", compressionType: grocksdb.ZSTDCompression, success: true},
+		{name: "gzip", success: false},
+		{name: "", success: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			compressionType, err := parseCompressionType(tc.name)
+			if !tc.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.compressionType, compressionType)
+		})
+	}
+}
+
+func TestOverrideCompressionOpts(t *testing.T) {
+	defaultOpts := newDefaultOptions()
+
+	for _, tc := range []struct {
+		desc                  string
+		mockAppOptions        *mockAppOptions
+		compression           grocksdb.CompressionType
+		bottommostCompression grocksdb.CompressionType
+		zstdMaxTrainBytes     int
+		success               bool
+	}{
+		{
+			desc:                  "override nothing",
+			mockAppOptions:        newMockAppOptions(map[string]interface{}{}),
+			compression:           defaultOpts.GetCompression(),
+			bottommostCompression: defaultOpts.GetBottommostCompression(),
+			zstdMaxTrainBytes:     defaultOpts.GetCompressionOptionsZstdMaxTrainBytes(),
+			success:               true,
+		},
+		{
+			desc: "override compression options",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				compressionCFOptName:           "lz4",
+				bottommostCompressionCFOptName: "zstd",
+				compressionPerLevelCFOptName:   []interface{}{"none", "none", "lz4", "lz4", "lz4", "lz4", "zstd"},
+				compressionLevelCFOptName:      3,
+				maxDictBytesCFOptName:          16384,
+				zstdMaxTrainBytesCFOptName:     1638400,
+			}),
+			compression:           grocksdb.LZ4Compression,
+			bottommostCompression: grocksdb.ZSTDCompression,
+			zstdMaxTrainBytes:     1638400,
+			success:               true,
+		},
+		{
+			desc: "invalid compression",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				compressionCFOptName: "gzip",
+			}),
+			success: false,
+		},
+		{
+			desc: "invalid bottommost compression",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				bottommostCompressionCFOptName: "gzip",
+			}),
+			success: false,
+		},
+		{
+			desc: "invalid compression per level",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				compressionPerLevelCFOptName: []string{"none", "gzip"},
+			}),
+			success: false,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			cfOpts, err := overrideCFOpts(newDefaultOptions(), tc.mockAppOptions)
+			if !tc.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.compression, cfOpts.GetCompression())
+			require.Equal(t, tc.bottommostCompression, cfOpts.GetBottommostCompression())
+			require.Equal(t, tc.zstdMaxTrainBytes, cfOpts.GetCompressionOptionsZstdMaxTrainBytes())
+		})
+	}
+}
+
+func TestCompressionOptsKeepPersistedValues(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	compressionOpts := func(appOpts *mockAppOptions) (map[string]string, map[string]string) {
+		db, err := openRocksdb(dir, defaultDBName, appOpts, MetricsConfig{})
+		require.NoError(t, err)
+		require.NoError(t, db.Close())
+
+		file, err := loadLatestOptionsFile(filepath.Join(dir, defaultDBName+".db"))
+		require.NoError(t, err)
+		cfOpts := file.cfOptions(DefaultColumnFamilyName)
+
+		return parseOptionsStruct(cfOpts["compression_opts"]), parseOptionsStruct(cfOpts["bottommost_compression_opts"])
+	}
+
+	opts, bottommostOpts := compressionOpts(newMockAppOptions(map[string]interface{}{
+		bottommostCompressionCFOptName: "snappy",
+		compressionLevelCFOptName:      5,
+		maxDictBytesCFOptName:          16384,
+	}))
+	require.Equal(t, "5", opts["level"])
+	require.Equal(t, "16384", opts["max_dict_bytes"])
+	require.Equal(t, "5", bottommostOpts["level"])
+	require.Equal(t, "true", bottommostOpts["enabled"])
+
+	// compression level and enabled bottommost options are kept if only max dict bytes is changed
+	opts, bottommostOpts = compressionOpts(newMockAppOptions(map[string]interface{}{
+		maxDictBytesCFOptName: 8192,
+	}))
+	require.Equal(t, "5", opts["level"])
+	require.Equal(t, "8192", opts["max_dict_bytes"])
+	require.Equal(t, "5", bottommostOpts["level"])
+	require.Equal(t, "8192", bottommostOpts["max_dict_bytes"])
+	require.Equal(t, "true", bottommostOpts["enabled"])
+}
+
+// parseOptionsStruct parses value of struct option of OPTIONS file, for example {level=5;enabled=false;}
+func parseOptionsStruct(value string) map[string]string {
+	fields := make(map[string]string)
+	for _, field := range strings.Split(strings.Trim(value, "{}"), ";") {
+		name, fieldValue, ok := strings.Cut(field, "=")
+		if ok {
+			fields[name] = fieldValue
+		}
+	}
+
+	return fields
+}
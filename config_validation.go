@@ -0,0 +1,179 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cast"
+)
+
+const (
+	// configValidationOptName controls what happens if [rocksdb] or [rocksdb.<db>] sections contain
+	// unknown options or values of wrong type: strict fails opening of database, warn (default) logs issues,
+	// off disables validation
+	configValidationOptName = "config-validation"
+	strictConfigValidation  = "strict"
+	warnConfigValidation    = "warn"
+	offConfigValidation     = "off"
+
+	// rocksdbConfigSection is a section of app config which contains rocksdb options
+	rocksdbConfigSection = "rocksdb"
+	// maxSuggestionDistance is a maximum edit distance between unknown option and suggested option
+	maxSuggestionDistance = 3
+)
+
+// ErrInvalidConfiguration is returned if rocksdb configuration is invalid and strict validation is enabled
+var ErrInvalidConfiguration = errors.New("invalid rocksdb configuration")
+
+// ValidateConfig checks [rocksdb] and [rocksdb.<dbName>] sections of appOpts, including column family sections,
+// it returns error which lists unknown options with suggested corrections and invalid values
+// sections of other databases are validated when these databases are opened
+func ValidateConfig(appOpts AppOptions, dbName string) error {
+	issues := validateConfig(appOpts, dbName)
+	if len(issues) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %w", ErrInvalidConfiguration, errors.Join(issues...))
+}
+
+// validateConfig returns issues found in [rocksdb] and [rocksdb.<dbName>] sections of appOpts
+func validateConfig(appOpts AppOptions, dbName string) []error {
+	var issues []error
+
+	fallback := cast.ToStringMap(appOpts.Get(rocksdbConfigSection))
+	for _, key := range sortedKeys(fallback) {
+		value := fallback[key]
+		// tables of the fallback section are sections of databases, only section of dbName is validated
+		if _, isSection := value.(map[string]interface{}); isSection && key != columnFamilyOptsPrefix {
+			continue
+		}
+		issues = append(issues, validateOption(rocksdbConfigSection, key, value, false)...)
+	}
+
+	dbSection := fmt.Sprintf("%v.%v", rocksdbConfigSection, dbName)
+	dbOpts := cast.ToStringMap(appOpts.Get(dbSection))
+	for _, key := range sortedKeys(dbOpts) {
+		issues = append(issues, validateOption(dbSection, key, dbOpts[key], false)...)
+	}
+
+	return issues
+}
+
+// validateOption validates option of section, cf is true if section is a section of column family
+func validateOption(section, key string, value interface{}, cf bool) []error {
+	if key == columnFamilyOptsPrefix && !cf {
+		var issues []error
+		cfSections := cast.ToStringMap(value)
+		for _, cfName := range sortedKeys(cfSections) {
+			cfSection := fmt.Sprintf("%v.%v.%v", section, columnFamilyOptsPrefix, cfName)
+			cfOpts := cast.ToStringMap(cfSections[cfName])
+			for _, cfKey := range sortedKeys(cfOpts) {
+				issues = append(issues, validateOption(cfSection, cfKey, cfOpts[cfKey], true)...)
+			}
+		}
+
+		return issues
+	}
+
+	def, ok := optionDefsByName[normalizeOptName(key)]
+	if !ok {
+		if suggestion := suggestOptName(key, cf); suggestion != "" {
+			return []error{fmt.Errorf("unknown option %v.%v, did you mean %v?", section, key, suggestion)}
+		}
+		return []error{fmt.Errorf("unknown option %v.%v", section, key)}
+	}
+	if cf && def.scope != cfScope {
+		return []error{fmt.Errorf("option %v.%v can't be set for column family", section, key)}
+	}
+	if err := def.typ.check(value); err != nil {
+		return []error{fmt.Errorf("invalid %v.%v: %v should be %v", section, key, value, def.typ)}
+	}
+	if _, err := def.parse(value); err != nil {
+		return []error{fmt.Errorf("invalid %v.%v: %w", section, key, err)}
+	}
+
+	return nil
+}
+
+// suggestOptName returns known option name which is the closest to name, or empty string if there is no close option
+func suggestOptName(name string, cf bool) string {
+	var (
+		suggestion   string
+		bestDistance = maxSuggestionDistance + 1
+	)
+	for _, def := range optionDefs {
+		if cf && def.scope != cfScope {
+			continue
+		}
+
+		distance := editDistance(normalizeOptName(name), normalizeOptName(def.name))
+		if distance < bestDistance {
+			suggestion, bestDistance = def.name, distance
+		}
+	}
+
+	return suggestion
+}
+
+// editDistance returns Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// checkConfig validates configuration of dbName according to config-validation option
+func checkConfig(appOpts AppOptions, dbName string) error {
+	mode := warnConfigValidation
+	if value := newRocksDBOptions(appOpts, dbName).Get(configValidationOptName); value != nil {
+		mode = cast.ToString(value)
+	}
+
+	switch mode {
+	case offConfigValidation:
+		return nil
+	case warnConfigValidation:
+		for _, issue := range validateConfig(appOpts, dbName) {
+			configLogger.Error("invalid rocksdb configuration", "db", dbName, "err", issue)
+		}
+		return nil
+	case strictConfigValidation:
+		return ValidateConfig(appOpts, dbName)
+	default:
+		return fmt.Errorf(
+			"unknown %v: %v, should be %v, %v or %v",
+			configValidationOptName, mode, strictConfigValidation, warnConfigValidation, offConfigValidation,
+		)
+	}
+}
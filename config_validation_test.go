@@ -0,0 +1,157 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfig(t *testing.T) {
+	for _, tc := range []struct {
+		desc           string
+		mockAppOptions *mockAppOptions
+		expectedIssues []string
+	}{
+		{
+			desc: "valid configuration with both spellings",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				"rocksdb": map[string]interface{}{
+					"max_open_files":    4096,
+					"enable-metrics":    "true",
+					"column-families":   []string{"nodes"},
+					"tx_index":          map[string]interface{}{"max-open-files": "bad value of other database"},
+					"cf":                map[string]interface{}{"nodes": map[string]interface{}{"write_buffer_size": 1024}},
+					"compression":       "lz4",
+					"bits_per_key":      10.5,
+					"block_cache_size":  "1GiB",
+					"ttl":               "24h",
+					"config-validation": "strict",
+				},
+				"rocksdb.application": map[string]interface{}{
+					"max-background-jobs": 4,
+					"cf": map[string]interface{}{
+						"nodes": map[string]interface{}{"num-levels": 5},
+					},
+				},
+			}),
+		},
+		{
+			desc: "unknown options with suggestions",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				"rocksdb": map[string]interface{}{
+					"max_open_file":  4096,
+					"enable_metric":  true,
+					"something_else": true,
+				},
+				"rocksdb.application": map[string]interface{}{
+					"block_sise": 4096,
+					"cf": map[string]interface{}{
+						"nodes": map[string]interface{}{"write_bufer_size": 1024},
+					},
+				},
+			}),
+			expectedIssues: []string{
+				"unknown option rocksdb.enable_metric, did you mean enable-metrics?",
+				"unknown option rocksdb.max_open_file, did you mean max-open-files?",
+				"unknown option rocksdb.something_else",
+				"unknown option rocksdb.application.block_sise, did you mean block_size?",
+				"unknown option rocksdb.application.cf.nodes.write_bufer_size, did you mean write-buffer-size?",
+			},
+		},
+		{
+			desc: "values of wrong type and database options of column family",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				"rocksdb": map[string]interface{}{
+					"max-open-files": "many",
+					"use_fsync":      "maybe",
+					"bytes_per_sync": -1,
+					"cf": map[string]interface{}{
+						"nodes": map[string]interface{}{"block_size": 4096},
+					},
+				},
+			}),
+			expectedIssues: []string{
+				"invalid rocksdb.bytes_per_sync: -1 should be size",
+				"option rocksdb.cf.nodes.block_size can't be set for column family",
+				"invalid rocksdb.max-open-files: many should be int",
+				"invalid rocksdb.use_fsync: maybe should be bool",
+			},
+		},
+		{
+			desc: "invalid values of known names",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				"rocksdb": map[string]interface{}{
+					"compression":                        "gzip",
+					"blob_garbage_collection_age_cutoff": 1.5,
+					"metrics-mode":                       "push",
+				},
+			}),
+			expectedIssues: []string{
+				"invalid rocksdb.blob_garbage_collection_age_cutoff: should be in range [0, 1], got 1.5",
+				"invalid rocksdb.compression: unknown compression type: gzip, should be one of: bz2, lz4, lz4hc, none, snappy, xpress, zlib, zstd",
+				"invalid rocksdb.metrics-mode: unknown value: push, should be one of: ticker, scrape",
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			issues := validateConfig(tc.mockAppOptions, "application")
+			var issueMsgs []string
+			for _, issue := range issues {
+				issueMsgs = append(issueMsgs, issue.Error())
+			}
+			require.Equal(t, tc.expectedIssues, issueMsgs)
+
+			err := ValidateConfig(tc.mockAppOptions, "application")
+			if len(tc.expectedIssues) == 0 {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, ErrInvalidConfiguration)
+			}
+		})
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	defer SetConfigLogger(configLogger)
+	logger := &mockLogger{}
+	SetConfigLogger(logger)
+
+	invalidOpts := map[string]interface{}{
+		"rocksdb": map[string]interface{}{"max_open_file": 4096},
+	}
+
+	// warn mode is used by default
+	require.NoError(t, checkConfig(newMockAppOptions(invalidOpts), "application"))
+	require.Len(t, logger.entries, 1)
+
+	invalidOpts["rocksdb.config-validation"] = offConfigValidation
+	require.NoError(t, checkConfig(newMockAppOptions(invalidOpts), "application"))
+	require.Len(t, logger.entries, 1)
+
+	invalidOpts["rocksdb.config-validation"] = strictConfigValidation
+	require.ErrorIs(t, checkConfig(newMockAppOptions(invalidOpts), "application"), ErrInvalidConfiguration)
+
+	invalidOpts["rocksdb.config-validation"] = "unknown"
+	require.Error(t, checkConfig(newMockAppOptions(invalidOpts), "application"))
+}
+
+func TestOptNameSpellings(t *testing.T) {
+	require.Equal(t, []string{"max-open-files", "max_open_files"}, optNameSpellings("max-open-files"))
+	require.Equal(t, []string{"block_size", "block-size"}, optNameSpellings("block_size"))
+	require.Equal(t, []string{"compression"}, optNameSpellings("compression"))
+	require.Equal(
+		t,
+		[]string{"cf.state-sync.num-levels", "cf.state-sync.num_levels"},
+		optNameSpellings("cf.state-sync.num-levels"),
+	)
+}
+
+func TestEditDistance(t *testing.T) {
+	require.Equal(t, 0, editDistance("ttl", "ttl"))
+	require.Equal(t, 1, editDistance("block_sise", "block_size"))
+	require.Equal(t, 3, editDistance("", "ttl"))
+	require.Equal(t, 3, editDistance("kitten", "sitting"))
+}
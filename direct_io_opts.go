@@ -0,0 +1,37 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"fmt"
+
+	"github.com/linxGnu/grocksdb"
+)
+
+const (
+	// useDirectReadsDBOptName enables direct I/O for reads, so user and compaction reads bypass OS page cache
+	useDirectReadsDBOptName = "use_direct_reads"
+	// useDirectIOForFlushAndCompactionDBOptName enables direct I/O for writes of flushes and compactions
+	useDirectIOForFlushAndCompactionDBOptName = "use_direct_io_for_flush_and_compaction"
+	// compactionReadaheadSizeDBOptName is a size of readahead of compaction inputs, it should be set with direct reads
+	compactionReadaheadSizeDBOptName = "compaction_readahead_size"
+	// writableFileMaxBufferSizeDBOptName is a maximum size of buffer of writable files, it's used by direct I/O writes
+	writableFileMaxBufferSizeDBOptName = "writable_file_max_buffer_size"
+
+	// readaheadSizeReadOptName is a size of readahead of iterators, 0 (default) enables auto readahead
+	readaheadSizeReadOptName = "readahead_size"
+)
+
+// checkDirectIOOpts returns error if dbOpts combine mmap and direct I/O, because rocksdb doesn't support it
+// options are checked after merging, because conflicting option may be loaded from existing OPTIONS file
+func checkDirectIOOpts(dbOpts *grocksdb.Options) error {
+	if dbOpts.AllowMmapReads() && dbOpts.UseDirectReads() {
+		return fmt.Errorf("%v can't be used together with %v", allowMMAPReadsDBOptName, useDirectReadsDBOptName)
+	}
+	if dbOpts.AllowMmapWrites() && dbOpts.UseDirectIOForFlushAndCompaction() {
+		return fmt.Errorf("%v can't be used together with %v", allowMMAPWritesDBOptName, useDirectIOForFlushAndCompactionDBOptName)
+	}
+
+	return nil
+}
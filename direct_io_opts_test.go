@@ -0,0 +1,76 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverrideDirectIOOpts(t *testing.T) {
+	for _, tc := range []struct {
+		desc                             string
+		mockAppOptions                   *mockAppOptions
+		useDirectReads                   bool
+		useDirectIOForFlushAndCompaction bool
+		compactionReadaheadSize          uint64
+		success                          bool
+	}{
+		{
+			desc: "direct reads and writes",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				useDirectReadsDBOptName:                   true,
+				useDirectIOForFlushAndCompactionDBOptName: true,
+				compactionReadaheadSizeDBOptName:          2 << 20,
+				writableFileMaxBufferSizeDBOptName:        1 << 20,
+			}),
+			useDirectReads:                   true,
+			useDirectIOForFlushAndCompaction: true,
+			compactionReadaheadSize:          2 << 20,
+			success:                          true,
+		},
+		{
+			desc: "mmap reads and direct writes",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				allowMMAPReadsDBOptName:                   true,
+				useDirectIOForFlushAndCompactionDBOptName: true,
+			}),
+			useDirectIOForFlushAndCompaction: true,
+			success:                          true,
+		},
+		{
+			desc: "mmap reads and direct reads",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				allowMMAPReadsDBOptName: true,
+				useDirectReadsDBOptName: true,
+			}),
+			success: false,
+		},
+		{
+			desc: "mmap writes and direct writes",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				allowMMAPWritesDBOptName:                  true,
+				useDirectIOForFlushAndCompactionDBOptName: true,
+			}),
+			success: false,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			// mmap options are applied by overrideDBOpts before direct I/O options
+			dbOpts, err := overrideDBOpts(newDefaultOptions(), tc.mockAppOptions)
+			if !tc.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.useDirectReads, dbOpts.UseDirectReads())
+			require.Equal(t, tc.useDirectIOForFlushAndCompaction, dbOpts.UseDirectIOForFlushAndCompaction())
+			if tc.compactionReadaheadSize != 0 {
+				require.Equal(t, tc.compactionReadaheadSize, dbOpts.GetCompactionReadaheadSize())
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// OptionSource is a source of value of option applied by opendb
+type OptionSource string
+
+const (
+	// OptionsFileSource means that option isn't specified in config and has no opendb default,
+	// so value of the latest rocksdb OPTIONS file is kept
+	OptionsFileSource OptionSource = "rocksdb OPTIONS file"
+	// RocksDBDefaultSource means that option isn't specified in config, has no opendb default and isn't loaded
+	// from OPTIONS file, for example because database isn't created yet, so rocksdb default is kept
+	RocksDBDefaultSource OptionSource = "rocksdb default"
+	// DefaultSource means that option isn't specified in config, so opendb default is applied, some defaults
+	// are applied only to databases and column families which are created by opendb
+	DefaultSource OptionSource = "opendb default"
+	// FallbackConfigSource means that option is specified in fallback [rocksdb] section of config
+	FallbackConfigSource OptionSource = "[rocksdb]"
+	// DBConfigSource means that option is specified in database-specific [rocksdb.<db>] section of config
+	DBConfigSource OptionSource = "[rocksdb.<db>]"
+)
+
+const (
+	// jsonFormat and tomlFormat are formats supported by WriteEffectiveOptions
+	jsonFormat = "json"
+	tomlFormat = "toml"
+)
+
+// tomlBareKey matches keys which can be written in TOML without quotes
+var tomlBareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// EffectiveOption is a value of option applied by opendb
+type EffectiveOption struct {
+	// ColumnFamily is a name of column family, it's empty for options which aren't column family options
+	ColumnFamily string `json:"column_family,omitempty"`
+	Name         string `json:"name"`
+	// Scope is a set of options which option belongs to: db, cf, bbto, read, write or opendb
+	Scope string `json:"scope"`
+	// Value is a value in canonical form, for example sizes are numbers of bytes, value kept from OPTIONS file
+	// is in format of rocksdb options string, it's empty if rocksdb default is kept
+	Value  string       `json:"value"`
+	Source OptionSource `json:"source"`
+	// Key is a key of config which value is taken from, for example rocksdb.application.cf.nodes.write_buffer_size
+	Key string `json:"key,omitempty"`
+	// OptionsFileValue is a value of the option in the latest OPTIONS file, it's empty if option isn't found there
+	OptionsFileValue string `json:"options_file_value,omitempty"`
+	// DiffersFromOptionsFile is true if value applied by opendb differs from value of the latest OPTIONS file,
+	// for example if config was changed after database was opened
+	DiffersFromOptionsFile bool `json:"differs_from_options_file"`
+	// Mutable is true if option can be changed with SetOptions
+	Mutable bool `json:"mutable"`
+}
+
+// EffectiveOptions returns options applied by opendb to the database, including options changed with SetOptions,
+// column family options are returned for every column family
+func (db *RocksDB) EffectiveOptions() ([]EffectiveOption, error) {
+	db.optsMtx.Lock()
+	defer db.optsMtx.Unlock()
+
+	file, err := loadLatestOptionsFile(db.path)
+	if err != nil {
+		return nil, fmt.Errorf("can't load options file: %w", err)
+	}
+
+	return effectiveOptions(db.appOpts, db.name, db.cfNames, file)
+}
+
+// LoadEffectiveOptions returns options which opendb applies when database dbName in dataDir is opened with appOpts,
+// values are compared with the latest OPTIONS file of the database. Database isn't opened,
+// so it can be used while database is opened by another process.
+func LoadEffectiveOptions(appOpts AppOptions, dataDir string, dbName string) ([]EffectiveOption, error) {
+	file, err := loadLatestOptionsFile(filepath.Join(dataDir, dbName+".db"))
+	// database which doesn't exist yet is opened with rocksdb defaults
+	if err != nil && !errors.Is(err, errOptionsFileNotFound) && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("can't load options file: %w", err)
+	}
+
+	cfNames := []string{DefaultColumnFamilyName}
+	if file != nil {
+		cfNames = file.cfNames()
+	}
+	rocksDBOpts := newRocksDBOptions(appOpts, dbName)
+	for _, cfName := range cast.ToStringSlice(rocksDBOpts.Get(columnFamiliesOptName)) {
+		if !slices.Contains(cfNames, cfName) {
+			cfNames = append(cfNames, cfName)
+		}
+	}
+
+	return effectiveOptions(rocksDBOpts, dbName, cfNames, file)
+}
+
+// effectiveOptions returns options of appOpts in order of optionDefs, options of column families are resolved
+// the same way as when database is opened, file is the latest OPTIONS file, it's nil if database isn't created yet
+func effectiveOptions(appOpts AppOptions, dbName string, cfNames []string, file optionsFile) ([]EffectiveOption, error) {
+	var opts []EffectiveOption
+	for _, def := range optionDefs {
+		var fileOpts map[string]string
+		switch def.scope {
+		case dbScope:
+			fileOpts = file.dbOptions()
+		case bbtoScope:
+			// bbto is shared by column families, so it's compared with bbto of default column family
+			fileOpts = file.bbtoOptions(DefaultColumnFamilyName)
+		case cfScope:
+			for _, cfName := range cfNames {
+				opt, err := effectiveOption(def, newColumnFamilyOptions(appOpts, cfName), dbName, file.cfOptions(cfName))
+				if err != nil {
+					return nil, fmt.Errorf("invalid options of column family %v: %w", cfName, err)
+				}
+				opt.ColumnFamily = cfName
+				opts = append(opts, opt)
+			}
+			continue
+		}
+
+		opt, err := effectiveOption(def, appOpts, dbName, fileOpts)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, opt)
+	}
+
+	return opts, nil
+}
+
+// effectiveOption resolves value of option and compares it with fileOpts, which are options of OPTIONS file section
+// of option scope
+func effectiveOption(def optionDef, appOpts AppOptions, dbName string, fileOpts map[string]string) (EffectiveOption, error) {
+	opt := EffectiveOption{
+		Name:    def.name,
+		Scope:   def.scope.String(),
+		Source:  RocksDBDefaultSource,
+		Mutable: def.mutable,
+	}
+
+	var fileValue string
+	inFile := false
+	if def.rocksdbName != "" {
+		fileValue, inFile = fileOpts[def.rocksdbName]
+	}
+	opt.OptionsFileValue = fileValue
+
+	var (
+		value optionValue
+		err   error
+	)
+	rawValue, key := lookupOpt(appOpts, def.name)
+	switch {
+	case rawValue != nil:
+		opt.Source, opt.Key = configSource(dbName, key), key
+		value, err = def.parse(rawValue)
+		if err != nil {
+			return EffectiveOption{}, fmt.Errorf("invalid %v: %w", def.name, err)
+		}
+	case def.defaultValue != nil:
+		opt.Source = DefaultSource
+		value, err = def.parse(def.defaultValue)
+		if err != nil {
+			return EffectiveOption{}, err
+		}
+	case inFile && (def.scope == dbScope || def.scope == cfScope):
+		// only database and column family options are loaded from OPTIONS file, bbto is created from scratch
+		opt.Source, opt.Value = OptionsFileSource, fileValue
+		return opt, nil
+	case def.newDBDefault != nil && fileOpts == nil:
+		// database or column family which isn't created yet is created with newDBDefault
+		opt.Source = DefaultSource
+		value, err = def.parse(def.newDBDefault)
+		if err != nil {
+			return EffectiveOption{}, err
+		}
+	default:
+		return opt, nil
+	}
+	opt.Value = def.formatValue(value)
+
+	if inFile {
+		rocksdbValue, err := def.formatRocksDBValue(value)
+		if err != nil {
+			return EffectiveOption{}, fmt.Errorf("invalid %v: %w", def.name, err)
+		}
+		opt.DiffersFromOptionsFile = !sameRocksDBValue(rocksdbValue, fileValue)
+	}
+
+	return opt, nil
+}
+
+// configSource returns source of value taken from key of config
+func configSource(dbName string, key string) OptionSource {
+	if strings.HasPrefix(key, fmt.Sprintf("rocksdb.%v.", dbName)) {
+		return DBConfigSource
+	}
+
+	return FallbackConfigSource
+}
+
+// sameRocksDBValue returns true if values of rocksdb options string are equal, numbers are compared by value,
+// because rocksdb writes floating point numbers with fixed precision, for example 10.000000
+func sameRocksDBValue(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	x, errX := strconv.ParseFloat(a, 64)
+	y, errY := strconv.ParseFloat(b, 64)
+	return errX == nil && errY == nil && x == y
+}
+
+// WriteEffectiveOptions writes effective options of databases, which are keyed by database name, in json or toml format,
+// databases are written in order of names
+func WriteEffectiveOptions(w io.Writer, format string, opts map[string][]EffectiveOption) error {
+	switch format {
+	case jsonFormat:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(opts)
+	case tomlFormat:
+		return writeEffectiveOptionsTOML(w, opts)
+	default:
+		return fmt.Errorf("unknown format: %v, should be %v or %v", format, jsonFormat, tomlFormat)
+	}
+}
+
+// writeEffectiveOptionsTOML writes options of every database as TOML tables of scopes and column families,
+// for example [application.cf.default], every option is an inline table
+func writeEffectiveOptionsTOML(w io.Writer, opts map[string][]EffectiveOption) error {
+	dbNames := make([]string, 0, len(opts))
+	for dbName := range opts {
+		dbNames = append(dbNames, dbName)
+	}
+	sort.Strings(dbNames)
+
+	var b strings.Builder
+	for _, dbName := range dbNames {
+		// tables are written in order of their first options
+		var tables []string
+		tableOpts := make(map[string][]EffectiveOption)
+		for _, opt := range opts[dbName] {
+			table := tomlKey(dbName) + "." + opt.Scope
+			if opt.ColumnFamily != "" {
+				table += "." + tomlKey(opt.ColumnFamily)
+			}
+			if _, ok := tableOpts[table]; !ok {
+				tables = append(tables, table)
+			}
+			tableOpts[table] = append(tableOpts[table], opt)
+		}
+
+		for _, table := range tables {
+			if b.Len() > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "[%v]\n", table)
+			for _, opt := range tableOpts[table] {
+				fmt.Fprintf(&b, "%v = { value = %v, source = %v", tomlKey(opt.Name), tomlString(opt.Value), tomlString(string(opt.Source)))
+				if opt.Key != "" {
+					fmt.Fprintf(&b, ", key = %v", tomlString(opt.Key))
+				}
+				if opt.OptionsFileValue != "" {
+					fmt.Fprintf(&b, ", options_file_value = %v", tomlString(opt.OptionsFileValue))
+				}
+				fmt.Fprintf(&b, ", differs_from_options_file = %v, mutable = %v }\n", opt.DiffersFromOptionsFile, opt.Mutable)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// tomlKey quotes key if it can't be written in TOML without quotes
+func tomlKey(key string) string {
+	if tomlBareKey.MatchString(key) {
+		return key
+	}
+
+	return tomlString(key)
+}
+
+// tomlString quotes s as TOML basic string, escape sequences of JSON strings are valid in TOML
+func tomlString(s string) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	// encoding of string can't fail
+	_ = enc.Encode(s)
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
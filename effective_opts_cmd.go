@@ -0,0 +1,103 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// EffectiveOptionsCmdName is a name of subcommand which prints effective options of databases
+const EffectiveOptionsCmdName = "effective-options"
+
+// RunEffectiveOptionsCmd runs effective-options subcommand with args, which don't include name of the subcommand,
+// and writes effective options of databases to w. Config is loaded with load, so the subcommand can be added
+// to CLI of application which loads app.toml with viper.
+func RunEffectiveOptionsCmd(args []string, w io.Writer, load OptionsLoader) error {
+	flags := flag.NewFlagSet(EffectiveOptionsCmdName, flag.ContinueOnError)
+	home := flags.String("home", "", "node home directory, databases are read from <home>/data and config from <home>/config/app.toml")
+	dataDir := flags.String("data-dir", "", "directory of databases, overrides <home>/data")
+	configPath := flags.String("config", "", "config file, overrides <home>/config/app.toml")
+	dbNames := flags.String("db", "", "comma-separated names of databases, all databases of data directory by default")
+	format := flags.String("format", tomlFormat, "output format: toml or json")
+	onlyDiff := flags.Bool("diff", false, "print only options which differ from the latest OPTIONS file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", strings.Join(flags.Args(), " "))
+	}
+
+	if *dataDir == "" && *home != "" {
+		*dataDir = filepath.Join(*home, "data")
+	}
+	if *dataDir == "" {
+		return errors.New("data directory isn't specified, use -home or -data-dir")
+	}
+	if *configPath == "" && *home != "" {
+		*configPath = filepath.Join(*home, "config", "app.toml")
+	}
+
+	var appOpts AppOptions = noAppOptions{}
+	if *configPath != "" {
+		var err error
+		appOpts, err = load(*configPath)
+		if err != nil {
+			return fmt.Errorf("can't load config: %w", err)
+		}
+	}
+
+	var names []string
+	if *dbNames != "" {
+		names = strings.Split(*dbNames, ",")
+	} else {
+		var err error
+		names, err = databaseNames(*dataDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	opts := make(map[string][]EffectiveOption, len(names))
+	for _, name := range names {
+		dbOpts, err := LoadEffectiveOptions(appOpts, *dataDir, name)
+		if err != nil {
+			return fmt.Errorf("can't load effective options of %v: %w", name, err)
+		}
+		if *onlyDiff {
+			dbOpts = slices.DeleteFunc(dbOpts, func(opt EffectiveOption) bool {
+				return !opt.DiffersFromOptionsFile
+			})
+		}
+		opts[name] = dbOpts
+	}
+
+	return WriteEffectiveOptions(w, *format, opts)
+}
+
+// databaseNames returns names of databases in dataDir which have OPTIONS file, for example application for
+// application.db, databases of other backends don't have OPTIONS files, so they are skipped
+func databaseNames(dataDir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dataDir, "*.db", optionsFilePrefix+"*"))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(filepath.Dir(path)), ".db")
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
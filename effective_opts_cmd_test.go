@@ -0,0 +1,87 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunEffectiveOptionsCmd(t *testing.T) {
+	home, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(home)
+		require.NoError(t, err)
+	}()
+
+	// blockstore database doesn't have OPTIONS file, because it's opened with another backend
+	for _, dbName := range []string{"application", "state", "blockstore"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(home, "data", dbName+".db"), 0o755))
+	}
+	for _, dbName := range []string{"application", "state"} {
+		path := filepath.Join(home, "data", dbName+".db", "OPTIONS-000007")
+		require.NoError(t, os.WriteFile(path, []byte(testOptionsFile), 0o644))
+	}
+
+	configPath := filepath.Join(home, "config", "app.toml")
+	load := func(path string) (AppOptions, error) {
+		if path != configPath {
+			return nil, errors.New("unexpected config path")
+		}
+
+		return newMockAppOptions(map[string]interface{}{
+			"rocksdb." + maxOpenFilesDBOptName:             4096,
+			"rocksdb.application." + maxOpenFilesDBOptName: 8192,
+		}), nil
+	}
+
+	names, err := databaseNames(filepath.Join(home, "data"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"application", "state"}, names)
+
+	var b bytes.Buffer
+	require.NoError(t, RunEffectiveOptionsCmd([]string{"-home", home}, &b, load))
+	require.Contains(t, b.String(), "[application.db]\n")
+	require.Contains(t, b.String(), "[state.cf.nodes]\n")
+	require.NotContains(t, b.String(), "[blockstore.")
+
+	// only options which differ from OPTIONS file are printed
+	b.Reset()
+	require.NoError(t, RunEffectiveOptionsCmd([]string{"-home", home, "-db", "application,state", "-format", "json", "-diff"}, &b, load))
+	var opts map[string][]EffectiveOption
+	require.NoError(t, json.Unmarshal(b.Bytes(), &opts))
+	require.Equal(t, map[string][]EffectiveOption{
+		"application": {{
+			Name:                   maxOpenFilesDBOptName,
+			Scope:                  "db",
+			Value:                  "8192",
+			Source:                 DBConfigSource,
+			Key:                    "rocksdb.application." + maxOpenFilesDBOptName,
+			OptionsFileValue:       "4096",
+			DiffersFromOptionsFile: true,
+		}},
+		"state": {},
+	}, opts)
+
+	// config isn't loaded if only data directory is specified
+	b.Reset()
+	require.NoError(t, RunEffectiveOptionsCmd([]string{"-data-dir", filepath.Join(home, "data"), "-db", "state"}, &b, nil))
+	require.Contains(t, b.String(), `max-open-files = { value = "4096", source = "rocksdb OPTIONS file"`)
+
+	for _, args := range [][]string{
+		{},
+		{"-home", home, "-format", "yaml"},
+		{"-home", home, "-config", filepath.Join(home, "missing.toml")},
+		{"-home", home, "application"},
+	} {
+		require.Error(t, RunEffectiveOptionsCmd(args, &b, load), args)
+	}
+}
@@ -0,0 +1,275 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testOptionsFile = `[DBOptions]
+  max_open_files=4096
+  use_fsync=false
+  max_background_jobs=2
+
+[CFOptions "default"]
+  write_buffer_size=67108864
+  level0_slowdown_writes_trigger=20
+  max_bytes_for_level_multiplier=10.000000
+
+[TableOptions/BlockBasedTable "default"]
+  block_size=4096
+
+[CFOptions "nodes"]
+  write_buffer_size=67108864
+  level0_slowdown_writes_trigger=20
+`
+
+func TestEffectiveOptions(t *testing.T) {
+	file, err := parseOptionsFile(strings.NewReader(testOptionsFile))
+	require.NoError(t, err)
+
+	mockAppOpts := newRocksDBOptions(newMockAppOptions(map[string]interface{}{
+		"rocksdb." + maxOpenFilesDBOptName:                                     8192,
+		"rocksdb." + blockCacheSizeBBTOOptName:                                 "2GiB",
+		"rocksdb." + writeBufferSizeCFOptName:                                  "64MiB",
+		"rocksdb." + maxBytesForLevelMultiplierCFOptName:                       10,
+		"rocksdb.application.cf.nodes." + writeBufferSizeCFOptName:             "128MiB",
+		"rocksdb.application.cf.nodes." + level0SlowdownWritesTriggerCFOptName: 30,
+	}), "application")
+
+	opts, err := effectiveOptions(mockAppOpts, "application", []string{DefaultColumnFamilyName, "nodes"}, file)
+	require.NoError(t, err)
+	byName := make(map[string]EffectiveOption, len(opts))
+	for _, opt := range opts {
+		byName[opt.ColumnFamily+"/"+opt.Name] = opt
+	}
+	require.Len(t, byName, len(opts))
+
+	require.Equal(t, EffectiveOption{
+		Name:                   maxOpenFilesDBOptName,
+		Scope:                  "db",
+		Value:                  "8192",
+		Source:                 FallbackConfigSource,
+		Key:                    "rocksdb." + maxOpenFilesDBOptName,
+		OptionsFileValue:       "4096",
+		DiffersFromOptionsFile: true,
+	}, byName["/"+maxOpenFilesDBOptName])
+	require.Equal(t, EffectiveOption{
+		Name:   blockCacheSizeBBTOOptName,
+		Scope:  "bbto",
+		Value:  "2147483648",
+		Source: FallbackConfigSource,
+		Key:    "rocksdb." + blockCacheSizeBBTOOptName,
+	}, byName["/"+blockCacheSizeBBTOOptName])
+	require.Equal(t, EffectiveOption{
+		Name:   reportMetricsIntervalSecsOptName,
+		Scope:  "opendb",
+		Value:  "15s",
+		Source: DefaultSource,
+	}, byName["/"+reportMetricsIntervalSecsOptName])
+	require.Equal(t, EffectiveOption{
+		Name:             useFsyncDBOptName,
+		Scope:            "db",
+		Value:            "false",
+		Source:           OptionsFileSource,
+		OptionsFileValue: "false",
+	}, byName["/"+useFsyncDBOptName])
+	require.Equal(t, EffectiveOption{
+		Name:   walDirDBOptName,
+		Scope:  "db",
+		Source: RocksDBDefaultSource,
+	}, byName["/"+walDirDBOptName])
+	// bbto isn't loaded from OPTIONS file, so rocksdb default is applied
+	require.Equal(t, EffectiveOption{
+		Name:             blockSizeBBTOOptName,
+		Scope:            "bbto",
+		Source:           RocksDBDefaultSource,
+		OptionsFileValue: "4096",
+	}, byName["/"+blockSizeBBTOOptName])
+
+	// column family specific options take precedence over database options
+	require.Equal(t, "67108864", byName[DefaultColumnFamilyName+"/"+writeBufferSizeCFOptName].Value)
+	require.False(t, byName[DefaultColumnFamilyName+"/"+writeBufferSizeCFOptName].DiffersFromOptionsFile)
+	require.Equal(t, OptionsFileSource, byName[DefaultColumnFamilyName+"/"+level0SlowdownWritesTriggerCFOptName].Source)
+	require.Equal(t, EffectiveOption{
+		ColumnFamily:           "nodes",
+		Name:                   writeBufferSizeCFOptName,
+		Scope:                  "cf",
+		Value:                  "134217728",
+		Source:                 DBConfigSource,
+		Key:                    "rocksdb.application.cf.nodes." + writeBufferSizeCFOptName,
+		OptionsFileValue:       "67108864",
+		DiffersFromOptionsFile: true,
+		Mutable:                true,
+	}, byName["nodes/"+writeBufferSizeCFOptName])
+	require.Equal(t, "30", byName["nodes/"+level0SlowdownWritesTriggerCFOptName].Value)
+
+	// numbers are compared by value
+	require.False(t, byName[DefaultColumnFamilyName+"/"+maxBytesForLevelMultiplierCFOptName].DiffersFromOptionsFile)
+
+	_, err = effectiveOptions(newMockAppOptions(map[string]interface{}{
+		compressionCFOptName: "gzip",
+	}), "application", []string{DefaultColumnFamilyName}, nil)
+	require.Error(t, err)
+}
+
+func TestLoadEffectiveOptions(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	dbPath := filepath.Join(dir, "application.db")
+	require.NoError(t, os.Mkdir(dbPath, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dbPath, "OPTIONS-000007"), []byte(testOptionsFile), 0o644))
+
+	mockAppOpts := newMockAppOptions(map[string]interface{}{
+		"rocksdb." + columnFamiliesOptName:                           []string{"nodes", "orphans"},
+		"rocksdb.application." + maxOpenFilesDBOptName:               4096,
+		"rocksdb.application.cf.orphans." + writeBufferSizeCFOptName: "32MiB",
+	})
+	opts, err := LoadEffectiveOptions(mockAppOpts, dir, "application")
+	require.NoError(t, err)
+
+	var cfNames []string
+	for _, opt := range opts {
+		if opt.Name == writeBufferSizeCFOptName {
+			cfNames = append(cfNames, opt.ColumnFamily)
+		}
+	}
+	// column families which don't exist yet are created
+	require.Equal(t, []string{DefaultColumnFamilyName, "nodes", "orphans"}, cfNames)
+
+	idx := slices.IndexFunc(opts, func(opt EffectiveOption) bool {
+		return opt.Name == maxOpenFilesDBOptName
+	})
+	require.NotEqual(t, -1, idx)
+	require.Equal(t, DBConfigSource, opts[idx].Source)
+	require.False(t, opts[idx].DiffersFromOptionsFile)
+
+	// column family which doesn't exist yet is created with opendb defaults
+	idx = slices.IndexFunc(opts, func(opt EffectiveOption) bool {
+		return opt.ColumnFamily == "orphans" && opt.Name == maxWriteBufferNumberCFOptName
+	})
+	require.NotEqual(t, -1, idx)
+	require.Equal(t, EffectiveOption{
+		ColumnFamily: "orphans",
+		Name:         maxWriteBufferNumberCFOptName,
+		Scope:        "cf",
+		Value:        "6",
+		Source:       DefaultSource,
+		Mutable:      true,
+	}, opts[idx])
+
+	// database which doesn't exist yet has no OPTIONS file
+	opts, err = LoadEffectiveOptions(mockAppOpts, dir, "blockstore")
+	require.NoError(t, err)
+	for _, opt := range opts {
+		require.Empty(t, opt.OptionsFileValue, opt.Name)
+		require.NotEqual(t, OptionsFileSource, opt.Source, opt.Name)
+	}
+	// database which doesn't exist yet is created with opendb defaults
+	idx = slices.IndexFunc(opts, func(opt EffectiveOption) bool {
+		return opt.Name == maxOpenFilesDBOptName
+	})
+	require.NotEqual(t, -1, idx)
+	require.Equal(t, EffectiveOption{
+		Name:   maxOpenFilesDBOptName,
+		Scope:  "db",
+		Value:  "4096",
+		Source: DefaultSource,
+	}, opts[idx])
+}
+
+func TestWriteEffectiveOptions(t *testing.T) {
+	opts := map[string][]EffectiveOption{
+		"state": {
+			{Name: maxOpenFilesDBOptName, Scope: "db", Value: "4096", Source: OptionsFileSource, OptionsFileValue: "4096"},
+		},
+		"application": {
+			{Name: maxOpenFilesDBOptName, Scope: "db", Value: "8192", Source: FallbackConfigSource,
+				Key: "rocksdb.max-open-files", OptionsFileValue: "4096", DiffersFromOptionsFile: true, Mutable: true},
+			{ColumnFamily: DefaultColumnFamilyName, Name: numLevelsCFOptName, Scope: "cf", Source: RocksDBDefaultSource},
+			{ColumnFamily: "s/k:nodes", Name: numLevelsCFOptName, Scope: "cf", Value: "5", Source: DBConfigSource,
+				Key: "rocksdb.application.cf.s/k:nodes.num-levels"},
+			{Name: reportMetricsIntervalSecsOptName, Scope: "opendb", Value: "15s", Source: DefaultSource},
+		},
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, WriteEffectiveOptions(&b, "toml", opts))
+	require.Equal(t, `[application.db]
+max-open-files = { value = "8192", source = "[rocksdb]", key = "rocksdb.max-open-files", options_file_value = "4096", differs_from_options_file = true, mutable = true }
+
+[application.cf.default]
+num-levels = { value = "", source = "rocksdb default", differs_from_options_file = false, mutable = false }
+
+[application.cf."s/k:nodes"]
+num-levels = { value = "5", source = "[rocksdb.<db>]", key = "rocksdb.application.cf.s/k:nodes.num-levels", differs_from_options_file = false, mutable = false }
+
+[application.opendb]
+report-metrics-interval-secs = { value = "15s", source = "opendb default", differs_from_options_file = false, mutable = false }
+
+[state.db]
+max-open-files = { value = "4096", source = "rocksdb OPTIONS file", options_file_value = "4096", differs_from_options_file = false, mutable = false }
+`, b.String())
+
+	b.Reset()
+	require.NoError(t, WriteEffectiveOptions(&b, "json", opts))
+	var decoded map[string][]EffectiveOption
+	require.NoError(t, json.Unmarshal(b.Bytes(), &decoded))
+	require.Equal(t, opts, decoded)
+	require.Contains(t, b.String(), `"differs_from_options_file": true`)
+
+	require.Error(t, WriteEffectiveOptions(&b, "yaml", opts))
+}
+
+func TestRocksDBEffectiveOptions(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	mockAppOpts := newMockAppOptions(map[string]interface{}{
+		maxWriteBufferNumberCFOptName: 3,
+	})
+	db, err := openRocksdb(dir, defaultDBName, mockAppOpts, MetricsConfig{})
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	_, err = db.setOptions(newMockAppOptions(map[string]interface{}{
+		maxWriteBufferNumberCFOptName: 4,
+	}))
+	require.NoError(t, err)
+
+	// options changed at runtime are effective and written to OPTIONS file
+	opts, err := db.EffectiveOptions()
+	require.NoError(t, err)
+	idx := slices.IndexFunc(opts, func(opt EffectiveOption) bool {
+		return opt.Name == maxWriteBufferNumberCFOptName
+	})
+	require.NotEqual(t, -1, idx)
+	require.Equal(t, "4", opts[idx].Value)
+	require.Equal(t, "4", opts[idx].OptionsFileValue)
+	require.False(t, opts[idx].DiffersFromOptionsFile)
+
+	// options applied by opendb are the same as options written by rocksdb
+	for _, opt := range opts {
+		require.False(t, opt.DiffersFromOptionsFile, "%v %v: %v != %v", opt.ColumnFamily, opt.Name, opt.Value, opt.OptionsFileValue)
+	}
+}
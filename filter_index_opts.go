@@ -0,0 +1,167 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/linxGnu/grocksdb"
+)
+
+const (
+	// indexTypeBBTOOptName is a type of index: binary_search (default), hash, two_level_index_search
+	// or binary_search_with_first_key, two_level_index_search partitions index to reduce memory usage
+	indexTypeBBTOOptName         = "index_type"
+	binarySearchIndexType        = "binary_search"
+	twoLevelIndexSearchIndexType = "two_level_index_search"
+	// hashIndexType requires prefix extractor, rocksdb silently falls back to binary search index without it
+	hashIndexType = "hash"
+	// partitionFiltersBBTOOptName partitions filters, it requires index_type = two_level_index_search
+	partitionFiltersBBTOOptName = "partition_filters"
+	// metadataBlockSizeBBTOOptName is a target size of index and filter partitions
+	metadataBlockSizeBBTOOptName = "metadata_block_size"
+	// filterTypeBBTOOptName is a type of filter: bloom (default) or ribbon,
+	// ribbon filter uses ~30% less memory than bloom filter with the same false positive rate, but it's slower to build
+	filterTypeBBTOOptName = "filter_type"
+	bloomFilterType       = "bloom"
+	ribbonFilterType      = "ribbon"
+	// ribbonBitsPerKeyBBTOOptName is a bloom equivalent bits per key of ribbon filter, it's bits_per_key by default
+	ribbonBitsPerKeyBBTOOptName = "ribbon_bits_per_key"
+	// wholeKeyFilteringBBTOOptName enables adding whole keys to filter
+	wholeKeyFilteringBBTOOptName = "whole_key_filtering"
+	// optimizeFiltersForMemoryBBTOOptName makes filter sizes fit into memory allocations to reduce internal fragmentation
+	optimizeFiltersForMemoryBBTOOptName = "optimize_filters_for_memory"
+)
+
+// indexTypes maps index type names accepted in appOpts to rocksdb index types
+var indexTypes = map[string]grocksdb.IndexType{
+	binarySearchIndexType:          grocksdb.KBinarySearchIndexType,
+	hashIndexType:                  grocksdb.KHashSearchIndexType,
+	twoLevelIndexSearchIndexType:   grocksdb.KTwoLevelIndexSearchIndexType,
+	"binary_search_with_first_key": grocksdb.KBinarySearchWithFirstKey,
+}
+
+// parseIndexType converts index type name into rocksdb index type
+func parseIndexType(name string) (grocksdb.IndexType, error) {
+	indexType, ok := indexTypes[name]
+	if !ok {
+		names := make([]string, 0, len(indexTypes))
+		for name := range indexTypes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return 0, fmt.Errorf("unknown index type: %v, should be one of: %v", name, strings.Join(names, ", "))
+	}
+
+	return indexType, nil
+}
+
+// filterPolicyFromAppOpts creates filter policy of filter_type, its bits per key can be overridden in appOpts
+// NOTE: caller takes ownership of filter policy
+func filterPolicyFromAppOpts(appOpts AppOptions) (*grocksdb.NativeFilterPolicy, error) {
+	bitsPerKey, _, err := optValue(appOpts, bitsPerKeyBBTOOptName)
+	if err != nil {
+		return nil, err
+	}
+	filterType, _, err := optValue(appOpts, filterTypeBBTOOptName)
+	if err != nil {
+		return nil, err
+	}
+
+	switch filterType.stringVal {
+	case bloomFilterType:
+		return grocksdb.NewBloomFilter(bitsPerKey.floatVal), nil
+	case ribbonFilterType:
+		ribbonBitsPerKey, ok, err := optValue(appOpts, ribbonBitsPerKeyBBTOOptName)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			ribbonBitsPerKey = bitsPerKey
+		}
+
+		return grocksdb.NewRibbonFilterPolicy(ribbonBitsPerKey.floatVal), nil
+	default:
+		return nil, fmt.Errorf(
+			"unknown %v: %v, should be %v or %v",
+			filterTypeBBTOOptName, filterType.stringVal, bloomFilterType, ribbonFilterType,
+		)
+	}
+}
+
+// indexTypeNames maps rocksdb index types to their names in rocksdb options string
+var indexTypeNames = map[grocksdb.IndexType]string{
+	grocksdb.KBinarySearchIndexType:        "kBinarySearch",
+	grocksdb.KHashSearchIndexType:          "kHashSearch",
+	grocksdb.KTwoLevelIndexSearchIndexType: "kTwoLevelIndexSearch",
+	grocksdb.KBinarySearchWithFirstKey:     "kBinarySearchWithFirstKey",
+}
+
+// formatIndexType formats index type as its name in rocksdb options string
+func formatIndexType(value optionValue) (string, error) {
+	indexType, err := parseIndexType(value.stringVal)
+	if err != nil {
+		return "", err
+	}
+
+	return indexTypeNames[indexType], nil
+}
+
+func setIndexType(bbto *grocksdb.BlockBasedTableOptions, value optionValue) error {
+	indexType, err := parseIndexType(value.stringVal)
+	if err != nil {
+		return err
+	}
+	bbto.SetIndexType(indexType)
+
+	return nil
+}
+
+// checkIndexOpts returns error if partitioned filters are enabled without partitioned index
+// or if hash index is used without prefix extractor of every column family of cfNames,
+// prefix extractor is either specified in appOpts or persisted in OPTIONS file, file is nil if database doesn't exist yet,
+// index type is resolved the same way as when bbto is created, so it's binary_search if it isn't specified
+func checkIndexOpts(appOpts AppOptions, cfNames []string, file optionsFile) error {
+	indexType := binarySearchIndexType
+	value, ok, err := optionDefsByName[indexTypeBBTOOptName].value(appOpts)
+	if err != nil {
+		return err
+	}
+	if ok {
+		indexType = value.stringVal
+	}
+
+	partitionFilters, _, err := optionDefsByName[partitionFiltersBBTOOptName].value(appOpts)
+	if err != nil {
+		return err
+	}
+	if partitionFilters.boolVal && indexType != twoLevelIndexSearchIndexType {
+		return fmt.Errorf(
+			"%v requires %v = %v, got %v",
+			partitionFiltersBBTOOptName, indexTypeBBTOOptName, twoLevelIndexSearchIndexType, indexType,
+		)
+	}
+
+	if indexType == hashIndexType {
+		for _, cfName := range cfNames {
+			if newColumnFamilyOptions(appOpts, cfName).Get(prefixExtractorCFOptName) != nil {
+				continue
+			}
+			// rocksdb writes nullptr if column family has no prefix extractor
+			if persisted := file.cfOptions(cfName)[prefixExtractorCFOptName]; persisted != "" && persisted != "nullptr" {
+				continue
+			}
+
+			return fmt.Errorf(
+				"%v = %v requires %v, it isn't specified or persisted for column family %v",
+				indexTypeBBTOOptName, hashIndexType, prefixExtractorCFOptName, cfName,
+			)
+		}
+	}
+
+	return nil
+}
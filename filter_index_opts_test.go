@@ -0,0 +1,264 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"os"
+	"testing"
+
+	"github.com/linxGnu/grocksdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIndexType(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		indexType grocksdb.IndexType
+		success   bool
+	}{
+		{name: "binary_search", indexType: grocksdb.KBinarySearchIndexType, success: true},
+		{name: "hash", indexType: grocksdb.KHashSearchIndexType, success: true},
+		{name: "two_level_index_search", indexType: grocksdb.KTwoLevelIndexSearchIndexType, success: true},
+		{name: "binary_search_with_first_key", indexType: grocksdb.KBinarySearchWithFirstKey, success: true},
+		{name: "partitioned", success: false},
+		{name: "", success: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			indexType, err := parseIndexType(tc.name)
+			if !tc.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.indexType, indexType)
+		})
+	}
+}
+
+func TestFilterPolicyFromAppOpts(t *testing.T) {
+	for _, tc := range []struct {
+		desc           string
+		mockAppOptions *mockAppOptions
+		success        bool
+	}{
+		{
+			desc:           "default bloom filter",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{}),
+			success:        true,
+		},
+		{
+			desc: "ribbon filter",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				filterTypeBBTOOptName:       ribbonFilterType,
+				ribbonBitsPerKeyBBTOOptName: 12,
+			}),
+			success: true,
+		},
+		{
+			desc: "unknown filter type",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				filterTypeBBTOOptName: "cuckoo",
+			}),
+			success: false,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			filterPolicy, err := filterPolicyFromAppOpts(tc.mockAppOptions)
+			if !tc.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, filterPolicy)
+			filterPolicy.Destroy()
+		})
+	}
+}
+
+func TestBBTOFromAppOpts(t *testing.T) {
+	for _, tc := range []struct {
+		desc           string
+		mockAppOptions *mockAppOptions
+		cfNames        []string
+		success        bool
+	}{
+		{
+			desc:           "default options",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{}),
+			success:        true,
+		},
+		{
+			desc: "partitioned index and filters",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				indexTypeBBTOOptName:                twoLevelIndexSearchIndexType,
+				partitionFiltersBBTOOptName:         true,
+				metadataBlockSizeBBTOOptName:        4096,
+				filterTypeBBTOOptName:               ribbonFilterType,
+				wholeKeyFilteringBBTOOptName:        true,
+				optimizeFiltersForMemoryBBTOOptName: true,
+			}),
+			success: true,
+		},
+		{
+			desc: "partitioned filters require partitioned index",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				partitionFiltersBBTOOptName: true,
+			}),
+			success: false,
+		},
+		{
+			desc: "partitioned filters with explicit binary search index",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				indexTypeBBTOOptName:        binarySearchIndexType,
+				partitionFiltersBBTOOptName: true,
+			}),
+			success: false,
+		},
+		{
+			desc: "hash index with prefix extractor of every column family",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				indexTypeBBTOOptName:                   hashIndexType,
+				prefixExtractorCFOptName:               "fixed:2",
+				"cf.nodes." + prefixExtractorCFOptName: "capped:3",
+			}),
+			cfNames: []string{DefaultColumnFamilyName, "nodes"},
+			success: true,
+		},
+		{
+			desc: "hash index without prefix extractor",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				indexTypeBBTOOptName: hashIndexType,
+			}),
+			success: false,
+		},
+		{
+			desc: "hash index without prefix extractor of one column family",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				indexTypeBBTOOptName:                   hashIndexType,
+				"cf.nodes." + prefixExtractorCFOptName: "fixed:2",
+			}),
+			cfNames: []string{DefaultColumnFamilyName, "nodes"},
+			success: false,
+		},
+		{
+			desc: "unknown index type",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				indexTypeBBTOOptName: "partitioned",
+			}),
+			success: false,
+		},
+		{
+			desc: "unknown filter type",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				filterTypeBBTOOptName: "cuckoo",
+			}),
+			success: false,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			blockCache := grocksdb.NewLRUCache(defaultBlockCacheSize)
+			defer blockCache.Destroy()
+
+			cfNames := tc.cfNames
+			if cfNames == nil {
+				cfNames = []string{DefaultColumnFamilyName}
+			}
+			bbto, err := bbtoFromAppOpts(tc.mockAppOptions, cfNames, nil, blockCache)
+			if !tc.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			bbto.Destroy()
+		})
+	}
+}
+
+func TestCheckIndexOpts(t *testing.T) {
+	file := optionsFile{
+		`CFOptions "default"`: {prefixExtractorCFOptName: "rocksdb.FixedPrefix.2"},
+		`CFOptions "nodes"`:   {prefixExtractorCFOptName: "nullptr"},
+	}
+
+	for _, tc := range []struct {
+		desc           string
+		mockAppOptions *mockAppOptions
+		cfNames        []string
+		file           optionsFile
+		success        bool
+	}{
+		{
+			desc: "hash index with prefix extractor persisted in options file",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				indexTypeBBTOOptName: hashIndexType,
+			}),
+			cfNames: []string{DefaultColumnFamilyName},
+			file:    file,
+			success: true,
+		},
+		{
+			desc: "hash index without persisted prefix extractor",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				indexTypeBBTOOptName: hashIndexType,
+			}),
+			cfNames: []string{DefaultColumnFamilyName, "nodes"},
+			file:    file,
+			success: false,
+		},
+		{
+			desc: "hash index with prefix extractor specified for column family without persisted one",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				indexTypeBBTOOptName:                   hashIndexType,
+				"cf.nodes." + prefixExtractorCFOptName: "fixed:2",
+			}),
+			cfNames: []string{DefaultColumnFamilyName, "nodes"},
+			file:    file,
+			success: true,
+		},
+		{
+			desc: "hash index of database which doesn't exist yet",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				indexTypeBBTOOptName: hashIndexType,
+			}),
+			cfNames: []string{DefaultColumnFamilyName},
+			file:    nil,
+			success: false,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := checkIndexOpts(tc.mockAppOptions, tc.cfNames, tc.file)
+			if !tc.success {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestHashIndexWithPersistedPrefixExtractor(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	db, err := openRocksdb(dir, defaultDBName, newMockAppOptions(map[string]interface{}{
+		indexTypeBBTOOptName:     hashIndexType,
+		prefixExtractorCFOptName: "fixed:2",
+	}), MetricsConfig{})
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// prefix extractor is kept in OPTIONS file, so it doesn't have to be specified again
+	db, err = openRocksdb(dir, defaultDBName, newMockAppOptions(map[string]interface{}{
+		indexTypeBBTOOptName: hashIndexType,
+	}), MetricsConfig{})
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+}
@@ -0,0 +1,153 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"sync"
+
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+// histogramMetric describes rocksdb histogram which is reported as prometheus summary
+type histogramMetric struct {
+	desc *stdprometheus.Desc
+	// histogram returns histogram from predefined statistics, it's nil if histogram can't be loaded
+	histogram func(stats *stats) *float64Histogram
+}
+
+// histogramCollector is a prometheus collector which exposes rocksdb histograms as summaries,
+// quantiles are calculated by rocksdb, so they are exposed as is with quantile label.
+// Unlike separate gauge per percentile, summaries can be aggregated across nodes.
+type histogramCollector struct {
+	histograms []histogramMetric
+
+	mtx sync.RWMutex
+	// stats contains latest statistics per database name
+	stats map[string]*stats
+}
+
+var _ stdprometheus.Collector = (*histogramCollector)(nil)
+
+func newHistogramCollector(namespace string, constLabels stdprometheus.Labels) *histogramCollector {
+	newDesc := func(subsystem, name, help string) *stdprometheus.Desc {
+		return stdprometheus.NewDesc(
+			stdprometheus.BuildFQName(namespace, subsystem, name),
+			help,
+			[]string{dbNameMetricLabelName},
+			constLabels,
+		)
+	}
+
+	return &histogramCollector{
+		histograms: []histogramMetric{
+			// Latency
+			{
+				desc:      newDesc("latency", "db_get_micros", "time spent in Get() calls in microseconds"),
+				histogram: func(stats *stats) *float64Histogram { return stats.DBGetMicros },
+			},
+			{
+				desc:      newDesc("latency", "db_write_micros", "time spent in Write() calls in microseconds"),
+				histogram: func(stats *stats) *float64Histogram { return stats.DBWriteMicros },
+			},
+
+			// Write Stall
+			{
+				desc:      newDesc("stall", "db_write_stall", "time writer has to wait for compaction or flush to finish in microseconds"),
+				histogram: func(stats *stats) *float64Histogram { return stats.DBWriteStallHistogram },
+			},
+
+			// Flush
+			{
+				desc:      newDesc("flush", "flush_micros", "time spent flushing memtable to disk in microseconds"),
+				histogram: func(stats *stats) *float64Histogram { return stats.FlushMicros },
+			},
+
+			// Compaction
+			{
+				desc:      newDesc("compaction", "compaction_times_micros", "time spent in compactions in microseconds"),
+				histogram: func(stats *stats) *float64Histogram { return stats.CompactionTimesMicros },
+			},
+			{
+				desc:      newDesc("compaction", "compaction_times_cpu_micros", "cpu time spent in compactions in microseconds"),
+				histogram: func(stats *stats) *float64Histogram { return stats.CompactionTimesCPUMicros },
+			},
+			{
+				desc:      newDesc("compaction", "num_files_in_single_compaction", "number of files in a single compaction"),
+				histogram: func(stats *stats) *float64Histogram { return stats.NumFilesInSingleCompaction },
+			},
+
+			// WAL
+			{
+				desc:      newDesc("wal", "wal_file_sync_micros", "time spent syncing WAL files in microseconds"),
+				histogram: func(stats *stats) *float64Histogram { return stats.WALFileSyncMicros },
+			},
+
+			// Bytes per operation
+			{
+				desc:      newDesc("io", "bytes_per_read", "size of value in bytes per Get() call"),
+				histogram: func(stats *stats) *float64Histogram { return stats.BytesPerRead },
+			},
+			{
+				desc:      newDesc("io", "bytes_per_write", "size of values in bytes per Write() call"),
+				histogram: func(stats *stats) *float64Histogram { return stats.BytesPerWrite },
+			},
+			{
+				desc:      newDesc("io", "bytes_per_multiget", "size of values in bytes per MultiGet() call"),
+				histogram: func(stats *stats) *float64Histogram { return stats.BytesPerMultiget },
+			},
+		},
+		stats: make(map[string]*stats),
+	}
+}
+
+// update replaces statistics of the database, they will be exposed on the next scrape
+func (c *histogramCollector) update(dbName string, stats *stats) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.stats[dbName] = stats
+}
+
+// remove removes statistics of the database, it should be called when database is closed
+func (c *histogramCollector) remove(dbName string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	delete(c.stats, dbName)
+}
+
+// Describe implements prometheus.Collector.
+func (c *histogramCollector) Describe(ch chan<- *stdprometheus.Desc) {
+	for _, histogram := range c.histograms {
+		ch <- histogram.desc
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *histogramCollector) Collect(ch chan<- stdprometheus.Metric) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	for dbName, stats := range c.stats {
+		for _, histogram := range c.histograms {
+			value := histogram.histogram(stats)
+			if value == nil {
+				continue
+			}
+
+			ch <- stdprometheus.MustNewConstSummary(
+				histogram.desc,
+				uint64(value.Count),
+				value.Sum,
+				map[float64]float64{
+					quantiles[p50]:  value.P50,
+					quantiles[p95]:  value.P95,
+					quantiles[p99]:  value.P99,
+					quantiles[p100]: value.P100,
+				},
+				dbName,
+			)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"strings"
+	"testing"
+
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistogramCollector(t *testing.T) {
+	collector := newHistogramCollector("rocksdb_v2", stdprometheus.Labels{"chain_id": "kava_2222-10"})
+	collector.update("application", &stats{
+		DBGetMicros: &float64Histogram{
+			Sum:   6,
+			Count: 5,
+			P50:   1,
+			P95:   2,
+			P99:   3,
+			P100:  4,
+		},
+	})
+
+	expected := `
+# HELP rocksdb_v2_latency_db_get_micros time spent in Get() calls in microseconds
+# TYPE rocksdb_v2_latency_db_get_micros summary
+rocksdb_v2_latency_db_get_micros{chain_id="kava_2222-10",db_name="application",quantile="0.5"} 1
+rocksdb_v2_latency_db_get_micros{chain_id="kava_2222-10",db_name="application",quantile="0.95"} 2
+rocksdb_v2_latency_db_get_micros{chain_id="kava_2222-10",db_name="application",quantile="0.99"} 3
+rocksdb_v2_latency_db_get_micros{chain_id="kava_2222-10",db_name="application",quantile="1"} 4
+rocksdb_v2_latency_db_get_micros_sum{chain_id="kava_2222-10",db_name="application"} 6
+rocksdb_v2_latency_db_get_micros_count{chain_id="kava_2222-10",db_name="application"} 5
+`
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+
+	// statistics of closed database aren't reported anymore
+	collector.remove("application")
+	require.Equal(t, 0, testutil.CollectAndCount(collector))
+}
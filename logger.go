@@ -0,0 +1,62 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// configLogger logs issues of rocksdb configuration found when database is opened
+var configLogger Logger = stdLogger{}
+
+// SetConfigLogger sets logger which logs issues of rocksdb configuration found when database is opened,
+// issues are written to stderr by default, it should be called before databases are opened
+func SetConfigLogger(logger Logger) {
+	configLogger = logger
+}
+
+// Logger logs runtime changes of rocksdb options and configuration issues,
+// it's compatible with cometbft and cosmos-sdk loggers
+type Logger interface {
+	Info(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+}
+
+// nopLogger is used if logger isn't set
+type nopLogger struct{}
+
+var _ Logger = nopLogger{}
+
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// stdLogger writes log entries to stderr with standard log package
+type stdLogger struct{}
+
+var _ Logger = stdLogger{}
+
+func (stdLogger) Info(msg string, keyvals ...interface{}) {
+	log.Print(formatLogEntry("INF", msg, keyvals))
+}
+
+func (stdLogger) Error(msg string, keyvals ...interface{}) {
+	log.Print(formatLogEntry("ERR", msg, keyvals))
+}
+
+// formatLogEntry formats log entry in logfmt-like format: level msg key=value ...
+func formatLogEntry(level string, msg string, keyvals []interface{}) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v %v", level, msg)
+	for i := 0; i < len(keyvals); i += 2 {
+		var value interface{} = "(missing)"
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		fmt.Fprintf(&b, " %v=%v", keyvals[i], value)
+	}
+
+	return b.String()
+}
@@ -4,6 +4,10 @@
 package opendb
 
 import (
+	"strconv"
+	"sync"
+	"time"
+
 	"github.com/go-kit/kit/metrics"
 	"github.com/go-kit/kit/metrics/prometheus"
 	stdprometheus "github.com/prometheus/client_golang/prometheus"
@@ -11,494 +15,451 @@ import (
 
 const (
 	dbNameMetricLabelName = "db_name"
+	statMetricLabelName   = "stat"
+	levelMetricLabelName  = "level"
 )
 
-// rocksdbMetrics will be initialized in registerMetrics() if enableRocksdbMetrics flag set to true
-var rocksdbMetrics *Metrics
+var (
+	// registeredMetricsMtx guards registeredMetrics and registeredStatsCollectors
+	registeredMetricsMtx sync.Mutex
+	// registeredMetrics contains metrics registered by registerMetrics() per MetricsConfig,
+	// databases opened with equal configs share metrics
+	registeredMetrics = make(map[metricsKey]*Metrics)
+)
 
 // Metrics contains all rocksdb metrics which will be reported to prometheus
 type Metrics struct {
 	// Keys
-	NumberKeysWritten metrics.Gauge
-	NumberKeysRead    metrics.Gauge
-	NumberKeysUpdated metrics.Gauge
-	EstimateNumKeys   metrics.Gauge
-
-	// Files
-	NumberFileOpens  metrics.Gauge
-	NumberFileErrors metrics.Gauge
+	EstimateNumKeys metrics.Gauge
 
 	// Memory
 	BlockCacheUsage         metrics.Gauge
 	EstimateTableReadersMem metrics.Gauge
 	CurSizeAllMemTables     metrics.Gauge
+	SizeAllMemTables        metrics.Gauge
 	BlockCachePinnedUsage   metrics.Gauge
 
-	// Cache
-	BlockCacheMiss        metrics.Gauge
-	BlockCacheHit         metrics.Gauge
-	BlockCacheAdd         metrics.Gauge
-	BlockCacheAddFailures metrics.Gauge
-
-	// Detailed Cache
-	BlockCacheIndexMiss        metrics.Gauge
-	BlockCacheIndexHit         metrics.Gauge
-	BlockCacheIndexBytesInsert metrics.Gauge
-
-	BlockCacheFilterMiss        metrics.Gauge
-	BlockCacheFilterHit         metrics.Gauge
-	BlockCacheFilterBytesInsert metrics.Gauge
-
-	BlockCacheDataMiss        metrics.Gauge
-	BlockCacheDataHit         metrics.Gauge
-	BlockCacheDataBytesInsert metrics.Gauge
-
-	// Latency
-	DBGetMicrosP50   metrics.Gauge
-	DBGetMicrosP95   metrics.Gauge
-	DBGetMicrosP99   metrics.Gauge
-	DBGetMicrosP100  metrics.Gauge
-	DBGetMicrosCount metrics.Gauge
-
-	DBWriteMicrosP50   metrics.Gauge
-	DBWriteMicrosP95   metrics.Gauge
-	DBWriteMicrosP99   metrics.Gauge
-	DBWriteMicrosP100  metrics.Gauge
-	DBWriteMicrosCount metrics.Gauge
-
-	// Write Stall
-	StallMicros metrics.Gauge
-
-	DBWriteStallP50   metrics.Gauge
-	DBWriteStallP95   metrics.Gauge
-	DBWriteStallP99   metrics.Gauge
-	DBWriteStallP100  metrics.Gauge
-	DBWriteStallCount metrics.Gauge
-	DBWriteStallSum   metrics.Gauge
-
-	// Bloom Filter
-	BloomFilterUseful           metrics.Gauge
-	BloomFilterFullPositive     metrics.Gauge
-	BloomFilterFullTruePositive metrics.Gauge
-
-	// LSM Tree Stats
-	LastLevelReadBytes    metrics.Gauge
-	LastLevelReadCount    metrics.Gauge
-	NonLastLevelReadBytes metrics.Gauge
-	NonLastLevelReadCount metrics.Gauge
-
-	GetHitL0      metrics.Gauge
-	GetHitL1      metrics.Gauge
-	GetHitL2AndUp metrics.Gauge
+	// Compaction and Flush Pressure
+	CompactionPending              metrics.Gauge
+	EstimatePendingCompactionBytes metrics.Gauge
+	NumRunningCompactions          metrics.Gauge
+	NumRunningFlushes              metrics.Gauge
+	MemTableFlushPending           metrics.Gauge
+	ActualDelayedWriteRate         metrics.Gauge
+	IsWriteStopped                 metrics.Gauge
+	WriteStoppedOrDelayed          metrics.Gauge
+
+	// LSM Levels
+	BaseLevel               metrics.Gauge
+	NumFilesAtLevel         metrics.Gauge
+	CompressionRatioAtLevel metrics.Gauge
+	LevelNumCompactingFiles metrics.Gauge
+	LevelSizeBytes          metrics.Gauge
+	LevelScore              metrics.Gauge
+	LevelWriteAmp           metrics.Gauge
+
+	// WAL
+	NumWALFiles          metrics.Gauge
+	WALFilesSize         metrics.Gauge
+	NumArchivedWALFiles  metrics.Gauge
+	ArchivedWALFilesSize metrics.Gauge
+
+	// Blob Files
+	NumBlobFiles            metrics.Gauge
+	TotalBlobFileSize       metrics.Gauge
+	LiveBlobFileSize        metrics.Gauge
+	LiveBlobFileGarbageSize metrics.Gauge
+
+	// Loading
+	LoadFailures metrics.Counter
+
+	// tickers reports rocksdb tickers as counters
+	tickers *tickerCollector
+	// histograms reports rocksdb histograms as summaries
+	histograms *histogramCollector
+	// shared reports memory usage of resources shared by databases
+	shared *sharedResourcesCollector
+	// scrapers refreshes metrics of databases which are reported at scrape time
+	scrapers *scrapers
+	// vecs contains vectors of gauges and counters labeled with database name,
+	// series of the database are deleted from them when database is closed
+	vecs []*stdprometheus.MetricVec
 }
 
-// registerMetrics registers metrics in prometheus and initializes rocksdbMetrics variable
-func registerMetrics() {
-	if rocksdbMetrics != nil {
-		// metrics already registered
-		return
+// registerMetrics registers metrics in prometheus according to config,
+// if metrics are already registered for equal config, registered metrics are returned
+func registerMetrics(config MetricsConfig) (*Metrics, error) {
+	registeredMetricsMtx.Lock()
+	defer registeredMetricsMtx.Unlock()
+
+	key := config.key()
+	if metrics, ok := registeredMetrics[key]; ok {
+		return metrics, nil
 	}
 
-	namespace := "rocksdb_v2"
+	var collectors []stdprometheus.Collector
+	var vecs []*stdprometheus.MetricVec
+	newGauge := func(opts stdprometheus.GaugeOpts, labelNames []string) metrics.Gauge {
+		opts.ConstLabels = config.ConstLabels
+		gaugeVec := stdprometheus.NewGaugeVec(opts, labelNames)
+		collectors = append(collectors, gaugeVec)
+		vecs = append(vecs, gaugeVec.MetricVec)
+		return prometheus.NewGauge(gaugeVec)
+	}
+	newCounter := func(opts stdprometheus.CounterOpts, labelNames []string) metrics.Counter {
+		opts.ConstLabels = config.ConstLabels
+		counterVec := stdprometheus.NewCounterVec(opts, labelNames)
+		collectors = append(collectors, counterVec)
+		vecs = append(vecs, counterVec.MetricVec)
+		return prometheus.NewCounter(counterVec)
+	}
+
+	namespace := config.namespace()
 	labels := []string{dbNameMetricLabelName}
-	rocksdbMetrics = &Metrics{
+	levelLabels := []string{dbNameMetricLabelName, levelMetricLabelName}
+	rocksdbMetrics := &Metrics{
 		// Keys
-		NumberKeysWritten: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "key",
-			Name:      "number_keys_written",
-			Help:      "",
-		}, labels),
-		NumberKeysRead: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "key",
-			Name:      "number_keys_read",
-			Help:      "",
-		}, labels),
-		NumberKeysUpdated: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "key",
-			Name:      "number_keys_updated",
-			Help:      "",
-		}, labels),
-		EstimateNumKeys: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		EstimateNumKeys: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
 			Subsystem: "key",
 			Name:      "estimate_num_keys",
 			Help:      "estimated number of total keys in the active and unflushed immutable memtables and storage",
 		}, labels),
 
-		// Files
-		NumberFileOpens: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "file",
-			Name:      "number_file_opens",
-			Help:      "",
-		}, labels),
-		NumberFileErrors: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "file",
-			Name:      "number_file_errors",
-			Help:      "",
-		}, labels),
-
 		// Memory
-		BlockCacheUsage: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		BlockCacheUsage: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
 			Subsystem: "memory",
 			Name:      "block_cache_usage",
 			Help:      "memory size for the entries residing in block cache",
 		}, labels),
-		EstimateTableReadersMem: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		EstimateTableReadersMem: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
 			Subsystem: "memory",
 			Name:      "estimate_table_readers_mem",
-			Help:      "estimated memory used for reading SST tables, excluding memory used in block cache (e.g., filter and index blocks)",
+			Help:      "estimated memory used for reading SST tables of all column families, excluding memory used in block cache (e.g., filter and index blocks)",
 		}, labels),
-		CurSizeAllMemTables: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		CurSizeAllMemTables: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
 			Subsystem: "memory",
 			Name:      "cur_size_all_mem_tables",
-			Help:      "approximate size of active and unflushed immutable memtables (bytes)",
+			Help:      "approximate size of active and unflushed immutable memtables of all column families (bytes)",
 		}, labels),
-		BlockCachePinnedUsage: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		SizeAllMemTables: newGauge(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "memory",
+			Name:      "size_all_mem_tables",
+			Help:      "approximate size of active, unflushed and pinned immutable memtables of all column families (bytes), it's charged to write buffer manager",
+		}, labels),
+		BlockCachePinnedUsage: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
 			Subsystem: "memory",
 			Name:      "block_cache_pinned_usage",
 			Help:      "returns the memory size for the entries being pinned",
 		}, labels),
 
-		// Cache
-		BlockCacheMiss: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "cache",
-			Name:      "block_cache_miss",
-			Help:      "block_cache_miss == block_cache_index_miss + block_cache_filter_miss + block_cache_data_miss",
-		}, labels),
-		BlockCacheHit: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "cache",
-			Name:      "block_cache_hit",
-			Help:      "block_cache_hit == block_cache_index_hit + block_cache_filter_hit + block_cache_data_hit",
-		}, labels),
-		BlockCacheAdd: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		// Compaction and Flush Pressure
+		CompactionPending: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "cache",
-			Name:      "block_cache_add",
-			Help:      "number of blocks added to block cache",
+			Subsystem: "compaction",
+			Name:      "pending",
+			Help:      "1 if at least one compaction is pending, otherwise 0",
 		}, labels),
-		BlockCacheAddFailures: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		EstimatePendingCompactionBytes: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "cache",
-			Name:      "block_cache_add_failures",
-			Help:      "number of failures when adding blocks to block cache",
+			Subsystem: "compaction",
+			Name:      "estimate_pending_compaction_bytes",
+			Help:      "estimated total number of bytes compaction needs to rewrite to get all levels down to under target size",
 		}, labels),
-
-		// Detailed Cache
-		BlockCacheIndexMiss: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		NumRunningCompactions: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "detailed_cache",
-			Name:      "block_cache_index_miss",
-			Help:      "",
+			Subsystem: "compaction",
+			Name:      "num_running_compactions",
+			Help:      "number of currently running compactions",
 		}, labels),
-		BlockCacheIndexHit: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		NumRunningFlushes: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "detailed_cache",
-			Name:      "block_cache_index_hit",
-			Help:      "",
+			Subsystem: "compaction",
+			Name:      "num_running_flushes",
+			Help:      "number of currently running flushes",
 		}, labels),
-		BlockCacheIndexBytesInsert: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		MemTableFlushPending: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "detailed_cache",
-			Name:      "block_cache_index_bytes_insert",
-			Help:      "",
+			Subsystem: "compaction",
+			Name:      "mem_table_flush_pending",
+			Help:      "1 if a memtable flush is pending, otherwise 0",
 		}, labels),
-
-		BlockCacheFilterMiss: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		ActualDelayedWriteRate: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "detailed_cache",
-			Name:      "block_cache_filter_miss",
-			Help:      "",
+			Subsystem: "stall",
+			Name:      "actual_delayed_write_rate",
+			Help:      "current write rate in bytes per second when writes are delayed, 0 means no delay",
 		}, labels),
-		BlockCacheFilterHit: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		IsWriteStopped: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "detailed_cache",
-			Name:      "block_cache_filter_hit",
-			Help:      "",
+			Subsystem: "stall",
+			Name:      "is_write_stopped",
+			Help:      "1 if writes have been stopped, otherwise 0",
 		}, labels),
-		BlockCacheFilterBytesInsert: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		WriteStoppedOrDelayed: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "detailed_cache",
-			Name:      "block_cache_filter_bytes_insert",
-			Help:      "",
+			Subsystem: "stall",
+			Name:      "write_stopped_or_delayed",
+			Help:      "1 if writes are currently stopped or delayed, otherwise 0",
 		}, labels),
 
-		BlockCacheDataMiss: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		// LSM Levels
+		BaseLevel: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "detailed_cache",
-			Name:      "block_cache_data_miss",
-			Help:      "",
-		}, labels),
-		BlockCacheDataHit: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "detailed_cache",
-			Name:      "block_cache_data_hit",
-			Help:      "",
+			Subsystem: "lsm",
+			Name:      "base_level",
+			Help:      "number of level to which L0 data will be compacted",
 		}, labels),
-		BlockCacheDataBytesInsert: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		NumFilesAtLevel: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "detailed_cache",
-			Name:      "block_cache_data_bytes_insert",
-			Help:      "",
-		}, labels),
-
-		// Latency
-		DBGetMicrosP50: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Subsystem: "lsm",
+			Name:      "num_files_at_level",
+			Help:      "number of files at level",
+		}, levelLabels),
+		CompressionRatioAtLevel: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "latency",
-			Name:      "db_get_micros_p50",
-			Help:      "",
-		}, labels),
-		DBGetMicrosP95: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Subsystem: "lsm",
+			Name:      "compression_ratio_at_level",
+			Help:      "compression ratio of data at level, -1 if there are no files at level",
+		}, levelLabels),
+		LevelNumCompactingFiles: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "latency",
-			Name:      "db_get_micros_p95",
-			Help:      "",
-		}, labels),
-		DBGetMicrosP99: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Subsystem: "lsm",
+			Name:      "level_num_compacting_files",
+			Help:      "number of files being compacted at level, level=sum is a total over all levels",
+		}, levelLabels),
+		LevelSizeBytes: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "latency",
-			Name:      "db_get_micros_p99",
-			Help:      "",
-		}, labels),
-		DBGetMicrosP100: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Subsystem: "lsm",
+			Name:      "level_size_bytes",
+			Help:      "total size of files at level, level=sum is a total over all levels",
+		}, levelLabels),
+		LevelScore: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "latency",
-			Name:      "db_get_micros_p100",
-			Help:      "",
-		}, labels),
-		DBGetMicrosCount: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Subsystem: "lsm",
+			Name:      "level_score",
+			Help:      "compaction score of level, level with score > 1 needs compaction",
+		}, levelLabels),
+		LevelWriteAmp: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "latency",
-			Name:      "db_get_micros_count",
-			Help:      "",
-		}, labels),
+			Subsystem: "lsm",
+			Name:      "level_write_amp",
+			Help:      "write amplification of level, level=sum is a write amplification of database",
+		}, levelLabels),
 
-		DBWriteMicrosP50: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		// WAL
+		NumWALFiles: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "latency",
-			Name:      "db_write_micros_p50",
-			Help:      "",
+			Subsystem: "wal",
+			Name:      "num_wal_files",
+			Help:      "number of live WAL files",
 		}, labels),
-		DBWriteMicrosP95: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		WALFilesSize: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "latency",
-			Name:      "db_write_micros_p95",
-			Help:      "",
+			Subsystem: "wal",
+			Name:      "wal_files_size",
+			Help:      "total size of live WAL files",
 		}, labels),
-		DBWriteMicrosP99: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		NumArchivedWALFiles: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "latency",
-			Name:      "db_write_micros_p99",
-			Help:      "",
+			Subsystem: "wal",
+			Name:      "num_archived_wal_files",
+			Help:      "number of obsolete WAL files kept in archive according to wal_ttl_seconds and wal_size_limit_mb",
 		}, labels),
-		DBWriteMicrosP100: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		ArchivedWALFilesSize: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "latency",
-			Name:      "db_write_micros_p100",
-			Help:      "",
-		}, labels),
-		DBWriteMicrosCount: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "latency",
-			Name:      "db_write_micros_count",
-			Help:      "",
-		}, labels),
-
-		// Write Stall
-		StallMicros: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "stall",
-			Name:      "stall_micros",
-			Help:      "Writer has to wait for compaction or flush to finish.",
+			Subsystem: "wal",
+			Name:      "archived_wal_files_size",
+			Help:      "total size of obsolete WAL files kept in archive according to wal_ttl_seconds and wal_size_limit_mb",
 		}, labels),
 
-		DBWriteStallP50: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "stall",
-			Name:      "db_write_stall_p50",
-			Help:      "",
-		}, labels),
-		DBWriteStallP95: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "stall",
-			Name:      "db_write_stall_p95",
-			Help:      "",
-		}, labels),
-		DBWriteStallP99: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		// Blob Files
+		NumBlobFiles: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "stall",
-			Name:      "db_write_stall_p99",
-			Help:      "",
+			Subsystem: "blob",
+			Name:      "num_blob_files",
+			Help:      "number of blob files in the current version",
 		}, labels),
-		DBWriteStallP100: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		TotalBlobFileSize: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "stall",
-			Name:      "db_write_stall_p100",
-			Help:      "",
+			Subsystem: "blob",
+			Name:      "total_blob_file_size",
+			Help:      "total size of all blob files including obsolete ones which aren't deleted yet",
 		}, labels),
-		DBWriteStallCount: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		LiveBlobFileSize: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "stall",
-			Name:      "db_write_stall_count",
-			Help:      "",
+			Subsystem: "blob",
+			Name:      "live_blob_file_size",
+			Help:      "total size of blob files in the current version",
 		}, labels),
-		DBWriteStallSum: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		LiveBlobFileGarbageSize: newGauge(stdprometheus.GaugeOpts{
 			Namespace: namespace,
-			Subsystem: "stall",
-			Name:      "db_write_stall_sum",
-			Help:      "",
+			Subsystem: "blob",
+			Name:      "live_blob_file_garbage_size",
+			Help:      "total size of garbage in blob files in the current version",
 		}, labels),
 
-		// Bloom Filter
-		BloomFilterUseful: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		// Loading
+		LoadFailures: newCounter(stdprometheus.CounterOpts{
 			Namespace: namespace,
-			Subsystem: "filter",
-			Name:      "bloom_filter_useful",
-			Help:      "number of times bloom filter has avoided file reads, i.e., negatives.",
-		}, labels),
-		BloomFilterFullPositive: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "filter",
-			Name:      "bloom_filter_full_positive",
-			Help:      "number of times bloom FullFilter has not avoided the reads.",
-		}, labels),
-		BloomFilterFullTruePositive: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "filter",
-			Name:      "bloom_filter_full_true_positive",
-			Help:      "number of times bloom FullFilter has not avoided the reads and data actually exist.",
-		}, labels),
+			Subsystem: "loader",
+			Name:      "load_failures_total",
+			Help:      "number of times rocksdb property or statistic can't be loaded",
+		}, []string{dbNameMetricLabelName, statMetricLabelName}),
 
-		// LSM Tree Stats
-		LastLevelReadBytes: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "lsm",
-			Name:      "last_level_read_bytes",
-			Help:      "",
-		}, labels),
-		LastLevelReadCount: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "lsm",
-			Name:      "last_level_read_count",
-			Help:      "",
-		}, labels),
-		NonLastLevelReadBytes: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "lsm",
-			Name:      "non_last_level_read_bytes",
-			Help:      "",
-		}, labels),
-		NonLastLevelReadCount: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "lsm",
-			Name:      "non_last_level_read_count",
-			Help:      "",
-		}, labels),
+		tickers:    newTickerCollector(namespace, config.ConstLabels),
+		histograms: newHistogramCollector(namespace, config.ConstLabels),
+		shared:     newSharedResourcesCollector(namespace, config.ConstLabels, processSharedResources),
+		scrapers:   newScrapers(),
+		vecs:       vecs,
+	}
+	collectors = append(collectors, rocksdbMetrics.tickers, rocksdbMetrics.histograms, rocksdbMetrics.shared)
 
-		GetHitL0: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "lsm",
-			Name:      "get_hit_l0",
-			Help:      "number of Get() queries served by L0",
-		}, labels),
-		GetHitL1: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "lsm",
-			Name:      "get_hit_l1",
-			Help:      "number of Get() queries served by L1",
-		}, labels),
-		GetHitL2AndUp: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "lsm",
-			Name:      "get_hit_l2_and_up",
-			Help:      "number of Get() queries served by L2 and up",
-		}, labels),
+	if err := config.registerer().Register(newMetricsCollector(rocksdbMetrics.scrapers, collectors)); err != nil {
+		return nil, err
+	}
+	registeredMetrics[key] = rocksdbMetrics
+
+	return rocksdbMetrics, nil
+}
+
+// metricsCollector collects all metrics registered for one MetricsConfig,
+// metrics of databases which are reported at scrape time are refreshed before collecting
+type metricsCollector struct {
+	scrapers   *scrapers
+	collectors []stdprometheus.Collector
+}
+
+var _ stdprometheus.Collector = (*metricsCollector)(nil)
+
+func newMetricsCollector(scrapers *scrapers, collectors []stdprometheus.Collector) *metricsCollector {
+	return &metricsCollector{
+		scrapers:   scrapers,
+		collectors: collectors,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *metricsCollector) Describe(ch chan<- *stdprometheus.Desc) {
+	for _, collector := range c.collectors {
+		collector.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *metricsCollector) Collect(ch chan<- stdprometheus.Metric) {
+	c.scrapers.refresh()
+
+	for _, collector := range c.collectors {
+		collector.Collect(ch)
 	}
 }
 
 // report reports metrics to prometheus based on rocksdb props and stats
 func (m *Metrics) report(dbName string, props *properties, stats *stats) {
-	// Keys
-	m.NumberKeysWritten.With(dbNameMetricLabelName, dbName).Set(float64(stats.NumberKeysWritten))
-	m.NumberKeysRead.With(dbNameMetricLabelName, dbName).Set(float64(stats.NumberKeysRead))
-	m.NumberKeysUpdated.With(dbNameMetricLabelName, dbName).Set(float64(stats.NumberKeysUpdated))
-	m.EstimateNumKeys.With(dbNameMetricLabelName, dbName).Set(float64(props.EstimateNumKeys))
+	// Histograms
+	m.histograms.update(dbName, stats)
+
+	// properties which can't be loaded aren't reported, because zero is a valid value for most of them,
+	// their failures are reported by load failures counter
+	setGauge := func(gauge metrics.Gauge, propName string, value uint64) {
+		if props.loaded(propName) {
+			gauge.With(dbNameMetricLabelName, dbName).Set(float64(value))
+		}
+	}
 
-	// Files
-	m.NumberFileOpens.With(dbNameMetricLabelName, dbName).Set(float64(stats.NumberFileOpens))
-	m.NumberFileErrors.With(dbNameMetricLabelName, dbName).Set(float64(stats.NumberFileErrors))
+	// Keys
+	setGauge(m.EstimateNumKeys, "rocksdb.estimate-num-keys", props.EstimateNumKeys)
 
 	// Memory
-	m.BlockCacheUsage.With(dbNameMetricLabelName, dbName).Set(float64(props.BlockCacheUsage))
-	m.EstimateTableReadersMem.With(dbNameMetricLabelName, dbName).Set(float64(props.EstimateTableReadersMem))
-	m.CurSizeAllMemTables.With(dbNameMetricLabelName, dbName).Set(float64(props.CurSizeAllMemTables))
-	m.BlockCachePinnedUsage.With(dbNameMetricLabelName, dbName).Set(float64(props.BlockCachePinnedUsage))
-
-	// Cache
-	m.BlockCacheMiss.With(dbNameMetricLabelName, dbName).Set(float64(stats.BlockCacheMiss))
-	m.BlockCacheHit.With(dbNameMetricLabelName, dbName).Set(float64(stats.BlockCacheHit))
-	m.BlockCacheAdd.With(dbNameMetricLabelName, dbName).Set(float64(stats.BlockCacheAdd))
-	m.BlockCacheAddFailures.With(dbNameMetricLabelName, dbName).Set(float64(stats.BlockCacheAddFailures))
-
-	// Detailed Cache
-	m.BlockCacheIndexMiss.With(dbNameMetricLabelName, dbName).Set(float64(stats.BlockCacheIndexMiss))
-	m.BlockCacheIndexHit.With(dbNameMetricLabelName, dbName).Set(float64(stats.BlockCacheIndexHit))
-	m.BlockCacheIndexBytesInsert.With(dbNameMetricLabelName, dbName).Set(float64(stats.BlockCacheIndexBytesInsert))
-
-	m.BlockCacheFilterMiss.With(dbNameMetricLabelName, dbName).Set(float64(stats.BlockCacheFilterMiss))
-	m.BlockCacheFilterHit.With(dbNameMetricLabelName, dbName).Set(float64(stats.BlockCacheFilterHit))
-	m.BlockCacheFilterBytesInsert.With(dbNameMetricLabelName, dbName).Set(float64(stats.BlockCacheFilterBytesInsert))
-
-	m.BlockCacheDataMiss.With(dbNameMetricLabelName, dbName).Set(float64(stats.BlockCacheDataMiss))
-	m.BlockCacheDataHit.With(dbNameMetricLabelName, dbName).Set(float64(stats.BlockCacheDataHit))
-	m.BlockCacheDataBytesInsert.With(dbNameMetricLabelName, dbName).Set(float64(stats.BlockCacheDataBytesInsert))
-
-	// Latency
-	m.DBGetMicrosP50.With(dbNameMetricLabelName, dbName).Set(stats.DBGetMicros.P50)
-	m.DBGetMicrosP95.With(dbNameMetricLabelName, dbName).Set(stats.DBGetMicros.P95)
-	m.DBGetMicrosP99.With(dbNameMetricLabelName, dbName).Set(stats.DBGetMicros.P99)
-	m.DBGetMicrosP100.With(dbNameMetricLabelName, dbName).Set(stats.DBGetMicros.P100)
-	m.DBGetMicrosCount.With(dbNameMetricLabelName, dbName).Set(stats.DBGetMicros.Count)
-
-	m.DBWriteMicrosP50.With(dbNameMetricLabelName, dbName).Set(stats.DBWriteMicros.P50)
-	m.DBWriteMicrosP95.With(dbNameMetricLabelName, dbName).Set(stats.DBWriteMicros.P95)
-	m.DBWriteMicrosP99.With(dbNameMetricLabelName, dbName).Set(stats.DBWriteMicros.P99)
-	m.DBWriteMicrosP100.With(dbNameMetricLabelName, dbName).Set(stats.DBWriteMicros.P100)
-	m.DBWriteMicrosCount.With(dbNameMetricLabelName, dbName).Set(stats.DBWriteMicros.Count)
-
-	// Write Stall
-	m.StallMicros.With(dbNameMetricLabelName, dbName).Set(float64(stats.StallMicros))
-
-	m.DBWriteStallP50.With(dbNameMetricLabelName, dbName).Set(stats.DBWriteStallHistogram.P50)
-	m.DBWriteStallP95.With(dbNameMetricLabelName, dbName).Set(stats.DBWriteStallHistogram.P95)
-	m.DBWriteStallP99.With(dbNameMetricLabelName, dbName).Set(stats.DBWriteStallHistogram.P99)
-	m.DBWriteStallP100.With(dbNameMetricLabelName, dbName).Set(stats.DBWriteStallHistogram.P100)
-	m.DBWriteStallCount.With(dbNameMetricLabelName, dbName).Set(stats.DBWriteStallHistogram.Count)
-	m.DBWriteStallSum.With(dbNameMetricLabelName, dbName).Set(stats.DBWriteStallHistogram.Sum)
-
-	// Bloom Filter
-	m.BloomFilterUseful.With(dbNameMetricLabelName, dbName).Set(float64(stats.BloomFilterUseful))
-	m.BloomFilterFullPositive.With(dbNameMetricLabelName, dbName).Set(float64(stats.BloomFilterFullPositive))
-	m.BloomFilterFullTruePositive.With(dbNameMetricLabelName, dbName).Set(float64(stats.BloomFilterFullTruePositive))
-
-	// LSM Tree Stats
-	m.LastLevelReadBytes.With(dbNameMetricLabelName, dbName).Set(float64(stats.LastLevelReadBytes))
-	m.LastLevelReadCount.With(dbNameMetricLabelName, dbName).Set(float64(stats.LastLevelReadCount))
-	m.NonLastLevelReadBytes.With(dbNameMetricLabelName, dbName).Set(float64(stats.NonLastLevelReadBytes))
-	m.NonLastLevelReadCount.With(dbNameMetricLabelName, dbName).Set(float64(stats.NonLastLevelReadCount))
-
-	m.GetHitL0.With(dbNameMetricLabelName, dbName).Set(float64(stats.GetHitL0))
-	m.GetHitL1.With(dbNameMetricLabelName, dbName).Set(float64(stats.GetHitL1))
-	m.GetHitL2AndUp.With(dbNameMetricLabelName, dbName).Set(float64(stats.GetHitL2AndUp))
+	setGauge(m.BlockCacheUsage, "rocksdb.block-cache-usage", props.BlockCacheUsage)
+	setGauge(m.EstimateTableReadersMem, "rocksdb.estimate-table-readers-mem", props.EstimateTableReadersMem)
+	setGauge(m.CurSizeAllMemTables, "rocksdb.cur-size-all-mem-tables", props.CurSizeAllMemTables)
+	setGauge(m.SizeAllMemTables, "rocksdb.size-all-mem-tables", props.SizeAllMemTables)
+	setGauge(m.BlockCachePinnedUsage, "rocksdb.block-cache-pinned-usage", props.BlockCachePinnedUsage)
+
+	// Compaction and Flush Pressure
+	setGauge(m.CompactionPending, "rocksdb.compaction-pending", props.CompactionPending)
+	setGauge(m.EstimatePendingCompactionBytes, "rocksdb.estimate-pending-compaction-bytes", props.EstimatePendingCompactionBytes)
+	setGauge(m.NumRunningCompactions, "rocksdb.num-running-compactions", props.NumRunningCompactions)
+	setGauge(m.NumRunningFlushes, "rocksdb.num-running-flushes", props.NumRunningFlushes)
+	setGauge(m.MemTableFlushPending, "rocksdb.mem-table-flush-pending", props.MemTableFlushPending)
+	setGauge(m.ActualDelayedWriteRate, "rocksdb.actual-delayed-write-rate", props.ActualDelayedWriteRate)
+	setGauge(m.IsWriteStopped, "rocksdb.is-write-stopped", props.IsWriteStopped)
+	if props.loaded("rocksdb.is-write-stopped") && props.loaded("rocksdb.actual-delayed-write-rate") {
+		m.WriteStoppedOrDelayed.With(dbNameMetricLabelName, dbName).Set(boolToFloat64(props.writeStoppedOrDelayed()))
+	}
+
+	// LSM Levels
+	setGauge(m.BaseLevel, "rocksdb.base-level", props.BaseLevel)
+	for level, levelProps := range props.Levels {
+		levelLabel := strconv.Itoa(level)
+		if props.loaded(numFilesAtLevelPropPrefix + levelLabel) {
+			m.NumFilesAtLevel.With(dbNameMetricLabelName, dbName, levelMetricLabelName, levelLabel).Set(float64(levelProps.NumFiles))
+		}
+		if props.loaded(compressionRatioAtLevelPropPrefix + levelLabel) {
+			m.CompressionRatioAtLevel.With(dbNameMetricLabelName, dbName, levelMetricLabelName, levelLabel).Set(levelProps.CompressionRatio)
+		}
+	}
+	for levelLabel, levelStats := range props.CompactionStats {
+		m.LevelNumCompactingFiles.With(dbNameMetricLabelName, dbName, levelMetricLabelName, levelLabel).Set(float64(levelStats.NumCompactingFiles))
+		m.LevelSizeBytes.With(dbNameMetricLabelName, dbName, levelMetricLabelName, levelLabel).Set(levelStats.SizeBytes)
+		m.LevelScore.With(dbNameMetricLabelName, dbName, levelMetricLabelName, levelLabel).Set(levelStats.Score)
+		m.LevelWriteAmp.With(dbNameMetricLabelName, dbName, levelMetricLabelName, levelLabel).Set(levelStats.WriteAmp)
+	}
+
+	// Blob Files
+	setGauge(m.NumBlobFiles, "rocksdb.num-blob-files", props.NumBlobFiles)
+	setGauge(m.TotalBlobFileSize, "rocksdb.total-blob-file-size", props.TotalBlobFileSize)
+	setGauge(m.LiveBlobFileSize, "rocksdb.live-blob-file-size", props.LiveBlobFileSize)
+	setGauge(m.LiveBlobFileGarbageSize, "rocksdb.live-blob-file-garbage-size", props.LiveBlobFileGarbageSize)
+}
+
+// reportTickers reports rocksdb tickers loaded at loadedAt,
+// if reportRates is true per second rates since the previous report are reported as well
+func (m *Metrics) reportTickers(dbName string, stats *stats, loadedAt time.Time, reportRates bool) {
+	m.tickers.update(dbName, stats, loadedAt, reportRates)
+}
+
+// reportWALFiles reports number and size of WAL files of the database
+func (m *Metrics) reportWALFiles(dbName string, walFiles *walFiles) {
+	m.NumWALFiles.With(dbNameMetricLabelName, dbName).Set(float64(walFiles.NumFiles))
+	m.WALFilesSize.With(dbNameMetricLabelName, dbName).Set(float64(walFiles.TotalSize))
+	m.NumArchivedWALFiles.With(dbNameMetricLabelName, dbName).Set(float64(walFiles.NumArchivedFiles))
+	m.ArchivedWALFilesSize.With(dbNameMetricLabelName, dbName).Set(float64(walFiles.TotalArchivedSize))
+}
+
+// reportSharedResources reports shared resources used by the database
+func (m *Metrics) reportSharedResources(dbName string, acquired *acquiredResources) {
+	m.shared.update(dbName, acquired)
+}
+
+// reportFailures increments failure counters of properties and statistics which can't be loaded
+func (m *Metrics) reportFailures(dbName string, failures []string) {
+	for _, statName := range failures {
+		m.LoadFailures.With(dbNameMetricLabelName, dbName, statMetricLabelName, statName).Add(1)
+	}
+}
+
+func boolToFloat64(value bool) float64 {
+	if value {
+		return 1
+	}
+
+	return 0
+}
+
+// remove stops reporting metrics of the database, it should be called when database is closed
+func (m *Metrics) remove(dbName string) {
+	m.tickers.remove(dbName)
+	m.histograms.remove(dbName)
+	m.shared.remove(dbName)
+	for _, vec := range m.vecs {
+		vec.DeletePartialMatch(stdprometheus.Labels{dbNameMetricLabelName: dbName})
+	}
 }
@@ -0,0 +1,66 @@
+package opendb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultMetricsNamespace is a namespace of rocksdb metrics used if MetricsConfig doesn't specify it
+const DefaultMetricsNamespace = "rocksdb_v2"
+
+// MetricsConfig configures where rocksdb metrics are registered and how they are named.
+// Zero value registers metrics in prometheus.DefaultRegisterer under DefaultMetricsNamespace.
+// Databases opened with equal configs share metrics, they are distinguished by db_name label.
+type MetricsConfig struct {
+	// Registerer is used to register metrics, prometheus.DefaultRegisterer is used if nil
+	Registerer stdprometheus.Registerer
+	// Namespace is a prefix of metric names, DefaultMetricsNamespace is used if empty
+	Namespace string
+	// ConstLabels are attached to every metric, for example chain_id or node
+	ConstLabels stdprometheus.Labels
+}
+
+func (c MetricsConfig) registerer() stdprometheus.Registerer {
+	if c.Registerer == nil {
+		return stdprometheus.DefaultRegisterer
+	}
+
+	return c.Registerer
+}
+
+func (c MetricsConfig) namespace() string {
+	if c.Namespace == "" {
+		return DefaultMetricsNamespace
+	}
+
+	return c.Namespace
+}
+
+// metricsKey identifies metrics registered in one registerer under the same namespace with the same const labels
+type metricsKey struct {
+	registerer  stdprometheus.Registerer
+	namespace   string
+	constLabels string
+}
+
+func (c MetricsConfig) key() metricsKey {
+	labelNames := make([]string, 0, len(c.ConstLabels))
+	for labelName := range c.ConstLabels {
+		labelNames = append(labelNames, labelName)
+	}
+	sort.Strings(labelNames)
+
+	constLabels := make([]string, 0, len(labelNames))
+	for _, labelName := range labelNames {
+		constLabels = append(constLabels, fmt.Sprintf("%v=%q", labelName, c.ConstLabels[labelName]))
+	}
+
+	return metricsKey{
+		registerer:  c.registerer(),
+		namespace:   c.namespace(),
+		constLabels: strings.Join(constLabels, ","),
+	}
+}
@@ -0,0 +1,36 @@
+package opendb
+
+import (
+	"testing"
+
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsConfigKey(t *testing.T) {
+	registry := stdprometheus.NewRegistry()
+
+	require.Equal(t, metricsKey{
+		registerer: stdprometheus.DefaultRegisterer,
+		namespace:  DefaultMetricsNamespace,
+	}, MetricsConfig{}.key())
+
+	key1 := MetricsConfig{
+		Registerer:  registry,
+		Namespace:   "custom",
+		ConstLabels: stdprometheus.Labels{"chain_id": "kava_2222-10", "node": "node1"},
+	}.key()
+	key2 := MetricsConfig{
+		Registerer:  registry,
+		Namespace:   "custom",
+		ConstLabels: stdprometheus.Labels{"node": "node1", "chain_id": "kava_2222-10"},
+	}.key()
+	require.Equal(t, key1, key2)
+
+	key3 := MetricsConfig{
+		Registerer:  registry,
+		Namespace:   "custom",
+		ConstLabels: stdprometheus.Labels{"chain_id": "kava_2222-10", "node": "node2"},
+	}.key()
+	require.NotEqual(t, key1, key3)
+}
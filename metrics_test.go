@@ -0,0 +1,211 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterMetrics(t *testing.T) {
+	registry1 := stdprometheus.NewRegistry()
+	registry2 := stdprometheus.NewRegistry()
+	config1 := MetricsConfig{
+		Registerer:  registry1,
+		Namespace:   "custom",
+		ConstLabels: stdprometheus.Labels{"chain_id": "kava_2222-10", "node": "node1"},
+	}
+	config2 := MetricsConfig{
+		Registerer:  registry2,
+		ConstLabels: stdprometheus.Labels{"chain_id": "kava_2221-17", "node": "node2"},
+	}
+
+	metrics1, err := registerMetrics(config1)
+	require.NoError(t, err)
+	metrics2, err := registerMetrics(config2)
+	require.NoError(t, err)
+	require.NotSame(t, metrics1, metrics2)
+
+	// second database opened with equal config shares metrics with the first one
+	sameMetrics, err := registerMetrics(MetricsConfig{
+		Registerer:  registry1,
+		Namespace:   "custom",
+		ConstLabels: stdprometheus.Labels{"node": "node1", "chain_id": "kava_2222-10"},
+	})
+	require.NoError(t, err)
+	require.Same(t, metrics1, sameMetrics)
+
+	metrics1.BaseLevel.With(dbNameMetricLabelName, "application").Set(1)
+	metrics2.BaseLevel.With(dbNameMetricLabelName, "blockstore").Set(2)
+
+	expected1 := `
+# HELP custom_lsm_base_level number of level to which L0 data will be compacted
+# TYPE custom_lsm_base_level gauge
+custom_lsm_base_level{chain_id="kava_2222-10",db_name="application",node="node1"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(registry1, strings.NewReader(expected1), "custom_lsm_base_level"))
+
+	expected2 := `
+# HELP rocksdb_v2_lsm_base_level number of level to which L0 data will be compacted
+# TYPE rocksdb_v2_lsm_base_level gauge
+rocksdb_v2_lsm_base_level{chain_id="kava_2221-17",db_name="blockstore",node="node2"} 2
+`
+	require.NoError(t, testutil.GatherAndCompare(registry2, strings.NewReader(expected2), "rocksdb_v2_lsm_base_level"))
+}
+
+func TestRegisterMetricsConflict(t *testing.T) {
+	registry := stdprometheus.NewRegistry()
+
+	_, err := registerMetrics(MetricsConfig{
+		Registerer:  registry,
+		ConstLabels: stdprometheus.Labels{"chain_id": "kava_2222-10"},
+	})
+	require.NoError(t, err)
+
+	// metrics with the same names but different label names can't be registered in the same registry
+	_, err = registerMetrics(MetricsConfig{
+		Registerer:  registry,
+		ConstLabels: stdprometheus.Labels{"node": "node1"},
+	})
+	require.Error(t, err)
+
+	// metrics aren't partially registered
+	_, err = registerMetrics(MetricsConfig{
+		Registerer:  registry,
+		Namespace:   "custom",
+		ConstLabels: stdprometheus.Labels{"node": "node1"},
+	})
+	require.NoError(t, err)
+}
+
+func TestRegisterStatsCollector(t *testing.T) {
+	registry := stdprometheus.NewRegistry()
+	config := MetricsConfig{
+		Registerer:  registry,
+		ConstLabels: stdprometheus.Labels{"chain_id": "kava_2222-10"},
+	}
+
+	collector, err := registerStatsCollector(config, nil)
+	require.NoError(t, err)
+	sameCollector, err := registerStatsCollector(config, nil)
+	require.NoError(t, err)
+	require.Same(t, collector, sameCollector)
+
+	collector.update("application", map[string]*stat{
+		"rocksdb.block.cache.miss": {
+			name:  "rocksdb.block.cache.miss",
+			props: map[string]string{"COUNT": "1"},
+		},
+	})
+	defer collector.remove("application")
+
+	expected := `
+# HELP rocksdb_v2_stats_block_cache_miss_total rocksdb rocksdb.block.cache.miss statistic
+# TYPE rocksdb_v2_stats_block_cache_miss_total counter
+rocksdb_v2_stats_block_cache_miss_total{chain_id="kava_2222-10",db_name="application"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected)))
+}
+
+func TestRegisterMetricsRefreshesScrapersOnCollect(t *testing.T) {
+	registry := stdprometheus.NewRegistry()
+	metrics, err := registerMetrics(MetricsConfig{Registerer: registry})
+	require.NoError(t, err)
+
+	baseLevel := 0
+	metrics.scrapers.add("application", func() {
+		baseLevel++
+		metrics.BaseLevel.With(dbNameMetricLabelName, "application").Set(float64(baseLevel))
+	}, time.Hour)
+
+	expected := `
+# HELP rocksdb_v2_lsm_base_level number of level to which L0 data will be compacted
+# TYPE rocksdb_v2_lsm_base_level gauge
+rocksdb_v2_lsm_base_level{db_name="application"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "rocksdb_v2_lsm_base_level"))
+	// metrics are cached until minimum refresh interval elapses
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "rocksdb_v2_lsm_base_level"))
+}
+
+func TestReportSkipsFailedProps(t *testing.T) {
+	registry := stdprometheus.NewRegistry()
+	metrics, err := registerMetrics(MetricsConfig{Registerer: registry, Namespace: "failed_props"})
+	require.NoError(t, err)
+
+	snapshot, err := getPropsAndStats(newMockPropsGetter(
+		map[string]string{
+			"rocksdb.num-files-at-level0": "2",
+		},
+		map[string]uint64{
+			"rocksdb.estimate-num-keys": 3,
+		},
+	), false)
+	require.NoError(t, err)
+	metrics.report("application", snapshot.props, snapshot.stats)
+
+	// properties which can't be loaded aren't reported as zero
+	expected := `
+# HELP failed_props_key_estimate_num_keys estimated number of total keys in the active and unflushed immutable memtables and storage
+# TYPE failed_props_key_estimate_num_keys gauge
+failed_props_key_estimate_num_keys{db_name="application"} 3
+# HELP failed_props_lsm_num_files_at_level number of files at level
+# TYPE failed_props_lsm_num_files_at_level gauge
+failed_props_lsm_num_files_at_level{db_name="application",level="0"} 2
+`
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"failed_props_key_estimate_num_keys",
+		"failed_props_memory_block_cache_usage",
+		"failed_props_lsm_num_files_at_level",
+		"failed_props_lsm_compression_ratio_at_level",
+		"failed_props_stall_write_stopped_or_delayed",
+	))
+}
+
+func TestRemoveMetrics(t *testing.T) {
+	registry := stdprometheus.NewRegistry()
+	metrics, err := registerMetrics(MetricsConfig{Registerer: registry, Namespace: "removed"})
+	require.NoError(t, err)
+
+	// seriesByDB returns number of reported series per database name
+	seriesByDB := func() map[string]int {
+		families, err := registry.Gather()
+		require.NoError(t, err)
+
+		series := make(map[string]int)
+		for _, family := range families {
+			for _, metric := range family.GetMetric() {
+				for _, label := range metric.GetLabel() {
+					if label.GetName() == dbNameMetricLabelName {
+						series[label.GetValue()]++
+					}
+				}
+			}
+		}
+
+		return series
+	}
+
+	for _, dbName := range []string{"application", "blockstore"} {
+		metrics.report(dbName, &properties{
+			EstimateNumKeys: 1,
+			Levels:          []levelProperties{{NumFiles: 2}},
+			CompactionStats: map[string]*levelCompactionStats{"0": {SizeBytes: 3}},
+		}, &stats{})
+		metrics.reportWALFiles(dbName, &walFiles{NumFiles: 4})
+		metrics.reportFailures(dbName, []string{"rocksdb.block-cache-usage"})
+	}
+	series := seriesByDB()
+	require.NotZero(t, series["application"])
+	require.Equal(t, series["application"], series["blockstore"])
+
+	// series of closed database aren't reported anymore, series of other databases are kept
+	metrics.remove("application")
+	require.Equal(t, map[string]int{"blockstore": series["blockstore"]}, seriesByDB())
+}
@@ -17,5 +17,17 @@ type AppOptions interface {
 // OpenDB is a copy of default DBOpener function used by ethermint, see for details:
 // https://github.com/evmos/ethermint/blob/07cf2bd2b1ce9bdb2e44ec42a39e7239292a14af/server/start.go#L647
 func OpenDB(appOpts AppOptions, dataDir string, dbName string, backendType dbm.BackendType) (dbm.DB, error) {
+	return OpenDBWithMetricsConfig(appOpts, dataDir, dbName, backendType, MetricsConfig{})
+}
+
+// OpenDBWithMetricsConfig is the same as OpenDB, metricsConfig is ignored because rocksdb metrics are available
+// only when built with rocksdb build tag
+func OpenDBWithMetricsConfig(
+	appOpts AppOptions,
+	dataDir string,
+	dbName string,
+	backendType dbm.BackendType,
+	metricsConfig MetricsConfig,
+) (dbm.DB, error) {
 	return dbm.NewDB(dbName, backendType, dataDir)
 }
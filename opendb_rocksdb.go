@@ -23,9 +23,12 @@ package opendb
 import (
 	"errors"
 	"fmt"
+	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 
@@ -34,17 +37,36 @@ import (
 	"github.com/spf13/cast"
 )
 
-var ErrUnexpectedConfiguration = errors.New("unexpected rocksdb configuration, rocksdb should have only one column family named default")
+var ErrUnexpectedConfiguration = errors.New("unexpected rocksdb configuration, rocksdb should have column family named default")
 
 const (
 	// default tm-db block cache size for RocksDB
 	defaultBlockCacheSize = 1 << 30
+	// default tm-db bloom filter bits per key for RocksDB
+	defaultBitsPerKey = 10
+	// default tm-db max open files for RocksDB, it seems to provide a reliable performance boost
+	defaultMaxOpenFiles = 4096
+	// default tm-db memtable memory budget for RocksDB, write buffers may use 50% more on heavy workloads
+	defaultMemtableMemoryBudget = 512 * 1024 * 1024
 
 	DefaultColumnFamilyName = "default"
 
+	// columnFamiliesOptName is a list of column families which should be created if they don't exist yet
+	columnFamiliesOptName = "column-families"
+	// columnFamilyOptsPrefix is a prefix of column family specific options, for example:
+	// rocksdb.<db>.cf.<name>.write-buffer-size
+	columnFamilyOptsPrefix = "cf"
+
 	enableMetricsOptName             = "enable-metrics"
 	reportMetricsIntervalSecsOptName = "report-metrics-interval-secs"
 	defaultReportMetricsIntervalSecs = 15
+	reportAllStatsOptName            = "report-all-stats"
+	reportTickerRatesOptName         = "report-ticker-rates"
+	metricsModeOptName               = "metrics-mode"
+	tickerMetricsMode                = "ticker"
+	scrapeMetricsMode                = "scrape"
+	scrapeMinRefreshIntervalOptName  = "scrape-min-refresh-interval-secs"
+	defaultScrapeMinRefreshInterval  = time.Second
 
 	maxOpenFilesDBOptName           = "max-open-files"
 	maxFileOpeningThreadsDBOptName  = "max-file-opening-threads"
@@ -100,24 +122,123 @@ func newRocksDBOptions(appOpts AppOptions, dbName string) *rocksDBOptions {
 
 // Get constructs database-specific and fallback keys and use them to get value from underlying AppOptions.
 // Database-specific key takes precedence over fallback key.
+// Dash and underscore spellings of option name are equal, for example max-open-files and max_open_files.
 func (opts *rocksDBOptions) Get(key string) interface{} {
+	value, _ := opts.lookup(key)
+	return value
+}
+
+// lookup returns value of key and key of underlying AppOptions which value is taken from
+func (opts *rocksDBOptions) lookup(key string) (interface{}, string) {
 	// get value using database-specific key
-	dbSpecificKey := fmt.Sprintf("rocksdb.%v.%v", opts.dbName, key)
-	if opts.appOpts.Get(dbSpecificKey) != nil {
-		return opts.appOpts.Get(dbSpecificKey)
+	for _, spelling := range optNameSpellings(key) {
+		dbSpecificKey := fmt.Sprintf("rocksdb.%v.%v", opts.dbName, spelling)
+		if value, resolvedKey := lookupOpt(opts.appOpts, dbSpecificKey); value != nil {
+			return value, resolvedKey
+		}
 	}
 
 	// get value using fallback key
-	fallbackKey := fmt.Sprintf("rocksdb.%v", key)
-	return opts.appOpts.Get(fallbackKey)
+	for _, spelling := range optNameSpellings(key) {
+		fallbackKey := fmt.Sprintf("rocksdb.%v", spelling)
+		if value, resolvedKey := lookupOpt(opts.appOpts, fallbackKey); value != nil {
+			return opts.fallbackValue(key, value), resolvedKey
+		}
+	}
+
+	return nil, ""
+}
+
+// fallbackValue returns value of fallback key for the database, wal_dir of fallback configuration is shared by
+// all databases, so WAL of every database is stored in its own subdirectory <wal_dir>/<db>.db,
+// otherwise databases would recover from WAL files of each other
+func (opts *rocksDBOptions) fallbackValue(key string, value interface{}) interface{} {
+	if normalizeOptName(key) != normalizeOptName(walDirDBOptName) || cast.ToString(value) == "" {
+		return value
+	}
+
+	return filepath.Join(cast.ToString(value), opts.dbName+".db")
+}
+
+// columnFamilyOptions implements AppOptions interface.
+// It does it by wrapping another AppOptions, but also takes into account column family name.
+type columnFamilyOptions struct {
+	appOpts AppOptions
+	cfName  string
+}
+
+func newColumnFamilyOptions(appOpts AppOptions, cfName string) *columnFamilyOptions {
+	return &columnFamilyOptions{
+		appOpts: appOpts,
+		cfName:  cfName,
+	}
+}
+
+// Get constructs column family specific key and use it to get value from underlying AppOptions.
+// Column family specific key takes precedence over key without column family.
+func (opts *columnFamilyOptions) Get(key string) interface{} {
+	value, _ := opts.lookup(key)
+	return value
+}
+
+// lookup returns value of key and key of underlying AppOptions which value is taken from
+func (opts *columnFamilyOptions) lookup(key string) (interface{}, string) {
+	// get value using column family specific key
+	cfSpecificKey := fmt.Sprintf("%v.%v.%v", columnFamilyOptsPrefix, opts.cfName, key)
+	if value, resolvedKey := lookupOpt(opts.appOpts, cfSpecificKey); value != nil {
+		return value, resolvedKey
+	}
+
+	// get value using key without column family
+	return lookupOpt(opts.appOpts, key)
+}
+
+// keyLookup is implemented by AppOptions which resolve key into one of several keys of underlying AppOptions
+type keyLookup interface {
+	lookup(key string) (interface{}, string)
+}
+
+// lookupOpt returns value of key in appOpts and key of the original AppOptions which value is taken from,
+// key is empty if value isn't specified
+func lookupOpt(appOpts AppOptions, key string) (interface{}, string) {
+	if l, ok := appOpts.(keyLookup); ok {
+		return l.lookup(key)
+	}
+
+	value := appOpts.Get(key)
+	if value == nil {
+		return nil, ""
+	}
+
+	return value, key
 }
 
 func OpenDB(appOpts AppOptions, dataDir string, dbName string, backendType dbm.BackendType) (dbm.DB, error) {
+	return OpenDBWithMetricsConfig(appOpts, dataDir, dbName, backendType, MetricsConfig{})
+}
+
+// OpenDBWithMetricsConfig is the same as OpenDB, but rocksdb metrics are registered according to metricsConfig
+func OpenDBWithMetricsConfig(
+	appOpts AppOptions,
+	dataDir string,
+	dbName string,
+	backendType dbm.BackendType,
+	metricsConfig MetricsConfig,
+) (dbm.DB, error) {
 	// wrap AppOptions with rocksDBOptions to make sure dbName is considered when applying configuration
 	// it allows individual database configuration
 	rocksDBOpts := newRocksDBOptions(appOpts, dbName)
 	if backendType == dbm.RocksDBBackend {
-		return openRocksdb(dataDir, dbName, rocksDBOpts)
+		if err := checkConfig(appOpts, dbName); err != nil {
+			return nil, err
+		}
+
+		db, err := openRocksdb(dataDir, dbName, rocksDBOpts, metricsConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		return db, nil
 	}
 
 	return dbm.NewDB(dbName, backendType, dataDir)
@@ -125,313 +246,636 @@ func OpenDB(appOpts AppOptions, dataDir string, dbName string, backendType dbm.B
 
 // openRocksdb loads existing options, overrides some of them with appOpts and opens database
 // option will be overridden only in case if it explicitly specified in appOpts
-func openRocksdb(dir string, dbName string, appOpts AppOptions) (dbm.DB, error) {
+// all existing column families are opened, column families listed in appOpts which don't exist yet are created
+func openRocksdb(dir string, dbName string, appOpts AppOptions, metricsConfig MetricsConfig) (*RocksDB, error) {
+	metricsOpts, err := metricsOptsFromAppOpts(appOpts)
+	if err != nil {
+		return nil, err
+	}
+	metricsOpts.config = metricsConfig
+
 	optionsPath := filepath.Join(dir, dbName+".db")
-	dbOpts, cfOpts, err := LoadLatestOptions(optionsPath)
+	dbOpts, cfNames, cfOpts, err := LoadLatestColumnFamilyOptions(optionsPath)
+	if err != nil {
+		return nil, err
+	}
+	cfNames, cfOpts = addMissingColumnFamilies(cfNames, cfOpts, cast.ToStringSlice(appOpts.Get(columnFamiliesOptName)))
+
+	sharedOpts, err := sharedResourcesOptsFromAppOpts(appOpts)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := processSharedResources.acquire(sharedOpts)
 	if err != nil {
 		return nil, err
 	}
+	metricsOpts.sharedResources = shared
+	// releaseFuncs release rocksdb objects created for the database in reverse order
+	releaseFuncs := []func(){func() { processSharedResources.release(shared) }}
+	release := func() {
+		for i := len(releaseFuncs) - 1; i >= 0; i-- {
+			releaseFuncs[i]()
+		}
+		// options are released by the database once it's opened, so they're destroyed here only if opening fails
+		dbOpts.Destroy()
+		for _, opts := range cfOpts {
+			if opts != nil {
+				opts.Destroy()
+			}
+		}
+	}
+
 	// customize rocksdb options
-	bbtoOpts := bbtoFromAppOpts(appOpts)
+	blockCache := shared.blockCache
+	if blockCache == nil {
+		blockCache, err = blockCacheFromAppOpts(appOpts)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		releaseFuncs = append(releaseFuncs, blockCache.Destroy)
+	}
+	// options persisted in OPTIONS file are kept unless appOpts override them, so they're needed to check bbto
+	file, err := loadLatestOptionsFile(optionsPath)
+	if err != nil && !errors.Is(err, errOptionsFileNotFound) && !errors.Is(err, fs.ErrNotExist) {
+		release()
+		return nil, fmt.Errorf("can't load options file: %w", err)
+	}
+	bbtoOpts, err := bbtoFromAppOpts(appOpts, cfNames, file, blockCache)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	// bbto owns filter policy, so it's released together with bbto
+	releaseFuncs = append(releaseFuncs, bbtoOpts.Destroy)
 	dbOpts.SetBlockBasedTableFactory(bbtoOpts)
-	cfOpts.SetBlockBasedTableFactory(bbtoOpts)
-	dbOpts = overrideDBOpts(dbOpts, appOpts)
-	cfOpts = overrideCFOpts(cfOpts, appOpts)
-	readOpts := readOptsFromAppOpts(appOpts)
+	if shared.writeBufferManager != nil {
+		dbOpts.SetWriteBufferManager(shared.writeBufferManager)
+	}
+	if _, err := overrideDBOpts(dbOpts, appOpts); err != nil {
+		release()
+		return nil, err
+	}
+	for i, cfName := range cfNames {
+		// column family specific options take precedence over database options
+		cfAppOpts := newColumnFamilyOptions(appOpts, cfName)
+		cfOpts[i].SetBlockBasedTableFactory(bbtoOpts)
+		// cfOpts[i] is nil if options can't be overridden, they're destroyed by overrideCFOpts in such case
+		cfOpts[i], err = overrideCFOpts(cfOpts[i], cfAppOpts)
+		if err != nil {
+			release()
+			return nil, fmt.Errorf("invalid options of column family %v: %w", cfName, err)
+		}
+	}
+	readOpts, err := readOptsFromAppOpts(appOpts)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	writeOpts, err := writeOptsFromAppOpts(appOpts)
+	if err != nil {
+		readOpts.Destroy()
+		release()
+		return nil, err
+	}
+	metricsOpts.walDir = cast.ToString(appOpts.Get(walDirDBOptName))
 
-	enableMetrics := cast.ToBool(appOpts.Get(enableMetricsOptName))
-	reportMetricsIntervalSecs := cast.ToInt64(appOpts.Get(reportMetricsIntervalSecsOptName))
-	if reportMetricsIntervalSecs == 0 {
-		reportMetricsIntervalSecs = defaultReportMetricsIntervalSecs
+	db, err := newRocksDBWithOptions(dbName, dir, dbOpts, cfNames, cfOpts, readOpts, writeOpts, metricsOpts)
+	if err != nil {
+		release()
+		return nil, err
 	}
+	db.releaseOnClose(releaseFuncs...)
+	db.appOpts = appOpts
 
-	return newRocksDBWithOptions(dbName, dir, dbOpts, cfOpts, readOpts, enableMetrics, reportMetricsIntervalSecs)
+	return db, nil
 }
 
-// LoadLatestOptions loads and returns database and column family options
+// LoadLatestOptions loads and returns database options and options of the default column family
 // if options file not found, it means database isn't created yet, in such case default tm-db options will be returned
-// if database exists it should have only one column family named default
 func LoadLatestOptions(dir string) (*grocksdb.Options, *grocksdb.Options, error) {
-	latestOpts, err := grocksdb.LoadLatestOptions(dir, grocksdb.NewDefaultEnv(), true, grocksdb.NewLRUCache(defaultBlockCacheSize))
-	if err != nil && strings.HasPrefix(err.Error(), "NotFound: ") {
-		return newDefaultOptions(), newDefaultOptions(), nil
-	}
+	dbOpts, cfNames, cfOpts, err := LoadLatestColumnFamilyOptions(dir)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	cfNames := latestOpts.ColumnFamilyNames()
-	cfOpts := latestOpts.ColumnFamilyOpts()
-	// db should have only one column family named default
-	ok := len(cfNames) == 1 && cfNames[0] == DefaultColumnFamilyName
-	if !ok {
-		return nil, nil, ErrUnexpectedConfiguration
-	}
-
-	// return db and cf opts
-	return latestOpts.Options(), &cfOpts[0], nil
+	idx := slices.Index(cfNames, DefaultColumnFamilyName)
+	return dbOpts, cfOpts[idx], nil
 }
 
-// overrideDBOpts merges dbOpts and appOpts, appOpts takes precedence
-func overrideDBOpts(dbOpts *grocksdb.Options, appOpts AppOptions) *grocksdb.Options {
-	maxOpenFiles := appOpts.Get(maxOpenFilesDBOptName)
-	if maxOpenFiles != nil {
-		dbOpts.SetMaxOpenFiles(cast.ToInt(maxOpenFiles))
+// LoadLatestColumnFamilyOptions loads and returns database options, names and options of all column families
+// if options file not found, it means database isn't created yet, in such case default tm-db options will be returned
+// for the only column family named default
+// if database exists it should have column family named default
+// returned options use block cache without capacity, OpenDB replaces it with configured block cache
+func LoadLatestColumnFamilyOptions(dir string) (*grocksdb.Options, []string, []*grocksdb.Options, error) {
+	// loaded options keep their own references to env and cache, so they can be released right after loading
+	env := grocksdb.NewDefaultEnv()
+	defer env.Destroy()
+	// openRocksdb replaces block based table factory of loaded options with one which uses configured block cache,
+	// so cache used for loading doesn't need capacity and doesn't count towards memory budget
+	cache := grocksdb.NewLRUCache(0)
+	defer cache.Destroy()
+
+	latestOpts, err := grocksdb.LoadLatestOptions(dir, env, true, cache)
+	if err != nil && strings.HasPrefix(err.Error(), "NotFound: ") {
+		return newDefaultOptions(), []string{DefaultColumnFamilyName}, []*grocksdb.Options{newDefaultOptions()}, nil
 	}
-
-	maxFileOpeningThreads := appOpts.Get(maxFileOpeningThreadsDBOptName)
-	if maxFileOpeningThreads != nil {
-		dbOpts.SetMaxFileOpeningThreads(cast.ToInt(maxFileOpeningThreads))
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
-	tableCacheNumshardbits := appOpts.Get(tableCacheNumshardbitsDBOptName)
-	if tableCacheNumshardbits != nil {
-		dbOpts.SetTableCacheNumshardbits(cast.ToInt(tableCacheNumshardbits))
+	cfNames := latestOpts.ColumnFamilyNames()
+	// db should have column family named default
+	if !slices.Contains(cfNames, DefaultColumnFamilyName) {
+		return nil, nil, nil, ErrUnexpectedConfiguration
 	}
 
-	allowMMAPWrites := appOpts.Get(allowMMAPWritesDBOptName)
-	if allowMMAPWrites != nil {
-		dbOpts.SetAllowMmapWrites(cast.ToBool(allowMMAPWrites))
+	latestCFOpts := latestOpts.ColumnFamilyOpts()
+	cfOpts := make([]*grocksdb.Options, len(latestCFOpts))
+	for i := range latestCFOpts {
+		cfOpts[i] = &latestCFOpts[i]
 	}
 
-	allowMMAPReads := appOpts.Get(allowMMAPReadsDBOptName)
-	if allowMMAPReads != nil {
-		dbOpts.SetAllowMmapReads(cast.ToBool(allowMMAPReads))
-	}
+	// return db and cf opts
+	return latestOpts.Options(), cfNames, cfOpts, nil
+}
 
-	useFsync := appOpts.Get(useFsyncDBOptName)
-	if useFsync != nil {
-		dbOpts.SetUseFsync(cast.ToBool(useFsync))
+// addMissingColumnFamilies appends column families which are requested but don't exist yet,
+// such column families start with default tm-db options
+func addMissingColumnFamilies(cfNames []string, cfOpts []*grocksdb.Options, requestedCFNames []string) ([]string, []*grocksdb.Options) {
+	for _, cfName := range requestedCFNames {
+		if slices.Contains(cfNames, cfName) {
+			continue
+		}
+
+		cfNames = append(cfNames, cfName)
+		cfOpts = append(cfOpts, newDefaultOptions())
 	}
 
-	useAdaptiveMutex := appOpts.Get(useAdaptiveMutexDBOptName)
-	if useAdaptiveMutex != nil {
-		dbOpts.SetUseAdaptiveMutex(cast.ToBool(useAdaptiveMutex))
+	return cfNames, cfOpts
+}
+
+// overrideDBOpts merges dbOpts and appOpts, appOpts takes precedence
+// it returns error if appOpts contain invalid value
+func overrideDBOpts(dbOpts *grocksdb.Options, appOpts AppOptions) (*grocksdb.Options, error) {
+	if err := applyOptionDefs(dbOpts, appOpts, optionDef.dbSetter); err != nil {
+		return nil, err
 	}
 
-	bytesPerSync := appOpts.Get(bytesPerSyncDBOptName)
-	if bytesPerSync != nil {
-		dbOpts.SetBytesPerSync(cast.ToUint64(bytesPerSync))
+	if err := checkDirectIOOpts(dbOpts); err != nil {
+		return nil, err
 	}
 
-	maxBackgroundJobs := appOpts.Get(maxBackgroundJobsDBOptName)
-	if maxBackgroundJobs != nil {
-		dbOpts.SetMaxBackgroundJobs(cast.ToInt(maxBackgroundJobs))
+	if err := overrideRateLimiterOpts(dbOpts, appOpts); err != nil {
+		return nil, err
 	}
 
-	return dbOpts
+	return dbOpts, nil
 }
 
 // overrideCFOpts merges cfOpts and appOpts, appOpts takes precedence
-func overrideCFOpts(cfOpts *grocksdb.Options, appOpts AppOptions) *grocksdb.Options {
-	writeBufferSize := appOpts.Get(writeBufferSizeCFOptName)
-	if writeBufferSize != nil {
-		cfOpts.SetWriteBufferSize(cast.ToUint64(writeBufferSize))
-	}
+// it returns error if appOpts contain invalid value
+// cfOpts must not be used after the call, because they may be replaced by new options, and they're destroyed on error
+func overrideCFOpts(cfOpts *grocksdb.Options, appOpts AppOptions) (_ *grocksdb.Options, err error) {
+	defer func() {
+		if err != nil {
+			cfOpts.Destroy()
+		}
+	}()
 
-	numLevels := appOpts.Get(numLevelsCFOptName)
-	if numLevels != nil {
-		cfOpts.SetNumLevels(cast.ToInt(numLevels))
+	if err := applyOptionDefs(cfOpts, appOpts, optionDef.cfSetter); err != nil {
+		return nil, err
 	}
 
-	maxWriteBufferNumber := appOpts.Get(maxWriteBufferNumberCFOptName)
-	if maxWriteBufferNumber != nil {
-		cfOpts.SetMaxWriteBufferNumber(cast.ToInt(maxWriteBufferNumber))
+	// compression, prefix extractor and compaction options are applied last,
+	// because compression_opts, prefix_extractor and ttl can be set only by creating new options from cfOpts
+	newCFOpts, err := overrideCompressionOpts(cfOpts, appOpts)
+	if err != nil {
+		return nil, err
 	}
+	cfOpts = newCFOpts
 
-	minWriteBufferNumberToMerge := appOpts.Get(minWriteBufferNumberToMergeCFOptName)
-	if minWriteBufferNumberToMerge != nil {
-		cfOpts.SetMinWriteBufferNumberToMerge(cast.ToInt(minWriteBufferNumberToMerge))
+	newCFOpts, err = overridePrefixExtractorOpts(cfOpts, appOpts)
+	if err != nil {
+		return nil, err
 	}
+	cfOpts = newCFOpts
 
-	maxBytesForLevelBase := appOpts.Get(maxBytesForLevelBaseCFOptName)
-	if maxBytesForLevelBase != nil {
-		cfOpts.SetMaxBytesForLevelBase(cast.ToUint64(maxBytesForLevelBase))
+	newCFOpts, err = overrideCompactionOpts(cfOpts, appOpts)
+	if err != nil {
+		return nil, err
 	}
 
-	maxBytesForLevelMultiplier := appOpts.Get(maxBytesForLevelMultiplierCFOptName)
-	if maxBytesForLevelMultiplier != nil {
-		cfOpts.SetMaxBytesForLevelMultiplier(cast.ToFloat64(maxBytesForLevelMultiplier))
-	}
+	return newCFOpts, nil
+}
 
-	targetFileSizeBase := appOpts.Get(targetFileSizeBaseCFOptName)
-	if targetFileSizeBase != nil {
-		cfOpts.SetTargetFileSizeBase(cast.ToUint64(targetFileSizeBase))
+// optionsFromString returns new options created from opts and rocksdb options string optsStr,
+// opts are destroyed if new options are created, so they must not be used after successful call
+func optionsFromString(opts *grocksdb.Options, optsStr string) (*grocksdb.Options, error) {
+	newOpts, err := grocksdb.GetOptionsFromString(opts, optsStr)
+	if err != nil {
+		return nil, err
 	}
+	// opendb doesn't set comparator, merge operator, compaction filter or env owned by grocksdb options,
+	// so new options don't reference objects released by Destroy
+	opts.Destroy()
 
-	targetFileSizeMultiplier := appOpts.Get(targetFileSizeMultiplierCFOptName)
-	if targetFileSizeMultiplier != nil {
-		cfOpts.SetTargetFileSizeMultiplier(cast.ToInt(targetFileSizeMultiplier))
-	}
+	return newOpts, nil
+}
 
-	level0FileNumCompactionTrigger := appOpts.Get(level0FileNumCompactionTriggerCFOptName)
-	if level0FileNumCompactionTrigger != nil {
-		cfOpts.SetLevel0FileNumCompactionTrigger(cast.ToInt(level0FileNumCompactionTrigger))
+// readOptsFromAppOpts creates read options shared by all readers
+// it returns error if appOpts contain invalid value
+func readOptsFromAppOpts(appOpts AppOptions) (*grocksdb.ReadOptions, error) {
+	ro := grocksdb.NewDefaultReadOptions()
+	if err := applyOptionDefs(ro, appOpts, optionDef.readSetter); err != nil {
+		ro.Destroy()
+		return nil, err
 	}
 
-	level0SlowdownWritesTrigger := appOpts.Get(level0SlowdownWritesTriggerCFOptName)
-	if level0SlowdownWritesTrigger != nil {
-		cfOpts.SetLevel0SlowdownWritesTrigger(cast.ToInt(level0SlowdownWritesTrigger))
-	}
+	return ro, nil
+}
 
-	return cfOpts
+// metricsOpts contains options of metrics reporting
+type metricsOpts struct {
+	// enabled enables rocksdb statistics and metrics reporting
+	enabled bool
+	// reportInterval is an interval between metrics reports
+	reportInterval time.Duration
+	// reportAllStats enables reporting of every rocksdb statistic in addition to predefined metrics
+	reportAllStats bool
+	// reportTickerRates enables reporting of per second ticker rates calculated between reports
+	reportTickerRates bool
+	// mode is either tickerMetricsMode, metrics are reported every reportInterval, it's suitable for push-based setups,
+	// or scrapeMetricsMode, metrics are reported when prometheus collects them
+	mode string
+	// minRefreshInterval is a minimum interval between rocksdb queries in scrapeMetricsMode,
+	// more frequent scrapes collect cached metrics
+	minRefreshInterval time.Duration
+	// config configures where metrics are registered, it's provided by caller and can't be set in appOpts
+	config MetricsConfig
+	// sharedResources contains shared resources used by the database, they are set when database is opened
+	sharedResources *acquiredResources
+	// walDir is a directory of WAL files, it's database directory if wal_dir isn't specified
+	walDir string
 }
 
-func readOptsFromAppOpts(appOpts AppOptions) *grocksdb.ReadOptions {
-	ro := grocksdb.NewDefaultReadOptions()
-	asyncIO := appOpts.Get(asyncIOReadOptName)
-	if asyncIO != nil {
-		ro.SetAsyncIO(cast.ToBool(asyncIO))
+func metricsOptsFromAppOpts(appOpts AppOptions) (metricsOpts, error) {
+	// every option has default, so values are always set
+	values := make(map[string]optionValue)
+	for _, name := range []string{
+		enableMetricsOptName,
+		reportMetricsIntervalSecsOptName,
+		reportAllStatsOptName,
+		reportTickerRatesOptName,
+		metricsModeOptName,
+		scrapeMinRefreshIntervalOptName,
+	} {
+		var err error
+		values[name], _, err = optValue(appOpts, name)
+		if err != nil {
+			return metricsOpts{}, err
+		}
 	}
 
-	return ro
-}
+	reportInterval := values[reportMetricsIntervalSecsOptName].duration
+	if reportInterval == 0 {
+		reportInterval = defaultReportMetricsIntervalSecs * time.Second
+	}
 
-func bbtoFromAppOpts(appOpts AppOptions) *grocksdb.BlockBasedTableOptions {
-	bbto := defaultBBTO()
+	return metricsOpts{
+		enabled:            values[enableMetricsOptName].boolVal,
+		reportInterval:     reportInterval,
+		reportAllStats:     values[reportAllStatsOptName].boolVal,
+		reportTickerRates:  values[reportTickerRatesOptName].boolVal,
+		mode:               values[metricsModeOptName].stringVal,
+		minRefreshInterval: values[scrapeMinRefreshIntervalOptName].duration,
+	}, nil
+}
 
-	blockCacheSize := appOpts.Get(blockCacheSizeBBTOOptName)
-	if blockCacheSize != nil {
-		cache := grocksdb.NewLRUCache(cast.ToUint64(blockCacheSize))
-		bbto.SetBlockCache(cache)
+// blockCacheFromAppOpts creates block cache, its size and type can be overridden in appOpts
+func blockCacheFromAppOpts(appOpts AppOptions) (*grocksdb.Cache, error) {
+	blockCacheSize, _, err := optValue(appOpts, blockCacheSizeBBTOOptName)
+	if err != nil {
+		return nil, err
 	}
 
-	bitsPerKey := appOpts.Get(bitsPerKeyBBTOOptName)
-	if bitsPerKey != nil {
-		filter := grocksdb.NewBloomFilter(cast.ToFloat64(bitsPerKey))
-		bbto.SetFilterPolicy(filter)
+	cacheOpts, err := blockCacheOptsFromAppOpts(appOpts)
+	if err != nil {
+		return nil, err
 	}
 
-	blockSize := appOpts.Get(blockSizeBBTOOptName)
-	if blockSize != nil {
-		bbto.SetBlockSize(cast.ToInt(blockSize))
+	return cacheOpts.newCache(blockCacheSize.uintVal), nil
+}
+
+// bbtoFromAppOpts creates block based table options which use provided block cache and are shared by column families cfNames,
+// file is the latest OPTIONS file of the database, it's nil if database doesn't exist yet
+// NOTE: bbto takes ownership of filter policy created for it
+// it returns error if appOpts contain invalid value
+func bbtoFromAppOpts(appOpts AppOptions, cfNames []string, file optionsFile, blockCache *grocksdb.Cache) (*grocksdb.BlockBasedTableOptions, error) {
+	if err := checkIndexOpts(appOpts, cfNames, file); err != nil {
+		return nil, err
 	}
 
-	cacheIndexAndFilterBlocks := appOpts.Get(cacheIndexAndFilterBlocksBBTOOptName)
-	if cacheIndexAndFilterBlocks != nil {
-		bbto.SetCacheIndexAndFilterBlocks(cast.ToBool(cacheIndexAndFilterBlocks))
+	filterPolicy, err := filterPolicyFromAppOpts(appOpts)
+	if err != nil {
+		return nil, err
 	}
 
-	pinL0FilterAndIndexBlocksInCache := appOpts.Get(pinL0FilterAndIndexBlocksInCacheBBTOOptName)
-	if pinL0FilterAndIndexBlocksInCache != nil {
-		bbto.SetPinL0FilterAndIndexBlocksInCache(cast.ToBool(pinL0FilterAndIndexBlocksInCache))
+	bbto := grocksdb.NewDefaultBlockBasedTableOptions()
+	bbto.SetBlockCache(blockCache)
+	bbto.SetFilterPolicy(filterPolicy)
+
+	if err := applyOptionDefs(bbto, appOpts, optionDef.bbtoSetter); err != nil {
+		bbto.Destroy()
+		return nil, err
 	}
 
-	formatVersion := appOpts.Get(formatVersionBBTOOptName)
-	if formatVersion != nil {
-		bbto.SetFormatVersion(cast.ToInt(formatVersion))
+	return bbto, nil
+}
+
+// setBlockSize sets block size of bbto, rocksdb accepts it as int
+func setBlockSize(bbto *grocksdb.BlockBasedTableOptions, value optionValue) error {
+	if value.uintVal > math.MaxInt {
+		return fmt.Errorf("block size is too large: %v", value.uintVal)
 	}
+	bbto.SetBlockSize(int(value.uintVal))
 
-	return bbto
+	return nil
 }
 
 // newRocksDBWithOptions opens rocksdb with provided database and column family options
-// newRocksDBWithOptions expects that db has only one column family named default
+// cfNames should contain column family named default, column families which don't exist yet are created
 func newRocksDBWithOptions(
 	dbName string,
 	dir string,
 	dbOpts *grocksdb.Options,
-	cfOpts *grocksdb.Options,
+	cfNames []string,
+	cfOpts []*grocksdb.Options,
 	readOpts *grocksdb.ReadOptions,
-	enableMetrics bool,
-	reportMetricsIntervalSecs int64,
-) (*dbm.RocksDB, error) {
+	writeOpts *grocksdb.WriteOptions,
+	metricsOpts metricsOpts,
+) (*RocksDB, error) {
 	dbPath := filepath.Join(dir, dbName+".db")
+	if metricsOpts.walDir == "" {
+		metricsOpts.walDir = dbPath
+	}
 
 	// Ensure path exists
 	if err := os.MkdirAll(dbPath, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create db path: %w", err)
 	}
+	// rocksdb creates only the last directory of wal_dir, while subdirectory of database may be in wal_dir
+	// which doesn't exist yet
+	if err := os.MkdirAll(metricsOpts.walDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create wal dir: %w", err)
+	}
+
+	var (
+		dbMetrics        *Metrics
+		dbStatsCollector *statsCollector
+		err              error
+	)
+	if metricsOpts.enabled {
+		dbMetrics, err = registerMetrics(metricsOpts.config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to register metrics: %w", err)
+		}
+		if metricsOpts.reportAllStats {
+			dbStatsCollector, err = registerStatsCollector(metricsOpts.config, dbMetrics.scrapers)
+			if err != nil {
+				return nil, fmt.Errorf("failed to register stats collector: %w", err)
+			}
+		}
 
-	// EnableStatistics adds overhead so shouldn't be enabled in production
-	if enableMetrics {
+		// EnableStatistics adds overhead so shouldn't be enabled in production
 		dbOpts.EnableStatistics()
 	}
 
-	db, _, err := grocksdb.OpenDbColumnFamilies(dbOpts, dbPath, []string{DefaultColumnFamilyName}, []*grocksdb.Options{cfOpts})
+	dbOpts.SetCreateIfMissingColumnFamilies(true)
+	db, cfHandles, err := grocksdb.OpenDbColumnFamilies(dbOpts, dbPath, cfNames, cfOpts)
 	if err != nil {
 		return nil, err
 	}
 
-	if enableMetrics {
-		registerMetrics()
-		go reportMetrics(dbName, db, time.Second*time.Duration(reportMetricsIntervalSecs))
-	}
-
-	wo := grocksdb.NewDefaultWriteOptions()
 	woSync := grocksdb.NewDefaultWriteOptions()
 	woSync.SetSync(true)
-	return dbm.NewRocksDBWithRawDB(db, readOpts, wo, woSync), nil
+	rocksDB := newRocksDB(db, cfNames, cfHandles, readOpts, writeOpts, woSync)
+	rocksDB.name = dbName
+	rocksDB.path = dbPath
+	rocksDB.releaseOnClose(dbOpts.Destroy)
+	for _, opts := range cfOpts {
+		rocksDB.releaseOnClose(opts.Destroy)
+	}
+
+	if metricsOpts.enabled {
+		rocksDB.stopMetrics = startMetricsReporting(dbName, rocksDB.propsGetter(), dbMetrics, dbStatsCollector, metricsOpts)
+	}
+
+	return rocksDB, nil
 }
 
 // newDefaultOptions returns default tm-db options for RocksDB, see for details:
 // https://github.com/Kava-Labs/tm-db/blob/94ff76d31724965f8883cddebabe91e0d01bc03f/rocksdb.go#L30
+// options which can be configured are applied from newDBDefault of optionDefs, so they're reported as opendb defaults
 func newDefaultOptions() *grocksdb.Options {
 	// default rocksdb option, good enough for most cases, including heavy workloads.
-	// 1GB table cache, 512MB write buffer(may use 50% more on heavy workloads).
+	// 512MB write buffer(may use 50% more on heavy workloads).
 	// compression: snappy as default, need to -lsnappy to enable.
+	// table factory keeps its own copy of bbto, so bbto is destroyed right after it's set
 	bbto := defaultBBTO()
+	defer bbto.Destroy()
 
 	opts := grocksdb.NewDefaultOptions()
 	opts.SetBlockBasedTableFactory(bbto)
-	// SetMaxOpenFiles to 4096 seems to provide a reliable performance boost
-	opts.SetMaxOpenFiles(4096)
 	opts.SetCreateIfMissing(true)
+	// IncreaseParallelism is kept for sizes of background thread pools and OptimizeLevelStyleCompaction for
+	// compression per level, other options set by them are the same as newDBDefault of optionDefs
 	opts.IncreaseParallelism(runtime.NumCPU())
-	// 1.5GB maximum memory use for writebuffer.
-	opts.OptimizeLevelStyleCompaction(512 * 1024 * 1024)
+	opts.OptimizeLevelStyleCompaction(defaultMemtableMemoryBudget)
+	// newDBDefault values are valid, it's checked by tests
+	if err := applyOptionDefs(opts, newDBDefaults{}, optionDef.dbSetter); err != nil {
+		panic(err)
+	}
+	if err := applyOptionDefs(opts, newDBDefaults{}, optionDef.cfSetter); err != nil {
+		panic(err)
+	}
 
 	return opts
 }
 
+// newDBDefaults are AppOptions which contain newDBDefault of every option
+type newDBDefaults struct{}
+
+func (newDBDefaults) Get(key string) interface{} {
+	def, ok := optionDefsByName[normalizeOptName(key)]
+	if !ok {
+		return nil
+	}
+
+	return def.newDBDefault
+}
+
 // defaultBBTO returns default tm-db bbto options for RocksDB, see for details:
 // https://github.com/Kava-Labs/tm-db/blob/94ff76d31724965f8883cddebabe91e0d01bc03f/rocksdb.go#L30
+// openRocksdb replaces block based table factory with one which uses configured block cache (1GB by default),
+// so like cache of options loaded from OPTIONS file default cache doesn't need capacity
 func defaultBBTO() *grocksdb.BlockBasedTableOptions {
+	// bbto keeps its own reference to the cache
+	cache := grocksdb.NewLRUCache(0)
+	defer cache.Destroy()
+
 	bbto := grocksdb.NewDefaultBlockBasedTableOptions()
-	bbto.SetBlockCache(grocksdb.NewLRUCache(defaultBlockCacheSize))
-	bbto.SetFilterPolicy(grocksdb.NewBloomFilter(10))
+	bbto.SetBlockCache(cache)
+	bbto.SetFilterPolicy(grocksdb.NewBloomFilter(defaultBitsPerKey))
 
 	return bbto
 }
 
-// reportMetrics periodically requests stats from rocksdb and reports to prometheus
+// startMetricsReporting starts reporting metrics of the database according to metricsOpts mode,
+// it returns function which stops reporting and waits until it's stopped
+func startMetricsReporting(
+	dbName string,
+	db propsGetter,
+	metrics *Metrics,
+	statsCollector *statsCollector,
+	metricsOpts metricsOpts,
+) func() {
+	removeMetrics := func() {
+		metrics.remove(dbName)
+		if statsCollector != nil {
+			statsCollector.remove(dbName)
+		}
+	}
+
+	if metricsOpts.sharedResources != nil {
+		metrics.reportSharedResources(dbName, metricsOpts.sharedResources)
+	}
+
+	if metricsOpts.mode == scrapeMetricsMode {
+		metrics.scrapers.add(dbName, func() {
+			reportSnapshot(dbName, db, metrics, statsCollector, metricsOpts)
+		}, metricsOpts.minRefreshInterval)
+
+		return func() {
+			metrics.scrapers.remove(dbName)
+			removeMetrics()
+		}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		reportMetrics(dbName, db, metrics, statsCollector, metricsOpts, done)
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+		removeMetrics()
+	}
+}
+
+// reportMetrics periodically requests stats from rocksdb and reports to prometheus until done channel is closed
+// statsCollector is nil if report-all-stats flag isn't set
 // NOTE: should be launched as a goroutine
-func reportMetrics(dbName string, db *grocksdb.DB, interval time.Duration) {
-	ticker := time.NewTicker(interval)
+func reportMetrics(
+	dbName string,
+	db propsGetter,
+	metrics *Metrics,
+	statsCollector *statsCollector,
+	metricsOpts metricsOpts,
+	done <-chan struct{},
+) {
+	ticker := time.NewTicker(metricsOpts.reportInterval)
+	defer ticker.Stop()
 	for {
 		select {
+		case <-done:
+			return
 		case <-ticker.C:
-			props, stats, err := getPropsAndStats(db)
-			if err != nil {
-				continue
-			}
-
-			if rocksdbMetrics == nil {
-				continue
-			}
-			rocksdbMetrics.report(dbName, props, stats)
+			reportSnapshot(dbName, db, metrics, statsCollector, metricsOpts)
 		}
 	}
 }
 
+// reportSnapshot requests stats from rocksdb and reports them to prometheus
+// statsCollector is nil if report-all-stats flag isn't set
+func reportSnapshot(
+	dbName string,
+	db propsGetter,
+	metrics *Metrics,
+	statsCollector *statsCollector,
+	metricsOpts metricsOpts,
+) {
+	snapshot, err := getPropsAndStats(db, false)
+	if err != nil {
+		return
+	}
+
+	if statsCollector != nil {
+		statsCollector.update(dbName, snapshot.statMap)
+	}
+
+	metrics.report(dbName, snapshot.props, snapshot.stats)
+	metrics.reportTickers(dbName, snapshot.stats, snapshot.loadedAt, metricsOpts.reportTickerRates)
+
+	failures := snapshot.failures
+	walFiles, err := loadWALFiles(metricsOpts.walDir)
+	if err != nil {
+		failures = append(failures, walFilesStatName)
+	} else {
+		metrics.reportWALFiles(dbName, walFiles)
+	}
+	metrics.reportFailures(dbName, failures)
+}
+
+// snapshot contains properties and statistics loaded from rocksdb
+type snapshot struct {
+	props *properties
+	// statMap contains all parsed statistics
+	statMap map[string]*stat
+	// stats contains predefined statistics
+	stats *stats
+	// failures contains names of properties and statistics which can't be loaded
+	failures []string
+	// loadedAt is a time when snapshot was loaded
+	loadedAt time.Time
+}
+
 // getPropsAndStats gets statistics from rocksdb
-func getPropsAndStats(db *grocksdb.DB) (*properties, *stats, error) {
-	propsLoader := newPropsLoader(db)
+// in strict mode it fails if any property or statistic can't be loaded, it's intended to use in tests,
+// otherwise loading is best-effort: values which can't be loaded are left zero and listed in snapshot failures,
+// because set of rocksdb statistics changes between rocksdb releases
+func getPropsAndStats(db propsGetter, strict bool) (*snapshot, error) {
+	propsLoader := newPropsLoader(db, strict)
 	props, err := propsLoader.load()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
+	failures := propsLoader.failures()
 
-	statMap, err := parseSerializedStats(props.OptionsStatistics)
-	if err != nil {
-		return nil, nil, err
+	statMap, errs := parseSerializedStatsBestEffort(props.OptionsStatistics)
+	if len(errs) != 0 {
+		if strict {
+			return nil, errs[0]
+		}
+		failures = append(failures, optionsStatisticsPropName)
 	}
 
-	statLoader := newStatLoader(statMap)
+	statLoader := newStatLoader(statMap, strict)
 	stats, err := statLoader.load()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
+	failures = append(failures, statLoader.failures()...)
 
-	return props, stats, nil
+	return &snapshot{
+		props:    props,
+		statMap:  statMap,
+		stats:    stats,
+		failures: failures,
+		loadedAt: time.Now(),
+	}, nil
 }
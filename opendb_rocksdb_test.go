@@ -7,13 +7,21 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/linxGnu/grocksdb"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
 )
 
 const defaultDBName = "application"
 
+var testMetricsOpts = metricsOpts{
+	enabled:        true,
+	reportInterval: defaultReportMetricsIntervalSecs * time.Second,
+	mode:           tickerMetricsMode,
+}
+
 type mockAppOptions struct {
 	opts map[string]interface{}
 }
@@ -50,6 +58,85 @@ func TestRocksDBOptions(t *testing.T) {
 	require.Equal(t, 4096, txIndexDBOpts.Get("block_size"))
 }
 
+func TestRocksDBOptionsWALDir(t *testing.T) {
+	mockAppOptions := newMockAppOptions(map[string]interface{}{
+		"rocksdb.wal-dir":          "/mnt/wal",
+		"rocksdb.tx_index.wal_dir": "/mnt/wal/tx_index",
+	})
+
+	// fallback wal_dir is shared by databases, so every database uses its own subdirectory
+	value, key := newRocksDBOptions(mockAppOptions, "application").lookup(walDirDBOptName)
+	require.Equal(t, "/mnt/wal/application.db", value)
+	require.Equal(t, "rocksdb.wal-dir", key)
+	require.Equal(t, "/mnt/wal/blockstore.db", newRocksDBOptions(mockAppOptions, "blockstore").Get("wal-dir"))
+
+	// database-specific wal_dir is used as is
+	require.Equal(t, "/mnt/wal/tx_index", newRocksDBOptions(mockAppOptions, "tx_index").Get(walDirDBOptName))
+}
+
+func TestRocksDBOptionsSpellings(t *testing.T) {
+	mockAppOptions := newMockAppOptions(map[string]interface{}{
+		// fallback configuration uses underscores instead of dashes
+		"rocksdb.max_open_files": 16_384,
+		"rocksdb.block-size":     16_384,
+
+		// database-specific configuration with any spelling takes precedence over fallback configuration
+		"rocksdb.tx_index.max-open-files":                  4096,
+		"rocksdb.tx_index.cf.state-sync.write_buffer_size": 8192,
+	})
+
+	appDBOpts := newRocksDBOptions(mockAppOptions, "application")
+	require.Equal(t, 16_384, appDBOpts.Get("max-open-files"))
+	require.Equal(t, 16_384, appDBOpts.Get("block_size"))
+
+	txIndexDBOpts := newRocksDBOptions(mockAppOptions, "tx_index")
+	require.Equal(t, 4096, txIndexDBOpts.Get("max_open_files"))
+	require.Equal(t, 8192, newColumnFamilyOptions(txIndexDBOpts, "state-sync").Get("write-buffer-size"))
+	require.Nil(t, newColumnFamilyOptions(txIndexDBOpts, "state_sync").Get("write-buffer-size"))
+}
+
+func TestColumnFamilyOptions(t *testing.T) {
+	mockAppOptions := newMockAppOptions(map[string]interface{}{
+		// fallback configuration
+		"rocksdb.write-buffer-size": 16_384,
+		"rocksdb.num-levels":        7,
+
+		// column family specific fallback configuration
+		"rocksdb.cf.orphans.write-buffer-size": 8192,
+
+		// database-specific configuration
+		"rocksdb.application.num-levels": 5,
+
+		// database and column family specific configuration
+		"rocksdb.application.cf.nodes.write-buffer-size": 4096,
+		"rocksdb.application.cf.nodes.num-levels":        3,
+	})
+	appDBOpts := newRocksDBOptions(mockAppOptions, "application")
+
+	// there isn't column family specific configuration for default column family, so use database configuration
+	defaultCFOpts := newColumnFamilyOptions(appDBOpts, DefaultColumnFamilyName)
+	require.Equal(t, 16_384, defaultCFOpts.Get("write-buffer-size"))
+	require.Equal(t, 5, defaultCFOpts.Get("num-levels"))
+
+	// there is column family specific fallback configuration for orphans column family
+	orphansCFOpts := newColumnFamilyOptions(appDBOpts, "orphans")
+	require.Equal(t, 8192, orphansCFOpts.Get("write-buffer-size"))
+	require.Equal(t, 5, orphansCFOpts.Get("num-levels"))
+
+	// there is database and column family specific configuration for nodes column family
+	nodesCFOpts := newColumnFamilyOptions(appDBOpts, "nodes")
+	require.Equal(t, 4096, nodesCFOpts.Get("write-buffer-size"))
+	require.Equal(t, 3, nodesCFOpts.Get("num-levels"))
+
+	// lookup resolves key which value is taken from through both wrappers
+	_, key := lookupOpt(nodesCFOpts, "write-buffer-size")
+	require.Equal(t, "rocksdb.application.cf.nodes.write-buffer-size", key)
+	_, key = lookupOpt(orphansCFOpts, "num-levels")
+	require.Equal(t, "rocksdb.application.num-levels", key)
+	_, key = lookupOpt(defaultCFOpts, "max-open-files")
+	require.Empty(t, key)
+}
+
 func TestOpenRocksdb(t *testing.T) {
 	t.Run("db already exists", func(t *testing.T) {
 		defaultOpts := newDefaultOptions()
@@ -103,7 +190,7 @@ func TestOpenRocksdb(t *testing.T) {
 					require.NoError(t, err)
 				}()
 
-				db, err := openRocksdb(dir, defaultDBName, tc.mockAppOptions)
+				db, err := openRocksdb(dir, defaultDBName, tc.mockAppOptions, MetricsConfig{})
 				require.NoError(t, err)
 				require.NoError(t, db.Close())
 
@@ -128,7 +215,7 @@ func TestOpenRocksdb(t *testing.T) {
 		}()
 
 		mockAppOpts := newMockAppOptions(map[string]interface{}{})
-		db, err := openRocksdb(dir, defaultDBName, mockAppOpts)
+		db, err := openRocksdb(dir, defaultDBName, mockAppOpts, MetricsConfig{})
 		require.NoError(t, err)
 		require.NoError(t, db.Close())
 
@@ -139,6 +226,49 @@ func TestOpenRocksdb(t *testing.T) {
 		require.Equal(t, defaultOpts.GetWriteBufferSize(), cfOpts.GetWriteBufferSize())
 		require.Equal(t, defaultOpts.GetNumLevels(), cfOpts.GetNumLevels())
 	})
+
+	t.Run("multiple column families", func(t *testing.T) {
+		defaultOpts := newDefaultOptions()
+
+		dir, err := os.MkdirTemp("", "rocksdb")
+		require.NoError(t, err)
+		defer func() {
+			err := os.RemoveAll(dir)
+			require.NoError(t, err)
+		}()
+
+		mockAppOpts := newMockAppOptions(map[string]interface{}{
+			columnFamiliesOptName:                    []string{"nodes", "orphans"},
+			writeBufferSizeCFOptName:                 999_999,
+			"cf.nodes." + writeBufferSizeCFOptName:   777_777,
+			"cf.orphans." + numLevelsCFOptName:       9,
+			"cf.unknown." + writeBufferSizeCFOptName: 555_555,
+		})
+		db, err := openRocksdb(dir, defaultDBName, mockAppOpts, MetricsConfig{})
+		require.NoError(t, err)
+		require.Equal(t, []string{DefaultColumnFamilyName, "nodes", "orphans"}, db.ColumnFamilyNames())
+		require.NoError(t, db.Close())
+
+		// column families created during first opening are opened even if they aren't requested anymore
+		db, err = openRocksdb(dir, defaultDBName, newMockAppOptions(map[string]interface{}{}), MetricsConfig{})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{DefaultColumnFamilyName, "nodes", "orphans"}, db.ColumnFamilyNames())
+		require.NoError(t, db.Close())
+
+		_, cfNames, cfOptsList, err := LoadLatestColumnFamilyOptions(filepath.Join(dir, "application.db"))
+		require.NoError(t, err)
+		cfOpts := make(map[string]*grocksdb.Options)
+		for i, cfName := range cfNames {
+			cfOpts[cfName] = cfOptsList[i]
+		}
+		require.Len(t, cfOpts, 3)
+		require.Equal(t, uint64(999_999), cfOpts[DefaultColumnFamilyName].GetWriteBufferSize())
+		require.Equal(t, defaultOpts.GetNumLevels(), cfOpts[DefaultColumnFamilyName].GetNumLevels())
+		require.Equal(t, uint64(777_777), cfOpts["nodes"].GetWriteBufferSize())
+		require.Equal(t, defaultOpts.GetNumLevels(), cfOpts["nodes"].GetNumLevels())
+		require.Equal(t, uint64(999_999), cfOpts["orphans"].GetWriteBufferSize())
+		require.Equal(t, 9, cfOpts["orphans"].GetNumLevels())
+	})
 }
 
 func TestLoadLatestOptions(t *testing.T) {
@@ -210,7 +340,7 @@ func TestLoadLatestOptions(t *testing.T) {
 					require.NoError(t, err)
 				}()
 
-				db, err := newRocksDBWithOptions(name, dir, tc.dbOpts, tc.cfOpts, grocksdb.NewDefaultReadOptions(), true, defaultReportMetricsIntervalSecs)
+				db, err := newRocksDBWithOptions(name, dir, tc.dbOpts, []string{DefaultColumnFamilyName}, []*grocksdb.Options{tc.cfOpts}, grocksdb.NewDefaultReadOptions(), grocksdb.NewDefaultWriteOptions(), testMetricsOpts)
 				require.NoError(t, err)
 				require.NoError(t, db.Close())
 
@@ -285,8 +415,8 @@ func TestOverrideDBOpts(t *testing.T) {
 		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
-			dbOpts := newDefaultOptions()
-			dbOpts = overrideDBOpts(dbOpts, tc.mockAppOptions)
+			dbOpts, err := overrideDBOpts(newDefaultOptions(), tc.mockAppOptions)
+			require.NoError(t, err)
 
 			require.Equal(t, tc.maxOpenFiles, dbOpts.GetMaxOpenFiles())
 			require.Equal(t, tc.maxFileOpeningThreads, dbOpts.GetMaxFileOpeningThreads())
@@ -334,10 +464,19 @@ func TestOverrideCFOpts(t *testing.T) {
 			writeBufferSize: 999_999,
 			numLevels:       9,
 		},
+		{
+			desc: "override write-buffer-size with human-readable size",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				writeBufferSizeCFOptName: "128MiB",
+			}),
+			writeBufferSize: 128 << 20,
+			numLevels:       defaultOpts.GetNumLevels(),
+		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
 			cfOpts := newDefaultOptions()
-			cfOpts = overrideCFOpts(cfOpts, tc.mockAppOptions)
+			cfOpts, err := overrideCFOpts(cfOpts, tc.mockAppOptions)
+			require.NoError(t, err)
 
 			require.Equal(t, tc.writeBufferSize, cfOpts.GetWriteBufferSize())
 			require.Equal(t, tc.numLevels, cfOpts.GetNumLevels())
@@ -345,11 +484,30 @@ func TestOverrideCFOpts(t *testing.T) {
 	}
 }
 
+func TestOptionsFromString(t *testing.T) {
+	opts := newDefaultOptions()
+	opts.SetWriteBufferSize(128 << 20)
+
+	// options aren't destroyed if options string is invalid
+	_, err := optionsFromString(opts, "unknown_option=1")
+	require.Error(t, err)
+	require.Equal(t, uint64(128<<20), opts.GetWriteBufferSize())
+
+	newOpts, err := optionsFromString(opts, "num_levels=5")
+	require.NoError(t, err)
+	defer newOpts.Destroy()
+	require.Equal(t, 5, newOpts.GetNumLevels())
+	require.Equal(t, uint64(128<<20), newOpts.GetWriteBufferSize())
+}
+
 func TestReadOptsFromAppOpts(t *testing.T) {
 	for _, tc := range []struct {
-		desc           string
-		mockAppOptions *mockAppOptions
-		asyncIO        bool
+		desc              string
+		mockAppOptions    *mockAppOptions
+		asyncIO           bool
+		prefixSameAsStart bool
+		totalOrderSeek    bool
+		readaheadSize     uint64
 	}{
 		{
 			desc:           "default options",
@@ -363,11 +521,118 @@ func TestReadOptsFromAppOpts(t *testing.T) {
 			}),
 			asyncIO: true,
 		},
+		{
+			desc: "set prefix options",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				prefixSameAsStartReadOptName: true,
+				totalOrderSeekReadOptName:    true,
+			}),
+			prefixSameAsStart: true,
+			totalOrderSeek:    true,
+		},
+		{
+			desc: "set readahead size",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				readaheadSizeReadOptName: 2 << 20,
+			}),
+			readaheadSize: 2 << 20,
+		},
+		{
+			desc: "set human-readable readahead size",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				readaheadSizeReadOptName: "2MiB",
+			}),
+			readaheadSize: 2 << 20,
+		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
-			readOpts := readOptsFromAppOpts(tc.mockAppOptions)
+			readOpts, err := readOptsFromAppOpts(tc.mockAppOptions)
+			require.NoError(t, err)
 
 			require.Equal(t, tc.asyncIO, readOpts.IsAsyncIO())
+			require.Equal(t, tc.prefixSameAsStart, readOpts.PrefixSameAsStart())
+			require.Equal(t, tc.totalOrderSeek, readOpts.GetTotalOrderSeek())
+			require.Equal(t, tc.readaheadSize, readOpts.GetReadaheadSize())
+		})
+	}
+}
+
+func TestMetricsOptsFromAppOpts(t *testing.T) {
+	for _, tc := range []struct {
+		desc           string
+		mockAppOptions *mockAppOptions
+		expectedOpts   metricsOpts
+		success        bool
+	}{
+		{
+			desc:           "default options",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{}),
+			expectedOpts: metricsOpts{
+				reportInterval:     defaultReportMetricsIntervalSecs * time.Second,
+				mode:               tickerMetricsMode,
+				minRefreshInterval: defaultScrapeMinRefreshInterval,
+			},
+			success: true,
+		},
+		{
+			desc: "scrape mode",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				enableMetricsOptName:            true,
+				metricsModeOptName:              scrapeMetricsMode,
+				scrapeMinRefreshIntervalOptName: 0.5,
+			}),
+			expectedOpts: metricsOpts{
+				enabled:            true,
+				reportInterval:     defaultReportMetricsIntervalSecs * time.Second,
+				mode:               scrapeMetricsMode,
+				minRefreshInterval: 500 * time.Millisecond,
+			},
+			success: true,
+		},
+		{
+			desc: "human-readable intervals",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				reportMetricsIntervalSecsOptName: "1m",
+				scrapeMinRefreshIntervalOptName:  "250ms",
+			}),
+			expectedOpts: metricsOpts{
+				reportInterval:     time.Minute,
+				mode:               tickerMetricsMode,
+				minRefreshInterval: 250 * time.Millisecond,
+			},
+			success: true,
+		},
+		{
+			desc: "invalid interval",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				reportMetricsIntervalSecsOptName: "15 seconds",
+			}),
+			success: false,
+		},
+		{
+			desc: "unknown mode",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				metricsModeOptName: "push",
+			}),
+			success: false,
+		},
+		{
+			desc: "invalid flag",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				enableMetricsOptName: "yes",
+			}),
+			success: false,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			opts, err := metricsOptsFromAppOpts(tc.mockAppOptions)
+			if !tc.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedOpts, opts)
 		})
 	}
 }
@@ -388,7 +653,7 @@ func TestNewRocksDBWithOptions(t *testing.T) {
 	cfOpts := newDefaultOptions()
 	cfOpts.SetWriteBufferSize(999_999)
 
-	db, err := newRocksDBWithOptions(name, dir, dbOpts, cfOpts, grocksdb.NewDefaultReadOptions(), true, defaultReportMetricsIntervalSecs)
+	db, err := newRocksDBWithOptions(name, dir, dbOpts, []string{DefaultColumnFamilyName}, []*grocksdb.Options{cfOpts}, grocksdb.NewDefaultReadOptions(), grocksdb.NewDefaultWriteOptions(), testMetricsOpts)
 	require.NoError(t, err)
 	require.NoError(t, db.Close())
 
@@ -406,3 +671,102 @@ func TestNewDefaultOptions(t *testing.T) {
 	maxOpenFiles := defaultOpts.GetMaxOpenFiles()
 	require.Equal(t, 4096, maxOpenFiles)
 }
+
+func TestReportMetricsStops(t *testing.T) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		reportMetrics(defaultDBName, nil, nil, nil, metricsOpts{enabled: true, reportInterval: time.Hour}, done)
+	}()
+
+	close(done)
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reportMetrics should stop after done channel is closed")
+	}
+}
+
+func TestCloseStopsMetricsReporting(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	mockAppOpts := newMockAppOptions(map[string]interface{}{
+		enableMetricsOptName:             true,
+		reportMetricsIntervalSecsOptName: 1,
+	})
+	db, err := openRocksdb(dir, defaultDBName, mockAppOpts, MetricsConfig{})
+	require.NoError(t, err)
+
+	stopped := make(chan struct{})
+	stopMetrics := db.stopMetrics
+	db.stopMetrics = func() {
+		stopMetrics()
+		close(stopped)
+	}
+
+	require.NoError(t, db.Close())
+	select {
+	case <-stopped:
+	default:
+		t.Fatal("metrics reporting should be stopped when database is closed")
+	}
+	require.Nil(t, db.releaseFuncs)
+}
+
+func TestScrapeModeMetricsReporting(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	mockAppOpts := newMockAppOptions(map[string]interface{}{
+		enableMetricsOptName: true,
+		metricsModeOptName:   scrapeMetricsMode,
+	})
+	config := MetricsConfig{Registerer: stdprometheus.NewRegistry()}
+	db, err := openRocksdb(dir, defaultDBName, mockAppOpts, config)
+	require.NoError(t, err)
+
+	metrics, err := registerMetrics(config)
+	require.NoError(t, err)
+	require.Contains(t, metrics.scrapers.scrapers, defaultDBName)
+
+	require.NoError(t, db.Close())
+	require.NotContains(t, metrics.scrapers.scrapers, defaultDBName)
+}
+
+func TestGetPropsAndStats(t *testing.T) {
+	mockPropsGetter := newMockPropsGetter(
+		map[string]string{
+			"rocksdb.options-statistics": `rocksdb.number.keys.written COUNT : 1
+rocksdb.unknown.stat COUNT : 2
+rocksdb.db.get.micros P50 :
+`,
+		},
+		map[string]uint64{
+			"rocksdb.estimate-num-keys": 3,
+		},
+	)
+
+	_, err := getPropsAndStats(mockPropsGetter, true)
+	require.Error(t, err)
+
+	snapshot, err := getPropsAndStats(mockPropsGetter, false)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), snapshot.props.EstimateNumKeys)
+	require.Equal(t, int64(1), snapshot.stats.NumberKeysWritten)
+	require.Contains(t, snapshot.statMap, "rocksdb.unknown.stat")
+	require.Contains(t, snapshot.failures, "rocksdb.block-cache-usage")
+	require.Contains(t, snapshot.failures, optionsStatisticsPropName)
+	require.Contains(t, snapshot.failures, "rocksdb.db.get.micros")
+	require.NotContains(t, snapshot.failures, "rocksdb.estimate-num-keys")
+	require.NotContains(t, snapshot.failures, "rocksdb.number.keys.written")
+}
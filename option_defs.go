@@ -0,0 +1,1035 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/linxGnu/grocksdb"
+	"github.com/spf13/cast"
+)
+
+// optSizeUnits maps lowercase units of human-readable sizes in appOpts to number of bytes,
+// all units are powers of 1024 as in rocksdb
+var optSizeUnits = map[string]uint64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1 << 10,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1 << 20,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1 << 30,
+	"gib": 1 << 30,
+	"t":   1 << 40,
+	"tb":  1 << 40,
+	"tib": 1 << 40,
+}
+
+// optionScope is a set of rocksdb options which option belongs to
+type optionScope int
+
+const (
+	// dbScope options are applied to database options
+	dbScope optionScope = iota
+	// cfScope options are applied to column family options, they can be overridden in cf.<name> section
+	cfScope
+	// bbtoScope options are applied to block based table options shared by all column families
+	bbtoScope
+	// readScope options are applied to read options shared by all readers
+	readScope
+	// writeScope options are applied to write options of writes which aren't synced
+	writeScope
+	// opendbScope options configure opendb itself, for example metrics and shared resources
+	opendbScope
+)
+
+func (s optionScope) String() string {
+	switch s {
+	case dbScope:
+		return "db"
+	case cfScope:
+		return "cf"
+	case bbtoScope:
+		return "bbto"
+	case readScope:
+		return "read"
+	case writeScope:
+		return "write"
+	case opendbScope:
+		return "opendb"
+	default:
+		return "unknown"
+	}
+}
+
+// optionType is a type of option value
+type optionType int
+
+const (
+	boolOption optionType = iota
+	intOption
+	uintOption
+	floatOption
+	stringOption
+	stringSliceOption
+	// sizeOption is a number of bytes, it accepts human-readable sizes, for example 128MiB
+	sizeOption
+	// durationOption is a duration, it accepts numbers in unit of the option and durations like 15s
+	durationOption
+)
+
+func (t optionType) String() string {
+	switch t {
+	case boolOption:
+		return "bool"
+	case intOption:
+		return "int"
+	case uintOption:
+		return "uint"
+	case floatOption:
+		return "float"
+	case stringOption:
+		return "string"
+	case stringSliceOption:
+		return "string slice"
+	case sizeOption:
+		return "size"
+	case durationOption:
+		return "duration"
+	default:
+		return "unknown"
+	}
+}
+
+// check returns error if value can't be converted to the type
+func (t optionType) check(value interface{}) error {
+	var err error
+	switch t {
+	case boolOption:
+		_, err = cast.ToBoolE(value)
+	case intOption:
+		_, err = cast.ToInt64E(value)
+	case uintOption:
+		_, err = cast.ToUint64E(value)
+	case floatOption:
+		_, err = cast.ToFloat64E(value)
+	case stringOption:
+		_, err = cast.ToStringE(value)
+	case stringSliceOption:
+		_, err = cast.ToStringSliceE(value)
+	case sizeOption:
+		_, err = parseSizeValue(value)
+	case durationOption:
+		_, err = parseDurationValue(value, time.Second)
+	}
+
+	return err
+}
+
+// optionValue is a value of option converted according to option type, only field of the type is set
+type optionValue struct {
+	boolVal   bool
+	intVal    int64
+	uintVal   uint64 // value of uint and size options
+	floatVal  float64
+	stringVal string
+	sliceVal  []string
+	duration  time.Duration
+}
+
+// optionDef describes option accepted in [rocksdb] and [rocksdb.<db>] sections
+type optionDef struct {
+	name  string
+	scope optionScope
+	typ   optionType
+	// unit is a unit of numeric values of duration option, for example time.Second for ttl
+	unit time.Duration
+	// defaultValue is a value used by opendb if option isn't specified, it's nil if value loaded from
+	// rocksdb OPTIONS file or rocksdb default is kept
+	defaultValue interface{}
+	// newDBDefault is a value used by opendb if option isn't specified for database or column family created by opendb,
+	// existing ones keep value of OPTIONS file, it's nil if rocksdb default is used for new ones
+	newDBDefault interface{}
+	// newDBDefaultDoc is shown in options reference instead of newDBDefault which depends on machine
+	newDBDefaultDoc string
+	// mutable is true if option can be changed at runtime with SetOptions, only column family options can be mutable,
+	// see Runtime option changes in README
+	mutable bool
+	// rocksdbName is a name of the option in rocksdb options string and OPTIONS file,
+	// it's empty if option isn't persisted by rocksdb
+	rocksdbName string
+	// format converts value into value of rocksdb options string, value is formatted according to type by default
+	format func(value optionValue) (string, error)
+	// validate checks value which is already converted according to type, for example that it's a known name
+	validate func(value optionValue) error
+	// setDB, setCF, setBBTO, setRead and setWrite apply value to options of the scope, only setter of the scope
+	// can be set, it's nil if option is applied together with related options or configures opendb
+	setDB    func(dbOpts *grocksdb.Options, value optionValue) error
+	setCF    func(cfOpts *grocksdb.Options, value optionValue) error
+	setBBTO  func(bbto *grocksdb.BlockBasedTableOptions, value optionValue) error
+	setRead  func(ro *grocksdb.ReadOptions, value optionValue) error
+	setWrite func(wo *grocksdb.WriteOptions, value optionValue) error
+	// description is shown in options reference of README
+	description string
+}
+
+// optionDefs are all options accepted in appOpts, overrides, validation, runtime changes, options reference
+// and effective options are derived from them
+var optionDefs = []optionDef{
+	{
+		name: configValidationOptName, scope: opendbScope, typ: stringOption, defaultValue: warnConfigValidation,
+		validate:    validateOneOf(strictConfigValidation, warnConfigValidation, offConfigValidation),
+		description: "what happens if config contains unknown options or invalid values: strict, warn or off",
+	},
+	{
+		name: columnFamiliesOptName, scope: opendbScope, typ: stringSliceOption,
+		description: "column families which are created if they don't exist yet",
+	},
+
+	{
+		name: enableMetricsOptName, scope: opendbScope, typ: boolOption, defaultValue: false,
+		description: "enables rocksdb statistics and metrics reporting",
+	},
+	{
+		name: reportMetricsIntervalSecsOptName, scope: opendbScope, typ: durationOption, unit: time.Second,
+		defaultValue: defaultReportMetricsIntervalSecs,
+		description:  "interval between metrics reports in ticker mode",
+	},
+	{
+		name: reportAllStatsOptName, scope: opendbScope, typ: boolOption, defaultValue: false,
+		description: "reports every rocksdb statistic in addition to predefined metrics",
+	},
+	{
+		name: reportTickerRatesOptName, scope: opendbScope, typ: boolOption, defaultValue: false,
+		description: "reports per second rates of tickers",
+	},
+	{
+		name: metricsModeOptName, scope: opendbScope, typ: stringOption, defaultValue: tickerMetricsMode,
+		validate:    validateOneOf(tickerMetricsMode, scrapeMetricsMode),
+		description: "ticker reports metrics periodically, scrape reports them when prometheus collects them",
+	},
+	{
+		name: scrapeMinRefreshIntervalOptName, scope: opendbScope, typ: durationOption, unit: time.Second,
+		defaultValue: defaultScrapeMinRefreshInterval.String(),
+		description:  "minimum interval between rocksdb queries in scrape mode",
+	},
+
+	{
+		name: maxOpenFilesDBOptName, scope: dbScope, typ: intOption, rocksdbName: "max_open_files",
+		newDBDefault: defaultMaxOpenFiles,
+		setDB:        setInt((*grocksdb.Options).SetMaxOpenFiles),
+		description:  "number of open files which can be used by the database, -1 keeps all files open",
+	},
+	{
+		name: maxFileOpeningThreadsDBOptName, scope: dbScope, typ: intOption, rocksdbName: "max_file_opening_threads",
+		setDB:       setInt((*grocksdb.Options).SetMaxFileOpeningThreads),
+		description: "number of threads used to open files when database is opened",
+	},
+	{
+		name: tableCacheNumshardbitsDBOptName, scope: dbScope, typ: intOption, rocksdbName: "table_cache_numshardbits",
+		setDB:       setInt((*grocksdb.Options).SetTableCacheNumshardbits),
+		description: "number of bits used to choose table cache shard",
+	},
+	{
+		name: allowMMAPWritesDBOptName, scope: dbScope, typ: boolOption, rocksdbName: "allow_mmap_writes",
+		setDB:       setBool((*grocksdb.Options).SetAllowMmapWrites),
+		description: "uses mmap to write files",
+	},
+	{
+		name: allowMMAPReadsDBOptName, scope: dbScope, typ: boolOption, rocksdbName: "allow_mmap_reads",
+		setDB:       setBool((*grocksdb.Options).SetAllowMmapReads),
+		description: "uses mmap to read files",
+	},
+	{
+		name: useFsyncDBOptName, scope: dbScope, typ: boolOption, rocksdbName: "use_fsync",
+		setDB:       setBool((*grocksdb.Options).SetUseFsync),
+		description: "uses fsync instead of fdatasync",
+	},
+	{
+		name: useAdaptiveMutexDBOptName, scope: dbScope, typ: boolOption, rocksdbName: "use_adaptive_mutex",
+		setDB:       setBool((*grocksdb.Options).SetUseAdaptiveMutex),
+		description: "spins mutex before blocking",
+	},
+	{
+		name: bytesPerSyncDBOptName, scope: dbScope, typ: sizeOption, rocksdbName: "bytes_per_sync",
+		setDB:       setUint64((*grocksdb.Options).SetBytesPerSync),
+		description: "size of data written to SST files which is synced incrementally, 0 disables it",
+	},
+	{
+		name: maxBackgroundJobsDBOptName, scope: dbScope, typ: intOption, rocksdbName: "max_background_jobs",
+		newDBDefault: runtime.NumCPU(), newDBDefaultDoc: "number of CPUs",
+		setDB:       setInt((*grocksdb.Options).SetMaxBackgroundJobs),
+		description: "maximum number of concurrent flushes and compactions",
+	},
+	{
+		name: useDirectReadsDBOptName, scope: dbScope, typ: boolOption, rocksdbName: "use_direct_reads",
+		setDB:       setBool((*grocksdb.Options).SetUseDirectReads),
+		description: "reads bypass OS page cache, can't be used with allow_mmap_reads",
+	},
+	{
+		name: useDirectIOForFlushAndCompactionDBOptName, scope: dbScope, typ: boolOption,
+		rocksdbName: "use_direct_io_for_flush_and_compaction",
+		setDB:       setBool((*grocksdb.Options).SetUseDirectIOForFlushAndCompaction),
+		description: "flushes and compactions bypass OS page cache, can't be used with allow_mmap_writes",
+	},
+	{
+		name: compactionReadaheadSizeDBOptName, scope: dbScope, typ: sizeOption,
+		rocksdbName: "compaction_readahead_size",
+		setDB:       setUint64((*grocksdb.Options).CompactionReadaheadSize),
+		description: "size of readahead of compaction inputs, it should be set with direct reads",
+	},
+	{
+		name: writableFileMaxBufferSizeDBOptName, scope: dbScope, typ: sizeOption,
+		rocksdbName: "writable_file_max_buffer_size",
+		setDB:       setUint64((*grocksdb.Options).SetWritableFileMaxBufferSize),
+		description: "maximum size of buffer of writable files",
+	},
+	{
+		name: rateLimiterBytesPerSecDBOptName, scope: dbScope, typ: sizeOption, defaultValue: 0,
+		description: "limit of flush and compaction write rate, 0 disables rate limiter",
+	},
+	{
+		name: rateLimiterRefillPeriodUsDBOptName, scope: dbScope, typ: durationOption, unit: time.Microsecond,
+		defaultValue: defaultRateLimiterRefillPeriodUs,
+		description:  "period of refilling rate limiter tokens, numbers are microseconds",
+	},
+	{
+		name: rateLimiterFairnessDBOptName, scope: dbScope, typ: intOption, defaultValue: defaultRateLimiterFairness,
+		description: "chance (1/fairness) of compactions to be served before flushes",
+	},
+	{
+		name: rateLimiterAutoTunedDBOptName, scope: dbScope, typ: boolOption, defaultValue: false,
+		description: "adjusts rate limit according to demand for background I/O",
+	},
+	{
+		name: walDirDBOptName, scope: dbScope, typ: stringOption, rocksdbName: "wal_dir",
+		setDB:       setString((*grocksdb.Options).SetWalDir),
+		description: "directory of write-ahead logs, database directory by default, fallback wal_dir gets a subdirectory per database",
+	},
+	{
+		name: maxTotalWALSizeDBOptName, scope: dbScope, typ: sizeOption, rocksdbName: "max_total_wal_size",
+		setDB:       setUint64((*grocksdb.Options).SetMaxTotalWalSize),
+		description: "total size of WAL files which forces flush of the oldest memtables",
+	},
+	{
+		name: walTTLSecondsDBOptName, scope: dbScope, typ: durationOption, unit: time.Second, rocksdbName: "WAL_ttl_seconds",
+		setDB:       setSeconds((*grocksdb.Options).SetWALTtlSeconds),
+		description: "time obsolete WAL files are kept in archive",
+	},
+	{
+		name: walSizeLimitMBDBOptName, scope: dbScope, typ: uintOption, rocksdbName: "WAL_size_limit_MB",
+		setDB:       setUint64((*grocksdb.Options).SetWalSizeLimitMb),
+		description: "size of WAL archive in megabytes",
+	},
+	{
+		name: manualWALFlushDBOptName, scope: dbScope, typ: boolOption, rocksdbName: "manual_wal_flush",
+		setDB:       setBool((*grocksdb.Options).SetManualWALFlush),
+		description: "WAL buffer is flushed only by synced writes",
+	},
+	{
+		name: walRecoveryModeDBOptName, scope: dbScope, typ: stringOption, rocksdbName: "wal_recovery_mode",
+		format:      formatWALRecoveryMode,
+		validate:    validateWith(parseWALRecoveryMode),
+		setDB:       setWALRecoveryMode,
+		description: "tolerate_corrupted_tail_records, absolute_consistency, point_in_time or skip_any_corrupted_records",
+	},
+	{
+		name: walCompressionDBOptName, scope: dbScope, typ: stringOption, rocksdbName: "wal_compression",
+		format:      formatCompressionType,
+		validate:    validateWALCompression,
+		setDB:       setCompressionType((*grocksdb.Options).SetWALCompression),
+		description: "compression of WAL records: none or zstd",
+	},
+
+	{
+		name: writeBufferSizeCFOptName, scope: cfScope, typ: sizeOption, mutable: true, rocksdbName: "write_buffer_size",
+		newDBDefault: defaultMemtableMemoryBudget / 4,
+		setCF:        setUint64((*grocksdb.Options).SetWriteBufferSize),
+		description:  "size of memtable",
+	},
+	{
+		name: numLevelsCFOptName, scope: cfScope, typ: intOption, rocksdbName: "num_levels",
+		setCF:       setInt((*grocksdb.Options).SetNumLevels),
+		description: "number of levels",
+	},
+	{
+		name: maxWriteBufferNumberCFOptName, scope: cfScope, typ: intOption, mutable: true,
+		rocksdbName:  "max_write_buffer_number",
+		newDBDefault: 6,
+		setCF:        setInt((*grocksdb.Options).SetMaxWriteBufferNumber),
+		description:  "maximum number of memtables",
+	},
+	{
+		name: minWriteBufferNumberToMergeCFOptName, scope: cfScope, typ: intOption,
+		rocksdbName:  "min_write_buffer_number_to_merge",
+		newDBDefault: 2,
+		setCF:        setInt((*grocksdb.Options).SetMinWriteBufferNumberToMerge),
+		description:  "minimum number of memtables merged before flush",
+	},
+	{
+		name: maxBytesForLevelBaseCFOptName, scope: cfScope, typ: sizeOption, mutable: true,
+		rocksdbName:  "max_bytes_for_level_base",
+		newDBDefault: defaultMemtableMemoryBudget,
+		setCF:        setUint64((*grocksdb.Options).SetMaxBytesForLevelBase),
+		description:  "maximum total size of level 1",
+	},
+	{
+		name: maxBytesForLevelMultiplierCFOptName, scope: cfScope, typ: floatOption, mutable: true,
+		rocksdbName: "max_bytes_for_level_multiplier",
+		setCF:       setFloat64((*grocksdb.Options).SetMaxBytesForLevelMultiplier),
+		description: "ratio between maximum total sizes of adjacent levels",
+	},
+	{
+		name: targetFileSizeBaseCFOptName, scope: cfScope, typ: sizeOption, mutable: true,
+		rocksdbName:  "target_file_size_base",
+		newDBDefault: defaultMemtableMemoryBudget / 8,
+		setCF:        setUint64((*grocksdb.Options).SetTargetFileSizeBase),
+		description:  "target size of SST files of level 1",
+	},
+	{
+		name: targetFileSizeMultiplierCFOptName, scope: cfScope, typ: intOption, mutable: true,
+		rocksdbName: "target_file_size_multiplier",
+		setCF:       setInt((*grocksdb.Options).SetTargetFileSizeMultiplier),
+		description: "ratio between target sizes of SST files of adjacent levels",
+	},
+	{
+		name: level0FileNumCompactionTriggerCFOptName, scope: cfScope, typ: intOption, mutable: true,
+		rocksdbName:  "level0_file_num_compaction_trigger",
+		newDBDefault: 2,
+		setCF:        setInt((*grocksdb.Options).SetLevel0FileNumCompactionTrigger),
+		description:  "number of level 0 files which triggers compaction",
+	},
+	{
+		name: level0SlowdownWritesTriggerCFOptName, scope: cfScope, typ: intOption, mutable: true,
+		rocksdbName: "level0_slowdown_writes_trigger",
+		setCF:       setInt((*grocksdb.Options).SetLevel0SlowdownWritesTrigger),
+		description: "number of level 0 files which slows down writes",
+	},
+	{
+		name: compressionCFOptName, scope: cfScope, typ: stringOption, mutable: true, rocksdbName: "compression",
+		format:      formatCompressionType,
+		validate:    validateWith(parseCompressionType),
+		setCF:       setCompressionType((*grocksdb.Options).SetCompression),
+		description: "compression type: none, snappy, zlib, bz2, lz4, lz4hc, xpress or zstd",
+	},
+	{
+		name: bottommostCompressionCFOptName, scope: cfScope, typ: stringOption, mutable: true,
+		rocksdbName: "bottommost_compression",
+		format:      formatCompressionType,
+		validate:    validateWith(parseCompressionType),
+		setCF:       setCompressionType((*grocksdb.Options).SetBottommostCompression),
+		description: "compression type of the last level",
+	},
+	{
+		name: compressionPerLevelCFOptName, scope: cfScope, typ: stringSliceOption, rocksdbName: "compression_per_level",
+		format:      formatCompressionPerLevel,
+		validate:    validateCompressionPerLevel,
+		setCF:       setCompressionPerLevel,
+		description: "compression types of levels, takes precedence over compression",
+	},
+	{
+		name: compressionLevelCFOptName, scope: cfScope, typ: intOption,
+		description: "compression level of compression and bottommost_compression",
+	},
+	{
+		name: maxDictBytesCFOptName, scope: cfScope, typ: sizeOption,
+		description: "maximum size of compression dictionary, 0 disables dictionary compression",
+	},
+	{
+		name: zstdMaxTrainBytesCFOptName, scope: cfScope, typ: sizeOption,
+		description: "maximum size of data used to train ZSTD dictionary",
+	},
+	{
+		name: compactionStyleCFOptName, scope: cfScope, typ: stringOption, rocksdbName: "compaction_style",
+		format:      formatCompactionStyle,
+		validate:    validateWith(parseCompactionStyle),
+		setCF:       setCompactionStyle,
+		description: "compaction style: level, universal or fifo",
+	},
+	{
+		name: universalSizeRatioCFOptName, scope: cfScope, typ: intOption,
+		description: "size ratio of universal compaction in percents",
+	},
+	{
+		name: universalMinMergeWidthCFOptName, scope: cfScope, typ: intOption,
+		description: "minimum number of files compacted by universal compaction",
+	},
+	{
+		name: universalMaxMergeWidthCFOptName, scope: cfScope, typ: uintOption,
+		description: "maximum number of files compacted by universal compaction",
+	},
+	{
+		name: universalMaxSizeAmplificationPercentCFOptName, scope: cfScope, typ: intOption,
+		description: "size amplification which triggers full universal compaction",
+	},
+	{
+		name: fifoMaxTableFilesSizeCFOptName, scope: cfScope, typ: sizeOption,
+		description: "total size of SST files after which the oldest files are deleted by fifo compaction",
+	},
+	{
+		name: fifoAllowCompactionCFOptName, scope: cfScope, typ: boolOption,
+		description: "allows fifo compaction to merge small files",
+	},
+	{
+		name: ttlCFOptName, scope: cfScope, typ: durationOption, unit: time.Second, mutable: true, rocksdbName: "ttl",
+		description: "files older than ttl are compacted, with fifo compaction they are deleted, numbers are seconds",
+	},
+	{
+		name: enableBlobFilesCFOptName, scope: cfScope, typ: boolOption, mutable: true, rocksdbName: "enable_blob_files",
+		setCF:       setBool((*grocksdb.Options).EnableBlobFiles),
+		description: "stores large values in blob files",
+	},
+	{
+		name: minBlobSizeCFOptName, scope: cfScope, typ: sizeOption, mutable: true, rocksdbName: "min_blob_size",
+		setCF:       setUint64((*grocksdb.Options).SetMinBlobSize),
+		description: "minimum size of value stored in blob file",
+	},
+	{
+		name: blobFileSizeCFOptName, scope: cfScope, typ: sizeOption, mutable: true, rocksdbName: "blob_file_size",
+		setCF:       setUint64((*grocksdb.Options).SetBlobFileSize),
+		description: "target size of blob file",
+	},
+	{
+		name: blobCompressionTypeCFOptName, scope: cfScope, typ: stringOption, mutable: true,
+		rocksdbName: "blob_compression_type",
+		format:      formatCompressionType,
+		validate:    validateWith(parseCompressionType),
+		setCF:       setCompressionType((*grocksdb.Options).SetBlobCompressionType),
+		description: "compression type of blob files",
+	},
+	{
+		name: enableBlobGarbageCollectionCFOptName, scope: cfScope, typ: boolOption, mutable: true,
+		rocksdbName: "enable_blob_garbage_collection",
+		setCF:       setBool((*grocksdb.Options).EnableBlobGC),
+		description: "relocates valid blobs of the oldest blob files during compaction",
+	},
+	{
+		name: blobGarbageCollectionAgeCutoffCFOptName, scope: cfScope, typ: floatOption, mutable: true,
+		rocksdbName: "blob_garbage_collection_age_cutoff",
+		validate:    validateFraction,
+		setCF:       setFloat64((*grocksdb.Options).SetBlobGCAgeCutoff),
+		description: "fraction of the oldest blob files which are garbage collected",
+	},
+	{
+		name: prefixExtractorCFOptName, scope: cfScope, typ: stringOption, mutable: true, rocksdbName: "prefix_extractor",
+		format:      formatPrefixExtractor,
+		validate:    validateWith(parsePrefixExtractor),
+		description: "prefix extractor of prefix bloom filters: fixed:N or capped:N",
+	},
+	{
+		name: memtablePrefixBloomSizeRatioCFOptName, scope: cfScope, typ: floatOption, mutable: true,
+		rocksdbName: "memtable_prefix_bloom_size_ratio",
+		validate:    validateFraction,
+		setCF:       setFloat64((*grocksdb.Options).SetMemTablePrefixBloomSizeRatio),
+		description: "size of memtable bloom filter relative to write buffer size, 0 disables it",
+	},
+	{
+		name: memtableWholeKeyFilteringCFOptName, scope: cfScope, typ: boolOption, mutable: true,
+		rocksdbName: "memtable_whole_key_filtering",
+		setCF:       setBool((*grocksdb.Options).SetMemtableWholeKeyFiltering),
+		description: "adds whole keys to memtable bloom filter",
+	},
+
+	{
+		name: blockCacheSizeBBTOOptName, scope: bbtoScope, typ: sizeOption, defaultValue: defaultBlockCacheSize,
+		description: "size of block cache of the database",
+	},
+	{
+		name: blockCacheTypeOptName, scope: bbtoScope, typ: stringOption, defaultValue: lruBlockCacheType,
+		validate:    validateOneOf(lruBlockCacheType, hyperClockCacheType),
+		description: "type of block cache: lru or hyper_clock",
+	},
+	{
+		name: blockCacheNumShardBitsOptName, scope: bbtoScope, typ: intOption, defaultValue: defaultBlockCacheNumShardBits,
+		description: "number of bits used to choose block cache shard, -1 lets rocksdb choose it",
+	},
+	{
+		name: blockCacheEstimatedEntryChargeOptName, scope: bbtoScope, typ: sizeOption,
+		description: "estimated size of hyper_clock cache entry, block_size is used by default",
+	},
+	{
+		name: strictCapacityLimitOptName, scope: bbtoScope, typ: boolOption,
+		description: "fails inserts into lru block cache instead of exceeding its capacity",
+	},
+	{
+		name: highPriPoolRatioOptName, scope: bbtoScope, typ: floatOption,
+		description: "isn't supported",
+	},
+	{
+		name: compressedSecondaryCacheSizeOptName, scope: bbtoScope, typ: sizeOption,
+		description: "isn't supported",
+	},
+	{
+		name: bitsPerKeyBBTOOptName, scope: bbtoScope, typ: floatOption, defaultValue: defaultBitsPerKey,
+		description: "bits per key of bloom filter",
+	},
+	{
+		name: blockSizeBBTOOptName, scope: bbtoScope, typ: sizeOption, rocksdbName: "block_size",
+		setBBTO:     setBlockSize,
+		description: "size of data block",
+	},
+	{
+		name: cacheIndexAndFilterBlocksBBTOOptName, scope: bbtoScope, typ: boolOption,
+		rocksdbName: "cache_index_and_filter_blocks",
+		setBBTO:     setBool((*grocksdb.BlockBasedTableOptions).SetCacheIndexAndFilterBlocks),
+		description: "stores index and filter blocks in block cache",
+	},
+	{
+		name: pinL0FilterAndIndexBlocksInCacheBBTOOptName, scope: bbtoScope, typ: boolOption,
+		rocksdbName: "pin_l0_filter_and_index_blocks_in_cache",
+		setBBTO:     setBool((*grocksdb.BlockBasedTableOptions).SetPinL0FilterAndIndexBlocksInCache),
+		description: "keeps index and filter blocks of level 0 in block cache",
+	},
+	{
+		name: formatVersionBBTOOptName, scope: bbtoScope, typ: intOption, rocksdbName: "format_version",
+		setBBTO:     setInt((*grocksdb.BlockBasedTableOptions).SetFormatVersion),
+		description: "format version of SST files",
+	},
+	{
+		name: indexTypeBBTOOptName, scope: bbtoScope, typ: stringOption, rocksdbName: "index_type",
+		format:      formatIndexType,
+		validate:    validateWith(parseIndexType),
+		setBBTO:     setIndexType,
+		description: "binary_search, hash (requires prefix_extractor), two_level_index_search or binary_search_with_first_key",
+	},
+	{
+		name: partitionFiltersBBTOOptName, scope: bbtoScope, typ: boolOption, rocksdbName: "partition_filters",
+		setBBTO:     setBool((*grocksdb.BlockBasedTableOptions).SetPartitionFilters),
+		description: "partitions filters, requires index_type = two_level_index_search",
+	},
+	{
+		name: metadataBlockSizeBBTOOptName, scope: bbtoScope, typ: sizeOption, rocksdbName: "metadata_block_size",
+		setBBTO:     setUint64((*grocksdb.BlockBasedTableOptions).SetMetadataBlockSize),
+		description: "target size of index and filter partitions",
+	},
+	{
+		name: filterTypeBBTOOptName, scope: bbtoScope, typ: stringOption, defaultValue: bloomFilterType,
+		validate:    validateOneOf(bloomFilterType, ribbonFilterType),
+		description: "type of filter: bloom or ribbon",
+	},
+	{
+		name: ribbonBitsPerKeyBBTOOptName, scope: bbtoScope, typ: floatOption,
+		description: "bloom equivalent bits per key of ribbon filter, bits_per_key is used by default",
+	},
+	{
+		name: wholeKeyFilteringBBTOOptName, scope: bbtoScope, typ: boolOption, rocksdbName: "whole_key_filtering",
+		setBBTO:     setBool((*grocksdb.BlockBasedTableOptions).SetWholeKeyFiltering),
+		description: "adds whole keys to filter",
+	},
+	{
+		name: optimizeFiltersForMemoryBBTOOptName, scope: bbtoScope, typ: boolOption,
+		rocksdbName: "optimize_filters_for_memory",
+		setBBTO:     setBool((*grocksdb.BlockBasedTableOptions).SetOptimizeFiltersForMemory),
+		description: "fits filter sizes into memory allocations",
+	},
+
+	{
+		name: sharedBlockCacheSizeOptName, scope: opendbScope, typ: sizeOption, defaultValue: 0,
+		description: "size of block cache shared by all databases, 0 disables it and every database creates its own block cache of block_cache_size",
+	},
+	{
+		name: sharedWriteBufferManagerSizeOptName, scope: opendbScope, typ: sizeOption, defaultValue: 0,
+		description: "limit of memory used by memtables of all databases, 0 disables it and every database limits only its own memtables",
+	},
+	{
+		name: sharedWriteBufferManagerAllowStallOptName, scope: opendbScope, typ: boolOption, defaultValue: false,
+		description: "stalls writes when memtables exceed the limit",
+	},
+	{
+		name: sharedWriteBufferManagerCostToCacheOptName, scope: opendbScope, typ: boolOption, defaultValue: false,
+		description: "charges memory used by memtables to the shared block cache",
+	},
+	{
+		name: useSharedBlockCacheOptName, scope: opendbScope, typ: boolOption, defaultValue: true,
+		description: "uses shared block cache if it's enabled",
+	},
+	{
+		name: useSharedWriteBufferManagerOptName, scope: opendbScope, typ: boolOption, defaultValue: true,
+		description: "uses shared write buffer manager if it's enabled",
+	},
+
+	{
+		name: asyncIOReadOptName, scope: readScope, typ: boolOption,
+		setRead:     setBool((*grocksdb.ReadOptions).SetAsyncIO),
+		description: "prefetches data of iterators asynchronously",
+	},
+	{
+		name: prefixSameAsStartReadOptName, scope: readScope, typ: boolOption,
+		setRead:     setBool((*grocksdb.ReadOptions).SetPrefixSameAsStart),
+		description: "iterators return only keys with the same prefix as the seek key",
+	},
+	{
+		name: totalOrderSeekReadOptName, scope: readScope, typ: boolOption,
+		setRead:     setBool((*grocksdb.ReadOptions).SetTotalOrderSeek),
+		description: "iterators ignore prefix extractor",
+	},
+	{
+		name: readaheadSizeReadOptName, scope: readScope, typ: sizeOption,
+		setRead:     setUint64((*grocksdb.ReadOptions).SetReadaheadSize),
+		description: "size of readahead of iterators, 0 enables auto readahead",
+	},
+	{
+		name: disableWALWriteOptName, scope: writeScope, typ: boolOption,
+		setWrite:    setBool((*grocksdb.WriteOptions).DisableWAL),
+		description: "writes which aren't synced skip WAL",
+	},
+}
+
+// optionDefsByName maps option names normalized with normalizeOptName to option definitions
+var optionDefsByName = func() map[string]optionDef {
+	defs := make(map[string]optionDef, len(optionDefs))
+	for _, def := range optionDefs {
+		defs[normalizeOptName(def.name)] = def
+	}
+
+	return defs
+}()
+
+// parse converts value according to type of the option and validates it
+func (def optionDef) parse(value interface{}) (optionValue, error) {
+	var (
+		v   optionValue
+		err error
+	)
+	switch def.typ {
+	case boolOption:
+		v.boolVal, err = cast.ToBoolE(value)
+	case intOption:
+		v.intVal, err = cast.ToInt64E(value)
+	case uintOption:
+		v.uintVal, err = cast.ToUint64E(value)
+	case floatOption:
+		v.floatVal, err = cast.ToFloat64E(value)
+	case stringOption:
+		v.stringVal, err = cast.ToStringE(value)
+	case stringSliceOption:
+		v.sliceVal, err = cast.ToStringSliceE(value)
+	case sizeOption:
+		v.uintVal, err = parseSizeValue(value)
+	case durationOption:
+		v.duration, err = parseDurationValue(value, def.unit)
+	}
+	if err != nil {
+		return optionValue{}, err
+	}
+
+	if def.validate != nil {
+		if err := def.validate(v); err != nil {
+			return optionValue{}, err
+		}
+	}
+
+	return v, nil
+}
+
+// value returns value of the option in appOpts, ok is false if option isn't specified
+func (def optionDef) value(appOpts AppOptions) (value optionValue, ok bool, err error) {
+	rawValue := appOpts.Get(def.name)
+	if rawValue == nil {
+		return optionValue{}, false, nil
+	}
+
+	value, err = def.parse(rawValue)
+	if err != nil {
+		return optionValue{}, false, fmt.Errorf("invalid %v: %w", def.name, err)
+	}
+
+	return value, true, nil
+}
+
+// optValue returns value of option name in appOpts converted according to its definition, default value of the option
+// is returned if it isn't specified, ok is false if option isn't specified and has no default
+func optValue(appOpts AppOptions, name string) (value optionValue, ok bool, err error) {
+	def, found := optionDefsByName[normalizeOptName(name)]
+	if !found {
+		return optionValue{}, false, fmt.Errorf("unknown option %v", name)
+	}
+
+	value, ok, err = def.value(appOpts)
+	if err != nil || ok || def.defaultValue == nil {
+		return value, ok, err
+	}
+	value, err = def.parse(def.defaultValue)
+	if err != nil {
+		return optionValue{}, false, fmt.Errorf("invalid default of %v: %w", def.name, err)
+	}
+
+	return value, true, nil
+}
+
+// formatValue formats value in canonical form, sizes are formatted as number of bytes
+func (def optionDef) formatValue(value optionValue) string {
+	switch def.typ {
+	case boolOption:
+		return strconv.FormatBool(value.boolVal)
+	case intOption:
+		return strconv.FormatInt(value.intVal, 10)
+	case uintOption, sizeOption:
+		return strconv.FormatUint(value.uintVal, 10)
+	case floatOption:
+		return strconv.FormatFloat(value.floatVal, 'g', -1, 64)
+	case stringSliceOption:
+		return strings.Join(value.sliceVal, ",")
+	case durationOption:
+		return value.duration.String()
+	default:
+		return value.stringVal
+	}
+}
+
+// formatRocksDBValue formats value as value of rocksdb options string, durations are formatted in unit of the option
+func (def optionDef) formatRocksDBValue(value optionValue) (string, error) {
+	if def.format != nil {
+		return def.format(value)
+	}
+	if def.typ == durationOption {
+		return strconv.FormatInt(int64(value.duration/def.unit), 10), nil
+	}
+
+	return def.formatValue(value), nil
+}
+
+// applyOptionDefs applies options which are specified in appOpts to target with setters returned by setter,
+// for example optionDef.dbSetter, options without setter are applied by functions which apply them together
+// with related options
+func applyOptionDefs[T any](target T, appOpts AppOptions, setter func(optionDef) func(T, optionValue) error) error {
+	for _, def := range optionDefs {
+		set := setter(def)
+		if set == nil {
+			continue
+		}
+
+		value, ok, err := def.value(appOpts)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := set(target, value); err != nil {
+			return fmt.Errorf("invalid %v: %w", def.name, err)
+		}
+	}
+
+	return nil
+}
+
+// dbSetter, cfSetter, bbtoSetter, readSetter and writeSetter return setters of scopes, they're used with applyOptionDefs
+func (def optionDef) dbSetter() func(*grocksdb.Options, optionValue) error {
+	return def.setDB
+}
+
+func (def optionDef) cfSetter() func(*grocksdb.Options, optionValue) error {
+	return def.setCF
+}
+
+func (def optionDef) bbtoSetter() func(*grocksdb.BlockBasedTableOptions, optionValue) error {
+	return def.setBBTO
+}
+
+func (def optionDef) readSetter() func(*grocksdb.ReadOptions, optionValue) error {
+	return def.setRead
+}
+
+func (def optionDef) writeSetter() func(*grocksdb.WriteOptions, optionValue) error {
+	return def.setWrite
+}
+
+func setBool[T any](set func(T, bool)) func(T, optionValue) error {
+	return func(target T, value optionValue) error {
+		set(target, value.boolVal)
+		return nil
+	}
+}
+
+func setInt[T any](set func(T, int)) func(T, optionValue) error {
+	return func(target T, value optionValue) error {
+		set(target, int(value.intVal))
+		return nil
+	}
+}
+
+func setUint64[T any](set func(T, uint64)) func(T, optionValue) error {
+	return func(target T, value optionValue) error {
+		set(target, value.uintVal)
+		return nil
+	}
+}
+
+func setFloat64[T any](set func(T, float64)) func(T, optionValue) error {
+	return func(target T, value optionValue) error {
+		set(target, value.floatVal)
+		return nil
+	}
+}
+
+func setString[T any](set func(T, string)) func(T, optionValue) error {
+	return func(target T, value optionValue) error {
+		set(target, value.stringVal)
+		return nil
+	}
+}
+
+// setSeconds returns setter of duration option which is set as number of seconds
+func setSeconds[T any](set func(T, uint64)) func(T, optionValue) error {
+	return func(target T, value optionValue) error {
+		set(target, uint64(value.duration/time.Second))
+		return nil
+	}
+}
+
+// validateWith returns validator of string option which should be accepted by parse
+func validateWith[T any](parse func(string) (T, error)) func(optionValue) error {
+	return func(value optionValue) error {
+		_, err := parse(value.stringVal)
+		return err
+	}
+}
+
+// validateOneOf returns validator of string option which should be one of values
+func validateOneOf(values ...string) func(optionValue) error {
+	return func(value optionValue) error {
+		if !slices.Contains(values, value.stringVal) {
+			return fmt.Errorf("unknown value: %v, should be one of: %v", value.stringVal, strings.Join(values, ", "))
+		}
+		return nil
+	}
+}
+
+// validateFraction checks that float option is in range [0, 1]
+func validateFraction(value optionValue) error {
+	if value.floatVal < 0 || value.floatVal > 1 {
+		return fmt.Errorf("should be in range [0, 1], got %v", value.floatVal)
+	}
+	return nil
+}
+
+// normalizeOptName makes dash and underscore spellings of option name equal
+func normalizeOptName(name string) string {
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+// optNameSpellings returns key and key with alternative spelling of option name, which is the last part of the key,
+// for example cf.state-sync.max-open-files and cf.state-sync.max_open_files
+func optNameSpellings(key string) []string {
+	prefix, name := "", key
+	if idx := strings.LastIndex(key, "."); idx != -1 {
+		prefix, name = key[:idx+1], key[idx+1:]
+	}
+
+	var alternative string
+	switch {
+	case strings.Contains(name, "-"):
+		alternative = strings.ReplaceAll(name, "-", "_")
+	case strings.Contains(name, "_"):
+		alternative = strings.ReplaceAll(name, "_", "-")
+	default:
+		return []string{key}
+	}
+
+	return []string{key, prefix + alternative}
+}
+
+// parseSizeValue parses number of bytes, value is either non-negative integer or string with number and unit,
+// for example 16k, 128MiB or 1GB, units are case-insensitive
+func parseSizeValue(value interface{}) (uint64, error) {
+	str, ok := value.(string)
+	if !ok {
+		return cast.ToUint64E(value)
+	}
+
+	str = strings.TrimSpace(str)
+	numEnd := strings.IndexFunc(str, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if numEnd == -1 {
+		numEnd = len(str)
+	}
+
+	unit, ok := optSizeUnits[strings.ToLower(strings.TrimSpace(str[numEnd:]))]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit: %v, should be one of: k, m, g, t, KB, MB, GB, TB, KiB, MiB, GiB, TiB", str[numEnd:])
+	}
+
+	if num, err := strconv.ParseUint(str[:numEnd], 10, 64); err == nil {
+		if num > math.MaxUint64/unit {
+			return 0, fmt.Errorf("size is too large: %v", str)
+		}
+		return num * unit, nil
+	}
+
+	num, err := strconv.ParseFloat(str[:numEnd], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size: %v", str)
+	}
+	size := num * float64(unit)
+	if size >= math.MaxUint64 {
+		return 0, fmt.Errorf("size is too large: %v", str)
+	}
+
+	return uint64(size), nil
+}
+
+// parseDurationValue parses non-negative duration, value is either number in unit or string accepted by time.ParseDuration,
+// for example 15s or 500ms
+func parseDurationValue(value interface{}, unit time.Duration) (time.Duration, error) {
+	var duration time.Duration
+	if str, ok := value.(string); ok && strings.TrimSpace(str) != "" && !isNumber(str) {
+		var err error
+		duration, err = time.ParseDuration(strings.TrimSpace(str))
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		num, err := cast.ToFloat64E(value)
+		if err != nil {
+			return 0, err
+		}
+		duration = time.Duration(num * float64(unit))
+	}
+
+	if duration < 0 {
+		return 0, fmt.Errorf("duration should be non-negative, got %v", duration)
+	}
+
+	return duration, nil
+}
+
+// isNumber returns true if str is a number without unit
+func isNumber(str string) bool {
+	_, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+	return err == nil
+}
+
+// sizeOptValue returns value of size option, ok is false if option isn't specified in appOpts
+func sizeOptValue(appOpts AppOptions, name string) (value uint64, ok bool, err error) {
+	def, found := optionDefsByName[normalizeOptName(name)]
+	if !found || def.typ != sizeOption {
+		return 0, false, fmt.Errorf("%v isn't a size option", name)
+	}
+
+	v, ok, err := def.value(appOpts)
+	return v.uintVal, ok, err
+}
+
+// durationOptValue returns value of duration option, numbers are interpreted in unit of the option,
+// ok is false if option isn't specified in appOpts
+func durationOptValue(appOpts AppOptions, name string) (value time.Duration, ok bool, err error) {
+	def, found := optionDefsByName[normalizeOptName(name)]
+	if !found || def.typ != durationOption {
+		return 0, false, fmt.Errorf("%v isn't a duration option", name)
+	}
+
+	v, ok, err := def.value(appOpts)
+	return v.duration, ok, err
+}
@@ -0,0 +1,222 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSizeValue(t *testing.T) {
+	for _, tc := range []struct {
+		value    interface{}
+		expected uint64
+		success  bool
+	}{
+		{value: 134217728, expected: 134217728, success: true},
+		{value: uint64(1 << 40), expected: 1 << 40, success: true},
+		{value: "4096", expected: 4096, success: true},
+		{value: "16k", expected: 16 << 10, success: true},
+		{value: "16K", expected: 16 << 10, success: true},
+		{value: "128MiB", expected: 128 << 20, success: true},
+		{value: "128 MB", expected: 128 << 20, success: true},
+		{value: "1GB", expected: 1 << 30, success: true},
+		{value: "1.5g", expected: 3 << 29, success: true},
+		{value: "2TiB", expected: 2 << 40, success: true},
+		{value: "512B", expected: 512, success: true},
+		{value: -1, success: false},
+		{value: "-1k", success: false},
+		{value: "16 bytes", success: false},
+		{value: "MiB", success: false},
+		{value: "20000000TiB", success: false},
+	} {
+		size, err := parseSizeValue(tc.value)
+		if !tc.success {
+			require.Error(t, err, tc.value)
+			continue
+		}
+
+		require.NoError(t, err, tc.value)
+		require.Equal(t, tc.expected, size, tc.value)
+	}
+}
+
+func TestParseDurationValue(t *testing.T) {
+	for _, tc := range []struct {
+		value    interface{}
+		unit     time.Duration
+		expected time.Duration
+		success  bool
+	}{
+		{value: 15, unit: time.Second, expected: 15 * time.Second, success: true},
+		{value: 0.5, unit: time.Second, expected: 500 * time.Millisecond, success: true},
+		{value: "15", unit: time.Second, expected: 15 * time.Second, success: true},
+		{value: "15s", unit: time.Second, expected: 15 * time.Second, success: true},
+		{value: "24h", unit: time.Second, expected: 24 * time.Hour, success: true},
+		{value: 100000, unit: time.Microsecond, expected: 100 * time.Millisecond, success: true},
+		{value: "100ms", unit: time.Microsecond, expected: 100 * time.Millisecond, success: true},
+		{value: "-1s", unit: time.Second, success: false},
+		{value: -1, unit: time.Second, success: false},
+		{value: "15 seconds", unit: time.Second, success: false},
+	} {
+		duration, err := parseDurationValue(tc.value, tc.unit)
+		if !tc.success {
+			require.Error(t, err, tc.value)
+			continue
+		}
+
+		require.NoError(t, err, tc.value)
+		require.Equal(t, tc.expected, duration, tc.value)
+	}
+}
+
+func TestOptValues(t *testing.T) {
+	mockAppOpts := newMockAppOptions(map[string]interface{}{
+		writeBufferSizeCFOptName:           "64MiB",
+		blockSizeBBTOOptName:               "bad",
+		ttlCFOptName:                       "1h",
+		rateLimiterRefillPeriodUsDBOptName: 50000,
+		walTTLSecondsDBOptName:             "1 hour",
+	})
+
+	size, ok, err := sizeOptValue(mockAppOpts, writeBufferSizeCFOptName)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint64(64<<20), size)
+
+	_, ok, err = sizeOptValue(mockAppOpts, targetFileSizeBaseCFOptName)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, _, err = sizeOptValue(mockAppOpts, blockSizeBBTOOptName)
+	require.ErrorContains(t, err, blockSizeBBTOOptName)
+
+	duration, ok, err := durationOptValue(mockAppOpts, ttlCFOptName)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, time.Hour, duration)
+
+	// numbers are interpreted in unit of the option
+	duration, ok, err = durationOptValue(mockAppOpts, rateLimiterRefillPeriodUsDBOptName)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 50*time.Millisecond, duration)
+
+	_, _, err = durationOptValue(mockAppOpts, walTTLSecondsDBOptName)
+	require.ErrorContains(t, err, walTTLSecondsDBOptName)
+
+	_, _, err = durationOptValue(mockAppOpts, writeBufferSizeCFOptName)
+	require.Error(t, err)
+
+	// default value is returned if option isn't specified
+	value, ok, err := optValue(mockAppOpts, rateLimiterFairnessDBOptName)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int64(defaultRateLimiterFairness), value.intVal)
+
+	_, ok, err = optValue(mockAppOpts, compressionLevelCFOptName)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// invalid value isn't converted to zero value
+	_, _, err = optValue(newMockAppOptions(map[string]interface{}{
+		rateLimiterFairnessDBOptName: "abc",
+	}), rateLimiterFairnessDBOptName)
+	require.ErrorContains(t, err, rateLimiterFairnessDBOptName)
+}
+
+func TestOptionDefs(t *testing.T) {
+	names := make(map[string]bool, len(optionDefs))
+	for _, def := range optionDefs {
+		require.False(t, names[normalizeOptName(def.name)], "duplicated option %v", def.name)
+		names[normalizeOptName(def.name)] = true
+
+		require.NotEmpty(t, def.description, def.name)
+		if def.typ == durationOption {
+			require.NotZero(t, def.unit, def.name)
+		}
+		if def.mutable {
+			require.NotEmpty(t, def.rocksdbName, def.name)
+			require.Equal(t, cfScope, def.scope, def.name)
+		}
+		if def.defaultValue != nil {
+			_, err := def.parse(def.defaultValue)
+			require.NoError(t, err, def.name)
+		}
+		if def.newDBDefault != nil {
+			require.Nil(t, def.defaultValue, def.name)
+			require.True(t, def.setDB != nil || def.setCF != nil, def.name)
+			_, err := def.parse(def.newDBDefault)
+			require.NoError(t, err, def.name)
+		}
+
+		// only setter of the scope can be set, options of read and write scopes are always applied by their setters
+		setters := map[optionScope]bool{
+			dbScope:    def.setDB != nil,
+			cfScope:    def.setCF != nil,
+			bbtoScope:  def.setBBTO != nil,
+			readScope:  def.setRead != nil,
+			writeScope: def.setWrite != nil,
+		}
+		for scope, hasSetter := range setters {
+			if scope != def.scope {
+				require.False(t, hasSetter, "%v has setter of %v scope", def.name, scope)
+			}
+		}
+		if def.scope == readScope || def.scope == writeScope {
+			require.True(t, setters[def.scope], def.name)
+		}
+	}
+}
+
+func TestOptionDefParse(t *testing.T) {
+	def := optionDefsByName[normalizeOptName(compressionCFOptName)]
+	value, err := def.parse("LZ4")
+	require.NoError(t, err)
+	require.Equal(t, "LZ4", def.formatValue(value))
+	rocksdbValue, err := def.formatRocksDBValue(value)
+	require.NoError(t, err)
+	require.Equal(t, "kLZ4Compression", rocksdbValue)
+
+	_, err = def.parse("gzip")
+	require.Error(t, err)
+
+	def = optionDefsByName[normalizeOptName(writeBufferSizeCFOptName)]
+	value, err = def.parse("64MiB")
+	require.NoError(t, err)
+	require.Equal(t, "67108864", def.formatValue(value))
+
+	def = optionDefsByName[normalizeOptName(ttlCFOptName)]
+	value, err = def.parse(3600)
+	require.NoError(t, err)
+	require.Equal(t, "1h0m0s", def.formatValue(value))
+	rocksdbValue, err = def.formatRocksDBValue(value)
+	require.NoError(t, err)
+	require.Equal(t, "3600", rocksdbValue)
+}
+
+func TestFormatRocksDBValue(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		value    interface{}
+		expected string
+	}{
+		{name: walRecoveryModeDBOptName, value: "absolute_consistency", expected: "kAbsoluteConsistency"},
+		{name: walCompressionDBOptName, value: "zstd", expected: "kZSTD"},
+		{name: compressionPerLevelCFOptName, value: []string{"none", "lz4", "zstd"}, expected: "kNoCompression:kLZ4Compression:kZSTD"},
+		{name: compactionStyleCFOptName, value: "universal", expected: "kCompactionStyleUniversal"},
+		{name: indexTypeBBTOOptName, value: "two_level_index_search", expected: "kTwoLevelIndexSearch"},
+		{name: walTTLSecondsDBOptName, value: "1h", expected: "3600"},
+		{name: maxBytesForLevelMultiplierCFOptName, value: 8, expected: "8"},
+	} {
+		def := optionDefsByName[normalizeOptName(tc.name)]
+		value, err := def.parse(tc.value)
+		require.NoError(t, err, tc.name)
+		rocksdbValue, err := def.formatRocksDBValue(value)
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expected, rocksdbValue, tc.name)
+	}
+}
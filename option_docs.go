@@ -0,0 +1,68 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// optionsReferenceStart and optionsReferenceEnd surround options reference generated from optionDefs in README
+	optionsReferenceStart = "<!-- options reference start -->"
+	optionsReferenceEnd   = "<!-- options reference end -->"
+)
+
+// optionsReference returns markdown table which describes all options accepted in appOpts
+func optionsReference() (string, error) {
+	var b strings.Builder
+	b.WriteString("| Option | Scope | Type | Default | Runtime | Description |\n")
+	b.WriteString("|--------|-------|------|---------|---------|-------------|\n")
+	for _, def := range optionDefs {
+		var defaultValue string
+		if def.defaultValue != nil {
+			value, err := def.parse(def.defaultValue)
+			if err != nil {
+				return "", fmt.Errorf("invalid default of %v: %w", def.name, err)
+			}
+			defaultValue = fmt.Sprintf("`%v`", def.formatDocValue(value))
+		}
+		switch {
+		case def.newDBDefaultDoc != "":
+			defaultValue = def.newDBDefaultDoc + " (new databases)"
+		case def.newDBDefault != nil:
+			value, err := def.parse(def.newDBDefault)
+			if err != nil {
+				return "", fmt.Errorf("invalid new database default of %v: %w", def.name, err)
+			}
+			defaultValue = fmt.Sprintf("`%v` (new databases)", def.formatDocValue(value))
+		}
+
+		var runtime string
+		if def.mutable {
+			runtime = "yes"
+		}
+
+		fmt.Fprintf(&b, "| `%v` | %v | %v | %v | %v | %v |\n", def.name, def.scope, def.typ, defaultValue, runtime, def.description)
+	}
+
+	return b.String(), nil
+}
+
+// formatDocValue formats value for documentation, sizes are formatted with the largest unit which represents them exactly
+func (def optionDef) formatDocValue(value optionValue) string {
+	if def.typ != sizeOption || value.uintVal == 0 {
+		return def.formatValue(value)
+	}
+
+	for _, unit := range []string{"TiB", "GiB", "MiB", "KiB"} {
+		unitSize := optSizeUnits[strings.ToLower(unit)]
+		if value.uintVal%unitSize == 0 {
+			return strconv.FormatUint(value.uintVal/unitSize, 10) + unit
+		}
+	}
+
+	return def.formatValue(value)
+}
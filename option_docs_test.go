@@ -0,0 +1,53 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var updateREADME = flag.Bool("update-readme", false, "update options reference in README.md")
+
+func TestOptionsReference(t *testing.T) {
+	reference, err := optionsReference()
+	require.NoError(t, err)
+
+	readme, err := os.ReadFile("README.md")
+	require.NoError(t, err)
+	before, rest, ok := strings.Cut(string(readme), optionsReferenceStart+"\n")
+	require.True(t, ok, "README doesn't contain options reference start marker")
+	readmeReference, after, ok := strings.Cut(rest, optionsReferenceEnd)
+	require.True(t, ok, "README doesn't contain options reference end marker")
+
+	if *updateREADME {
+		updated := before + optionsReferenceStart + "\n" + reference + optionsReferenceEnd + after
+		require.NoError(t, os.WriteFile("README.md", []byte(updated), 0644))
+		return
+	}
+
+	require.Equal(t, reference, readmeReference, "options reference is outdated, run make docs")
+}
+
+func TestFormatDocValue(t *testing.T) {
+	def := optionDefsByName[normalizeOptName(blockCacheSizeBBTOOptName)]
+	for _, tc := range []struct {
+		value    interface{}
+		expected string
+	}{
+		{value: 0, expected: "0"},
+		{value: 1 << 30, expected: "1GiB"},
+		{value: "512MiB", expected: "512MiB"},
+		{value: 1536, expected: "1536"},
+		{value: 3 << 10, expected: "3KiB"},
+	} {
+		value, err := def.parse(tc.value)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, def.formatDocValue(value))
+	}
+}
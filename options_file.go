@@ -0,0 +1,160 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// optionsFilePrefix is a prefix of rocksdb OPTIONS files, for example OPTIONS-000007,
+	// rocksdb writes new OPTIONS file every time options are changed
+	optionsFilePrefix = "OPTIONS-"
+	// dbOptionsSection is a section of OPTIONS file which contains database options
+	dbOptionsSection = "DBOptions"
+	// cfOptionsSection is a section of OPTIONS file which contains options of column family, for example:
+	// [CFOptions "default"]
+	cfOptionsSection = "CFOptions"
+	// bbtoOptionsSection is a section of OPTIONS file which contains block based table options of column family,
+	// for example: [TableOptions/BlockBasedTable "default"]
+	bbtoOptionsSection = "TableOptions/BlockBasedTable"
+)
+
+// errOptionsFileNotFound is returned if database has no OPTIONS file, it means database isn't created yet
+var errOptionsFileNotFound = errors.New("options file not found")
+
+// optionsFile contains options of rocksdb OPTIONS file grouped by section,
+// for example DBOptions or CFOptions "default"
+type optionsFile map[string]map[string]string
+
+// dbOptions returns database options
+func (f optionsFile) dbOptions() map[string]string {
+	return f[dbOptionsSection]
+}
+
+// cfOptions returns options of column family with provided name
+func (f optionsFile) cfOptions(cfName string) map[string]string {
+	return f[fmt.Sprintf("%v %q", cfOptionsSection, cfName)]
+}
+
+// bbtoOptions returns block based table options of column family with provided name
+func (f optionsFile) bbtoOptions(cfName string) map[string]string {
+	return f[fmt.Sprintf("%v %q", bbtoOptionsSection, cfName)]
+}
+
+// cfNames returns names of column families in sorted order, default column family goes first
+func (f optionsFile) cfNames() []string {
+	var names []string
+	for section := range f {
+		name, ok := strings.CutPrefix(section, cfOptionsSection+" ")
+		if !ok {
+			continue
+		}
+		if name, err := strconv.Unquote(name); err == nil && name != DefaultColumnFamilyName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return append([]string{DefaultColumnFamilyName}, names...)
+}
+
+// latestOptionsFilePath returns path of OPTIONS file with the largest number in dbPath
+func latestOptionsFilePath(dbPath string) (string, error) {
+	entries, err := os.ReadDir(dbPath)
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		latestName   string
+		latestNumber uint64
+	)
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasPrefix(entry.Name(), optionsFilePrefix) {
+			continue
+		}
+
+		// temporary files like OPTIONS-000007.dbtmp are skipped
+		number, err := strconv.ParseUint(strings.TrimPrefix(entry.Name(), optionsFilePrefix), 10, 64)
+		if err != nil {
+			continue
+		}
+		if latestName == "" || number > latestNumber {
+			latestName, latestNumber = entry.Name(), number
+		}
+	}
+
+	if latestName == "" {
+		return "", fmt.Errorf("%w in %v", errOptionsFileNotFound, dbPath)
+	}
+
+	return filepath.Join(dbPath, latestName), nil
+}
+
+// loadLatestOptionsFile loads and parses the latest OPTIONS file in dbPath
+func loadLatestOptionsFile(dbPath string) (optionsFile, error) {
+	path, err := latestOptionsFilePath(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseOptionsFile(f)
+}
+
+// parseOptionsFile parses rocksdb OPTIONS file in INI format
+func parseOptionsFile(r io.Reader) (optionsFile, error) {
+	file := make(optionsFile)
+	var section map[string]string
+
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("invalid section at line %v: %v", lineNum, line)
+			}
+			name := strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+			section = make(map[string]string)
+			file[name] = section
+			continue
+		}
+
+		if section == nil {
+			return nil, fmt.Errorf("option outside of section at line %v: %v", lineNum, line)
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid option at line %v: %v", lineNum, line)
+		}
+		section[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(file) == 0 {
+		return nil, errors.New("options file is empty")
+	}
+
+	return file, nil
+}
@@ -0,0 +1,107 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOptionsFile(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		content  string
+		expected optionsFile
+		success  bool
+	}{
+		{
+			desc: "db and column family options",
+			content: `# This is a RocksDB option file.
+[Version]
+  rocksdb_version=8.1.1
+
+[DBOptions]
+  max_open_files=4096
+  wal_dir=
+
+[CFOptions "default"]
+  write_buffer_size=67108864
+  prefix_extractor=rocksdb.CappedPrefix.2
+
+[TableOptions/BlockBasedTable "default"]
+  block_size=4096
+
+[CFOptions "nodes"]
+  write_buffer_size=134217728
+`,
+			expected: optionsFile{
+				"Version":                                {"rocksdb_version": "8.1.1"},
+				"DBOptions":                              {"max_open_files": "4096", "wal_dir": ""},
+				`CFOptions "default"`:                    {"write_buffer_size": "67108864", "prefix_extractor": "rocksdb.CappedPrefix.2"},
+				`TableOptions/BlockBasedTable "default"`: {"block_size": "4096"},
+				`CFOptions "nodes"`:                      {"write_buffer_size": "134217728"},
+			},
+			success: true,
+		},
+		{
+			desc:    "option outside of section",
+			content: "max_open_files=4096\n",
+			success: false,
+		},
+		{
+			desc:    "invalid option",
+			content: "[DBOptions]\n  max_open_files\n",
+			success: false,
+		},
+		{
+			desc:    "invalid section",
+			content: "[DBOptions\n",
+			success: false,
+		},
+		{
+			desc:    "empty file",
+			content: "# comment\n",
+			success: false,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			file, err := parseOptionsFile(strings.NewReader(tc.content))
+			if !tc.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, file)
+			require.Equal(t, "4096", file.dbOptions()["max_open_files"])
+			require.Equal(t, "67108864", file.cfOptions(DefaultColumnFamilyName)["write_buffer_size"])
+			require.Nil(t, file.cfOptions("missing"))
+			require.Equal(t, "4096", file.bbtoOptions(DefaultColumnFamilyName)["block_size"])
+			require.Equal(t, []string{DefaultColumnFamilyName, "nodes"}, file.cfNames())
+		})
+	}
+}
+
+func TestLatestOptionsFilePath(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	_, err = latestOptionsFilePath(dir)
+	require.ErrorIs(t, err, errOptionsFileNotFound)
+
+	for _, name := range []string{"OPTIONS-000007", "OPTIONS-000012", "OPTIONS-000015.dbtmp", "CURRENT"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+	}
+	path, err := latestOptionsFilePath(dir)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "OPTIONS-000012"), path)
+}
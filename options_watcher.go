@@ -0,0 +1,92 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// OptionsLoader loads appOpts from config file, for example app.toml can be loaded with viper
+type OptionsLoader func(path string) (AppOptions, error)
+
+// WatchOptionsFile checks modification time and size of config file at path every interval,
+// when file is changed it's loaded with load and its mutable options are applied with SetOptions.
+// Errors of loading and applying options are logged, so invalid config doesn't stop watching.
+// Only one file can be watched, watching is stopped when database is closed.
+func (db *RocksDB) WatchOptionsFile(path string, interval time.Duration, load OptionsLoader) error {
+	if interval <= 0 {
+		return fmt.Errorf("options file watch interval should be positive, got %v", interval)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("can't watch options file: %w", err)
+	}
+
+	db.optsMtx.Lock()
+	defer db.optsMtx.Unlock()
+	if db.closed {
+		return errDBClosed
+	}
+	if db.watchingOptions {
+		return errors.New("options file is already watched")
+	}
+	db.watchingOptions = true
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		modTime, size := info.ModTime(), info.Size()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				db.logger.Error("can't stat options file", "db", db.name, "path", path, "err", err)
+				continue
+			}
+			if info.ModTime().Equal(modTime) && info.Size() == size {
+				continue
+			}
+			modTime, size = info.ModTime(), info.Size()
+
+			db.reloadOptionsFile(path, load)
+		}
+	}()
+
+	db.stopOptionsWatcher = func() {
+		close(done)
+		<-stopped
+	}
+
+	return nil
+}
+
+// reloadOptionsFile loads options file and applies its mutable options, errors are logged
+func (db *RocksDB) reloadOptionsFile(path string, load OptionsLoader) {
+	appOpts, err := load(path)
+	if err != nil {
+		db.logger.Error("can't load options file", "db", db.name, "path", path, "err", err)
+		return
+	}
+
+	changes, err := db.SetOptions(appOpts)
+	if err != nil {
+		db.logger.Error("can't apply options file", "db", db.name, "path", path, "err", err)
+		return
+	}
+	db.logger.Info("options file applied", "db", db.name, "path", path, "changes", len(changes))
+}
@@ -0,0 +1,102 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// loadTestOptionsFile loads options file which contains key=value pairs, one per line
+func loadTestOptionsFile(path string) (AppOptions, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := make(map[string]interface{})
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		key, value, _ := strings.Cut(line, "=")
+		opts[key] = value
+	}
+
+	return newMockAppOptions(opts), nil
+}
+
+func TestWatchOptionsFile(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	configPath := filepath.Join(dir, "app.toml")
+	writeConfig := func(content string) {
+		require.NoError(t, os.WriteFile(configPath, []byte(content), 0o644))
+	}
+	writeConfig("rocksdb.level0_slowdown_writes_trigger=20\n")
+
+	appOpts, err := loadTestOptionsFile(configPath)
+	require.NoError(t, err)
+	db, err := openRocksdb(dir, defaultDBName, newRocksDBOptions(appOpts, defaultDBName), MetricsConfig{})
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	require.Error(t, db.WatchOptionsFile(filepath.Join(dir, "missing.toml"), time.Millisecond, loadTestOptionsFile))
+	require.Error(t, db.WatchOptionsFile(configPath, 0, loadTestOptionsFile))
+	require.NoError(t, db.WatchOptionsFile(configPath, time.Millisecond, loadTestOptionsFile))
+	require.Error(t, db.WatchOptionsFile(configPath, time.Millisecond, loadTestOptionsFile))
+
+	level0SlowdownWritesTrigger := func() string {
+		file, err := loadLatestOptionsFile(db.path)
+		require.NoError(t, err)
+		return file.cfOptions(DefaultColumnFamilyName)["level0_slowdown_writes_trigger"]
+	}
+	require.Equal(t, "20", level0SlowdownWritesTrigger())
+
+	// immutable option is rejected, so mutable option changed together with it isn't applied
+	writeConfig("rocksdb.level0_slowdown_writes_trigger=25\nrocksdb.block_size=8192\n")
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, "20", level0SlowdownWritesTrigger())
+
+	writeConfig("rocksdb.level0_slowdown_writes_trigger=30\n")
+	require.Eventually(t, func() bool {
+		return level0SlowdownWritesTrigger() == "30"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCloseStopsOptionsWatcher(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	configPath := filepath.Join(dir, "app.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte("rocksdb.level0_slowdown_writes_trigger=20\n"), 0o644))
+
+	db, err := openRocksdb(dir, defaultDBName, noAppOptions{}, MetricsConfig{})
+	require.NoError(t, err)
+
+	// options file is watched concurrently with Close
+	watched := make(chan error)
+	go func() {
+		watched <- db.WatchOptionsFile(configPath, time.Millisecond, loadTestOptionsFile)
+	}()
+	require.NoError(t, db.Close())
+	<-watched
+
+	// database is closed only once, options file isn't watched after database is closed
+	require.NoError(t, db.Close())
+	require.Error(t, db.WatchOptionsFile(configPath, time.Millisecond, loadTestOptionsFile))
+}
@@ -0,0 +1,74 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/linxGnu/grocksdb"
+)
+
+const (
+	// prefixExtractorCFOptName is a prefix extractor used by prefix bloom filters: fixed:N or capped:N,
+	// fixed:N uses first N bytes of keys which are at least N bytes long, capped:N uses first N bytes of all keys
+	prefixExtractorCFOptName = "prefix_extractor"
+	fixedPrefixExtractor     = "fixed"
+	cappedPrefixExtractor    = "capped"
+	// memtablePrefixBloomSizeRatioCFOptName is a size of memtable bloom filter relative to write_buffer_size,
+	// 0 disables memtable bloom filter
+	memtablePrefixBloomSizeRatioCFOptName = "memtable_prefix_bloom_size_ratio"
+	// memtableWholeKeyFilteringCFOptName adds whole keys to memtable bloom filter
+	memtableWholeKeyFilteringCFOptName = "memtable_whole_key_filtering"
+
+	// prefixSameAsStartReadOptName makes iterators return only keys with the same prefix as the seek key
+	prefixSameAsStartReadOptName = "prefix_same_as_start"
+	// totalOrderSeekReadOptName makes iterators ignore prefix extractor and prefix bloom filters
+	totalOrderSeekReadOptName = "total_order_seek"
+)
+
+// parsePrefixExtractor parses prefix extractor in fixed:N or capped:N format,
+// it returns prefix extractor in format accepted by rocksdb options string
+func parsePrefixExtractor(value string) (string, error) {
+	kind, lenStr, ok := strings.Cut(value, ":")
+	if !ok || (kind != fixedPrefixExtractor && kind != cappedPrefixExtractor) {
+		return "", fmt.Errorf(
+			"unknown prefix extractor: %v, should be %v:N or %v:N",
+			value, fixedPrefixExtractor, cappedPrefixExtractor,
+		)
+	}
+
+	prefixLen, err := strconv.Atoi(lenStr)
+	if err != nil || prefixLen <= 0 {
+		return "", fmt.Errorf("invalid prefix length: %v, should be positive integer", lenStr)
+	}
+
+	return fmt.Sprintf("%v:%d", kind, prefixLen), nil
+}
+
+// overridePrefixExtractorOpts sets prefix extractor of cfOpts if it's specified in appOpts,
+// memtable bloom options are applied by their option definitions
+// it returns new options if prefix_extractor is specified, because capped prefix extractor isn't exposed by rocksdb C API,
+// so prefix extractor is set by creating options from string, cfOpts are destroyed in such case
+func overridePrefixExtractorOpts(cfOpts *grocksdb.Options, appOpts AppOptions) (*grocksdb.Options, error) {
+	prefixExtractor, ok, err := optValue(appOpts, prefixExtractorCFOptName)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		prefixExtractorVal, err := parsePrefixExtractor(prefixExtractor.stringVal)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %v: %w", prefixExtractorCFOptName, err)
+		}
+
+		newCFOpts, err := optionsFromString(cfOpts, fmt.Sprintf("%v=%v", prefixExtractorCFOptName, prefixExtractorVal))
+		if err != nil {
+			return nil, fmt.Errorf("can't set %v: %w", prefixExtractorCFOptName, err)
+		}
+		cfOpts = newCFOpts
+	}
+
+	return cfOpts, nil
+}
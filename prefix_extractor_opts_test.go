@@ -0,0 +1,132 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePrefixExtractor(t *testing.T) {
+	for _, tc := range []struct {
+		value           string
+		prefixExtractor string
+		success         bool
+	}{
+		{value: "fixed:2", prefixExtractor: "fixed:2", success: true},
+		{value: "capped:3", prefixExtractor: "capped:3", success: true},
+		{value: "fixed:02", prefixExtractor: "fixed:2", success: true},
+		{value: "fixed", success: false},
+		{value: "fixed:", success: false},
+		{value: "fixed:0", success: false},
+		{value: "capped:-1", success: false},
+		{value: "noop:2", success: false},
+		{value: "", success: false},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			prefixExtractor, err := parsePrefixExtractor(tc.value)
+			if !tc.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.prefixExtractor, prefixExtractor)
+		})
+	}
+}
+
+func TestOverridePrefixExtractorOpts(t *testing.T) {
+	defaultOpts := newDefaultOptions()
+
+	for _, tc := range []struct {
+		desc                         string
+		mockAppOptions               *mockAppOptions
+		memtablePrefixBloomSizeRatio float64
+		success                      bool
+	}{
+		{
+			desc:                         "override nothing",
+			mockAppOptions:               newMockAppOptions(map[string]interface{}{}),
+			memtablePrefixBloomSizeRatio: defaultOpts.GetMemTablePrefixBloomSizeRatio(),
+			success:                      true,
+		},
+		{
+			desc: "fixed prefix extractor",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				prefixExtractorCFOptName:              "fixed:2",
+				memtablePrefixBloomSizeRatioCFOptName: 0.1,
+			}),
+			memtablePrefixBloomSizeRatio: 0.1,
+			success:                      true,
+		},
+		{
+			desc: "capped prefix extractor",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				prefixExtractorCFOptName:              "capped:3",
+				memtablePrefixBloomSizeRatioCFOptName: 0.05,
+				memtableWholeKeyFilteringCFOptName:    true,
+			}),
+			memtablePrefixBloomSizeRatio: 0.05,
+			success:                      true,
+		},
+		{
+			desc: "invalid prefix extractor",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				prefixExtractorCFOptName: "fixed:n",
+			}),
+			success: false,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			cfOpts, err := overrideCFOpts(newDefaultOptions(), tc.mockAppOptions)
+			if !tc.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.memtablePrefixBloomSizeRatio, cfOpts.GetMemTablePrefixBloomSizeRatio())
+		})
+	}
+}
+
+func TestOpenRocksdbWithPrefixExtractor(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	mockAppOpts := newMockAppOptions(map[string]interface{}{
+		prefixExtractorCFOptName:              "capped:2",
+		memtablePrefixBloomSizeRatioCFOptName: 0.1,
+		prefixSameAsStartReadOptName:          true,
+	})
+	db, err := openRocksdb(dir, defaultDBName, mockAppOpts, MetricsConfig{})
+	require.NoError(t, err)
+
+	for _, key := range []string{"n/1", "n/2", "o/1", "o/2"} {
+		require.NoError(t, db.Set([]byte(key), []byte(key)))
+	}
+
+	// iterator with prefix_same_as_start stops at the end of the prefix of start key
+	it, err := db.Iterator([]byte("n/"), nil)
+	require.NoError(t, err)
+	var keys []string
+	for ; it.Valid(); it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+	require.NoError(t, it.Close())
+	require.Equal(t, []string{"n/1", "n/2"}, keys)
+	require.NoError(t, db.Close())
+
+	_, cfOpts, err := LoadLatestOptions(filepath.Join(dir, "application.db"))
+	require.NoError(t, err)
+	require.Equal(t, 0.1, cfOpts.GetMemTablePrefixBloomSizeRatio())
+}
@@ -5,25 +5,68 @@ package opendb
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"errors"
+
+	"github.com/linxGnu/grocksdb"
+)
+
+const (
+	// optionsStatisticsPropName is a name of property which contains serialized rocksdb statistics
+	optionsStatisticsPropName = "rocksdb.options-statistics"
+	// cfStatsPropName is a name of property which contains serialized stats of default column family
+	cfStatsPropName = "rocksdb.cfstats"
+
+	numFilesAtLevelPropPrefix         = "rocksdb.num-files-at-level"
+	compressionRatioAtLevelPropPrefix = "rocksdb.compression-ratio-at-level"
 )
 
 type propsGetter interface {
 	GetProperty(propName string) (value string)
 	GetIntProperty(propName string) (value uint64, success bool)
+	// GetAggregatedIntProperty returns sum of int property over all column families
+	GetAggregatedIntProperty(propName string) (value uint64, success bool)
+}
+
+// cfPropsGetter gets properties of the default column family and properties aggregated over all column families
+type cfPropsGetter struct {
+	*grocksdb.DB
+	cfHandles []*grocksdb.ColumnFamilyHandle
+}
+
+// GetAggregatedIntProperty implements propsGetter like DB::GetAggregatedIntProperty of rocksdb,
+// which isn't exposed by C API
+func (g cfPropsGetter) GetAggregatedIntProperty(propName string) (uint64, bool) {
+	var sum uint64
+	for _, handle := range g.cfHandles {
+		value, ok := g.GetIntPropertyCF(propName, handle)
+		if !ok {
+			return 0, false
+		}
+		sum += value
+	}
+
+	return sum, true
 }
 
 type propsLoader struct {
-	db        propsGetter
-	errorMsgs []string
+	db propsGetter
+	// strict makes load fail if any property can't be loaded, it's intended to use in tests,
+	// otherwise properties which can't be loaded are left zero and reported as failed
+	strict bool
+
+	errorMsgs   []string
+	failedProps []string
 }
 
-func newPropsLoader(db propsGetter) *propsLoader {
+func newPropsLoader(db propsGetter, strict bool) *propsLoader {
 	return &propsLoader{
-		db:        db,
-		errorMsgs: make([]string, 0),
+		db:          db,
+		strict:      strict,
+		errorMsgs:   make([]string, 0),
+		failedProps: make([]string, 0),
 	}
 }
 
@@ -33,17 +76,37 @@ func (l *propsLoader) load() (*properties, error) {
 		BlockCacheCapacity:      l.getIntProperty("rocksdb.block-cache-capacity"),
 		BlockCachePinnedUsage:   l.getIntProperty("rocksdb.block-cache-pinned-usage"),
 		BlockCacheUsage:         l.getIntProperty("rocksdb.block-cache-usage"),
-		CurSizeActiveMemTable:   l.getIntProperty("rocksdb.cur-size-active-mem-table"),
-		CurSizeAllMemTables:     l.getIntProperty("rocksdb.cur-size-all-mem-tables"),
+		CurSizeActiveMemTable:   l.getAggregatedIntProperty("rocksdb.cur-size-active-mem-table"),
+		CurSizeAllMemTables:     l.getAggregatedIntProperty("rocksdb.cur-size-all-mem-tables"),
 		EstimateLiveDataSize:    l.getIntProperty("rocksdb.estimate-live-data-size"),
 		EstimateNumKeys:         l.getIntProperty("rocksdb.estimate-num-keys"),
-		EstimateTableReadersMem: l.getIntProperty("rocksdb.estimate-table-readers-mem"),
+		EstimateTableReadersMem: l.getAggregatedIntProperty("rocksdb.estimate-table-readers-mem"),
 		LiveSSTFilesSize:        l.getIntProperty("rocksdb.live-sst-files-size"),
-		SizeAllMemTables:        l.getIntProperty("rocksdb.size-all-mem-tables"),
-		OptionsStatistics:       l.getProperty("rocksdb.options-statistics"),
+		SizeAllMemTables:        l.getAggregatedIntProperty("rocksdb.size-all-mem-tables"),
+
+		CompactionPending:              l.getIntProperty("rocksdb.compaction-pending"),
+		EstimatePendingCompactionBytes: l.getIntProperty("rocksdb.estimate-pending-compaction-bytes"),
+		NumRunningCompactions:          l.getIntProperty("rocksdb.num-running-compactions"),
+		NumRunningFlushes:              l.getIntProperty("rocksdb.num-running-flushes"),
+		MemTableFlushPending:           l.getIntProperty("rocksdb.mem-table-flush-pending"),
+		ActualDelayedWriteRate:         l.getIntProperty("rocksdb.actual-delayed-write-rate"),
+		IsWriteStopped:                 l.getIntProperty("rocksdb.is-write-stopped"),
+
+		NumBlobFiles:            l.getIntProperty("rocksdb.num-blob-files"),
+		TotalBlobFileSize:       l.getIntProperty("rocksdb.total-blob-file-size"),
+		LiveBlobFileSize:        l.getIntProperty("rocksdb.live-blob-file-size"),
+		LiveBlobFileGarbageSize: l.getIntProperty("rocksdb.live-blob-file-garbage-size"),
+
+		OptionsStatistics: l.getProperty(optionsStatisticsPropName),
+		Levels:            l.getLevelProperties(),
+		CompactionStats:   l.getCompactionStats(),
+	}
+	props.failed = make(map[string]struct{}, len(l.failedProps))
+	for _, propName := range l.failedProps {
+		props.failed[propName] = struct{}{}
 	}
 
-	if len(l.errorMsgs) != 0 {
+	if l.strict && len(l.errorMsgs) != 0 {
 		errorMsg := strings.Join(l.errorMsgs, ";")
 		return nil, errors.New(errorMsg)
 	}
@@ -51,10 +114,16 @@ func (l *propsLoader) load() (*properties, error) {
 	return props, nil
 }
 
+// failures returns names of properties which can't be loaded
+func (l *propsLoader) failures() []string {
+	return l.failedProps
+}
+
 func (l *propsLoader) getProperty(propName string) string {
 	value := l.db.GetProperty(propName)
 	if value == "" {
 		l.errorMsgs = append(l.errorMsgs, fmt.Sprintf("property %v is empty", propName))
+		l.failedProps = append(l.failedProps, propName)
 		return ""
 	}
 
@@ -65,17 +134,104 @@ func (l *propsLoader) getIntProperty(propName string) uint64 {
 	value, ok := l.db.GetIntProperty(propName)
 	if !ok {
 		l.errorMsgs = append(l.errorMsgs, fmt.Sprintf("can't get %v int property", propName))
+		l.failedProps = append(l.failedProps, propName)
+		return 0
+	}
+
+	return value
+}
+
+// getAggregatedIntProperty gets int property summed over all column families,
+// it's used for memory usage which should be reported per database
+func (l *propsLoader) getAggregatedIntProperty(propName string) uint64 {
+	value, ok := l.db.GetAggregatedIntProperty(propName)
+	if !ok {
+		l.errorMsgs = append(l.errorMsgs, fmt.Sprintf("can't get %v aggregated int property", propName))
+		l.failedProps = append(l.failedProps, propName)
 		return 0
 	}
 
 	return value
 }
 
+// getLevelProperties gets properties of every lsm level,
+// number of levels is determined by rocksdb.num-files-at-levelN property which is empty for levels >= num_levels
+func (l *propsLoader) getLevelProperties() []levelProperties {
+	levels := make([]levelProperties, 0)
+	for level := 0; ; level++ {
+		numFilesPropName := fmt.Sprintf("%v%d", numFilesAtLevelPropPrefix, level)
+		numFiles := l.db.GetProperty(numFilesPropName)
+		if numFiles == "" {
+			break
+		}
+
+		levels = append(levels, levelProperties{
+			NumFiles:         l.parseUint(numFilesPropName, numFiles),
+			CompressionRatio: l.getFloatProperty(fmt.Sprintf("%v%d", compressionRatioAtLevelPropPrefix, level)),
+		})
+	}
+
+	if len(levels) == 0 {
+		propName := fmt.Sprintf("%v%d", numFilesAtLevelPropPrefix, 0)
+		l.errorMsgs = append(l.errorMsgs, fmt.Sprintf("property %v is empty", propName))
+		l.failedProps = append(l.failedProps, propName)
+	}
+
+	return levels
+}
+
+// getCompactionStats gets compaction stats by level from rocksdb.cfstats property
+func (l *propsLoader) getCompactionStats() map[string]*levelCompactionStats {
+	cfStats := l.getProperty(cfStatsPropName)
+	if cfStats == "" {
+		return nil
+	}
+
+	compactionStats, err := parseCompactionStats(cfStats)
+	if err != nil {
+		l.errorMsgs = append(l.errorMsgs, fmt.Sprintf("can't parse %v property: %v", cfStatsPropName, err))
+		l.failedProps = append(l.failedProps, cfStatsPropName)
+		return nil
+	}
+
+	return compactionStats
+}
+
+func (l *propsLoader) getFloatProperty(propName string) float64 {
+	value := l.getProperty(propName)
+	if value == "" {
+		return 0
+	}
+
+	floatVal, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		l.errorMsgs = append(l.errorMsgs, fmt.Sprintf("can't parse %v float property: %v", propName, err))
+		l.failedProps = append(l.failedProps, propName)
+		return 0
+	}
+
+	return floatVal
+}
+
+func (l *propsLoader) parseUint(propName, value string) uint64 {
+	uintVal, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		l.errorMsgs = append(l.errorMsgs, fmt.Sprintf("can't parse %v uint property: %v", propName, err))
+		l.failedProps = append(l.failedProps, propName)
+		return 0
+	}
+
+	return uintVal
+}
+
 type properties struct {
-	BaseLevel               uint64
-	BlockCacheCapacity      uint64
-	BlockCachePinnedUsage   uint64
-	BlockCacheUsage         uint64
+	BaseLevel uint64
+	// block cache properties are the same for all column families, because they share block cache,
+	// they report usage of the whole shared block cache if database uses it
+	BlockCacheCapacity    uint64
+	BlockCachePinnedUsage uint64
+	BlockCacheUsage       uint64
+	// memory used by memtables and table readers is summed over all column families of the database
 	CurSizeActiveMemTable   uint64
 	CurSizeAllMemTables     uint64
 	EstimateLiveDataSize    uint64
@@ -84,4 +240,54 @@ type properties struct {
 	LiveSSTFilesSize        uint64
 	SizeAllMemTables        uint64
 	OptionsStatistics       string
+
+	// Compaction and flush pressure
+	// CompactionPending is 1 if at least one compaction is pending, otherwise 0
+	CompactionPending uint64
+	// EstimatePendingCompactionBytes is estimated total number of bytes compaction needs to rewrite
+	// to get all levels down to under target size
+	EstimatePendingCompactionBytes uint64
+	NumRunningCompactions          uint64
+	NumRunningFlushes              uint64
+	// MemTableFlushPending is 1 if a memtable flush is pending, otherwise 0
+	MemTableFlushPending uint64
+	// ActualDelayedWriteRate is current write rate in bytes per second when writes are delayed, 0 means no delay
+	ActualDelayedWriteRate uint64
+	// IsWriteStopped is 1 if writes have been stopped, otherwise 0
+	IsWriteStopped uint64
+
+	// Blob files, they are zero if blob files are disabled
+	NumBlobFiles uint64
+	// TotalBlobFileSize is a total size of all blob files including obsolete ones which aren't deleted yet
+	TotalBlobFileSize uint64
+	// LiveBlobFileSize is a total size of blob files in the current version
+	LiveBlobFileSize uint64
+	// LiveBlobFileGarbageSize is a total size of garbage in blob files in the current version
+	LiveBlobFileGarbageSize uint64
+
+	// Levels contains properties of lsm levels, index is a level number
+	Levels []levelProperties
+	// CompactionStats contains compaction stats parsed from rocksdb.cfstats property,
+	// key is a level number or sumLevelLabel for totals over all levels
+	CompactionStats map[string]*levelCompactionStats
+
+	// failed contains names of properties which can't be loaded, their values are left zero
+	failed map[string]struct{}
+}
+
+// loaded returns true if property is loaded, properties which can't be loaded shouldn't be reported as zero
+func (p *properties) loaded(propName string) bool {
+	_, failed := p.failed[propName]
+	return !failed
+}
+
+// writeStoppedOrDelayed returns true if writes are currently stopped or delayed
+func (p *properties) writeStoppedOrDelayed() bool {
+	return p.IsWriteStopped != 0 || p.ActualDelayedWriteRate != 0
+}
+
+type levelProperties struct {
+	NumFiles uint64
+	// CompressionRatio is -1 if there are no files at the level
+	CompressionRatio float64
 }
@@ -4,6 +4,7 @@
 package opendb
 
 import (
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -33,9 +34,21 @@ func (m *mockPropsGetter) GetIntProperty(propName string) (uint64, bool) {
 	return prop, ok
 }
 
+func (m *mockPropsGetter) GetAggregatedIntProperty(propName string) (uint64, bool) {
+	prop, ok := m.intProps[propName]
+	return prop, ok
+}
+
 func TestPropsLoader(t *testing.T) {
 	defaultProps := map[string]string{
-		"rocksdb.options-statistics": "1",
+		"rocksdb.options-statistics":          "1",
+		"rocksdb.num-files-at-level0":         "2",
+		"rocksdb.num-files-at-level1":         "0",
+		"rocksdb.compression-ratio-at-level0": "1.5",
+		"rocksdb.compression-ratio-at-level1": "-1.000000",
+		"rocksdb.cfstats": `Level    Files   Size     Score W-Amp
+  L0      2/0    2.00 KB   0.5   1.0
+`,
 	}
 	defaultIntProps := map[string]uint64{
 		"rocksdb.base-level":                 1,
@@ -49,6 +62,19 @@ func TestPropsLoader(t *testing.T) {
 		"rocksdb.estimate-table-readers-mem": 9,
 		"rocksdb.live-sst-files-size":        10,
 		"rocksdb.size-all-mem-tables":        11,
+
+		"rocksdb.compaction-pending":                1,
+		"rocksdb.estimate-pending-compaction-bytes": 12,
+		"rocksdb.num-running-compactions":           13,
+		"rocksdb.num-running-flushes":               14,
+		"rocksdb.mem-table-flush-pending":           1,
+		"rocksdb.actual-delayed-write-rate":         15,
+		"rocksdb.is-write-stopped":                  0,
+
+		"rocksdb.num-blob-files":              2,
+		"rocksdb.total-blob-file-size":        16,
+		"rocksdb.live-blob-file-size":         17,
+		"rocksdb.live-blob-file-garbage-size": 18,
 	}
 	missingProps := make(map[string]string)
 	missingIntProps := make(map[string]uint64)
@@ -65,48 +91,231 @@ func TestPropsLoader(t *testing.T) {
 		LiveSSTFilesSize:        10,
 		SizeAllMemTables:        11,
 		OptionsStatistics:       "1",
+
+		CompactionPending:              1,
+		EstimatePendingCompactionBytes: 12,
+		NumRunningCompactions:          13,
+		NumRunningFlushes:              14,
+		MemTableFlushPending:           1,
+		ActualDelayedWriteRate:         15,
+		IsWriteStopped:                 0,
+
+		NumBlobFiles:            2,
+		TotalBlobFileSize:       16,
+		LiveBlobFileSize:        17,
+		LiveBlobFileGarbageSize: 18,
+
+		Levels: []levelProperties{
+			{NumFiles: 2, CompressionRatio: 1.5},
+			{NumFiles: 0, CompressionRatio: -1},
+		},
+		CompactionStats: map[string]*levelCompactionStats{
+			"0": {NumFiles: 2, SizeBytes: 2048, Score: 0.5, WriteAmp: 1},
+		},
+	}
+
+	propsWithoutStatistics := defaultExpectedProps
+	propsWithoutStatistics.OptionsStatistics = ""
+	propsWithoutStatistics.Levels = []levelProperties{}
+	propsWithoutStatistics.CompactionStats = nil
+	propsWithoutIntProps := properties{
+		OptionsStatistics: "1",
+		Levels:            defaultExpectedProps.Levels,
+		CompactionStats:   defaultExpectedProps.CompactionStats,
+	}
+	invalidProps := map[string]string{
+		"rocksdb.options-statistics":          "1",
+		"rocksdb.num-files-at-level0":         "invalid",
+		"rocksdb.compression-ratio-at-level0": "invalid",
+		"rocksdb.cfstats": `Level    Files   Size     Score W-Amp
+  L0      invalid    2.00 KB   0.5   1.0
+`,
 	}
+	propsWithInvalidLevels := defaultExpectedProps
+	propsWithInvalidLevels.Levels = []levelProperties{{}}
+	propsWithInvalidLevels.CompactionStats = nil
 
 	for _, tc := range []struct {
-		desc          string
-		props         map[string]string
-		intProps      map[string]uint64
-		expectedProps *properties
-		success       bool
+		desc             string
+		props            map[string]string
+		intProps         map[string]uint64
+		strict           bool
+		expectedProps    *properties
+		expectedFailures []string
+		success          bool
 	}{
 		{
-			desc:          "success case",
-			props:         defaultProps,
-			intProps:      defaultIntProps,
-			expectedProps: &defaultExpectedProps,
-			success:       true,
+			desc:             "success case",
+			props:            defaultProps,
+			intProps:         defaultIntProps,
+			strict:           true,
+			expectedProps:    &defaultExpectedProps,
+			expectedFailures: []string{},
+			success:          true,
+		},
+		{
+			desc:             "missing props",
+			props:            missingProps,
+			intProps:         defaultIntProps,
+			strict:           true,
+			expectedProps:    nil,
+			expectedFailures: []string{"rocksdb.options-statistics", "rocksdb.num-files-at-level0", "rocksdb.cfstats"},
+			success:          false,
+		},
+		{
+			desc:             "missing integer props",
+			props:            defaultProps,
+			intProps:         missingIntProps,
+			strict:           true,
+			expectedProps:    nil,
+			expectedFailures: mapKeys(defaultIntProps),
+			success:          false,
+		},
+		{
+			desc:             "best-effort success case",
+			props:            defaultProps,
+			intProps:         defaultIntProps,
+			strict:           false,
+			expectedProps:    &defaultExpectedProps,
+			expectedFailures: []string{},
+			success:          true,
 		},
 		{
-			desc:          "missing props",
-			props:         missingProps,
+			desc:             "best-effort missing props",
+			props:            missingProps,
+			intProps:         defaultIntProps,
+			strict:           false,
+			expectedProps:    &propsWithoutStatistics,
+			expectedFailures: []string{"rocksdb.options-statistics", "rocksdb.num-files-at-level0", "rocksdb.cfstats"},
+			success:          true,
+		},
+		{
+			desc:          "invalid level props",
+			props:         invalidProps,
 			intProps:      defaultIntProps,
+			strict:        true,
 			expectedProps: nil,
-			success:       false,
+			expectedFailures: []string{
+				"rocksdb.num-files-at-level0",
+				"rocksdb.compression-ratio-at-level0",
+				"rocksdb.cfstats",
+			},
+			success: false,
 		},
 		{
-			desc:          "missing integer props",
-			props:         defaultProps,
-			intProps:      missingIntProps,
-			expectedProps: nil,
-			success:       false,
+			desc:          "best-effort invalid level props",
+			props:         invalidProps,
+			intProps:      defaultIntProps,
+			strict:        false,
+			expectedProps: &propsWithInvalidLevels,
+			expectedFailures: []string{
+				"rocksdb.num-files-at-level0",
+				"rocksdb.compression-ratio-at-level0",
+				"rocksdb.cfstats",
+			},
+			success: true,
+		},
+		{
+			desc:             "best-effort missing integer props",
+			props:            defaultProps,
+			intProps:         missingIntProps,
+			strict:           false,
+			expectedProps:    &propsWithoutIntProps,
+			expectedFailures: mapKeys(defaultIntProps),
+			success:          true,
 		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
 			mockPropsGetter := newMockPropsGetter(tc.props, tc.intProps)
 
-			propsLoader := newPropsLoader(mockPropsGetter)
+			propsLoader := newPropsLoader(mockPropsGetter, tc.strict)
 			actualProps, err := propsLoader.load()
 			if tc.success {
 				require.NoError(t, err)
 			} else {
 				require.Error(t, err)
 			}
+			require.ElementsMatch(t, tc.expectedFailures, propsLoader.failures())
+			if actualProps != nil {
+				// failed properties aren't reported, so they are marked in loaded properties
+				require.ElementsMatch(t, tc.expectedFailures, mapKeys(actualProps.failed))
+				actualProps.failed = nil
+			}
 			require.Equal(t, tc.expectedProps, actualProps)
 		})
 	}
 }
+
+func mapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+func TestWriteStoppedOrDelayed(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		props    properties
+		expected bool
+	}{
+		{
+			desc:     "writes aren't stopped or delayed",
+			props:    properties{},
+			expected: false,
+		},
+		{
+			desc:     "writes are stopped",
+			props:    properties{IsWriteStopped: 1},
+			expected: true,
+		},
+		{
+			desc:     "writes are delayed",
+			props:    properties{ActualDelayedWriteRate: 1024},
+			expected: true,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.props.writeStoppedOrDelayed())
+		})
+	}
+}
+
+func TestCFPropsGetter(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	mockAppOpts := newMockAppOptions(map[string]interface{}{
+		columnFamiliesOptName: []string{"nodes"},
+	})
+	db, err := openRocksdb(dir, defaultDBName, mockAppOpts, MetricsConfig{})
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	nodesDB, err := db.ColumnFamily("nodes")
+	require.NoError(t, err)
+	require.NoError(t, nodesDB.Set([]byte("key"), []byte("value")))
+
+	propsGetter := db.propsGetter()
+	defaultSize, ok := propsGetter.GetIntProperty("rocksdb.size-all-mem-tables")
+	require.True(t, ok)
+	nodesSize, ok := propsGetter.GetIntPropertyCF("rocksdb.size-all-mem-tables", db.cfHandles["nodes"])
+	require.True(t, ok)
+
+	// memory used by memtables of the database includes memtables of all column families
+	size, ok := propsGetter.GetAggregatedIntProperty("rocksdb.size-all-mem-tables")
+	require.True(t, ok)
+	require.Equal(t, defaultSize+nodesSize, size)
+	require.Greater(t, nodesSize, uint64(0))
+
+	_, ok = propsGetter.GetAggregatedIntProperty("rocksdb.unknown")
+	require.False(t, ok)
+}
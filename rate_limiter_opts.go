@@ -0,0 +1,80 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/linxGnu/grocksdb"
+)
+
+const (
+	// rateLimiterBytesPerSecDBOptName is a limit of flush and compaction write rate, 0 (default) disables rate limiter,
+	// rate limiter is created per database, so databases don't share the limit
+	rateLimiterBytesPerSecDBOptName = "rate_limiter_bytes_per_sec"
+	// rateLimiterRefillPeriodUsDBOptName is a period of refilling rate limiter tokens in microseconds,
+	// larger value leads to burstier writes while smaller value introduces more CPU overhead
+	rateLimiterRefillPeriodUsDBOptName = "rate_limiter_refill_period_us"
+	// rateLimiterFairnessDBOptName is a chance (1/fairness) of low priority compaction requests
+	// to be served before high priority flush requests
+	rateLimiterFairnessDBOptName = "rate_limiter_fairness"
+	// rateLimiterAutoTunedDBOptName enables dynamic adjustment of rate limit within
+	// [rate_limiter_bytes_per_sec / 20, rate_limiter_bytes_per_sec] according to the recent demand for background I/O
+	rateLimiterAutoTunedDBOptName = "rate_limiter_auto_tuned"
+
+	defaultRateLimiterRefillPeriodUs = 100 * 1000
+	defaultRateLimiterFairness       = 10
+)
+
+// overrideRateLimiterOpts sets rate limiter of dbOpts if rate_limiter_bytes_per_sec is specified in appOpts
+func overrideRateLimiterOpts(dbOpts *grocksdb.Options, appOpts AppOptions) error {
+	bytesPerSec, _, err := sizeOptValue(appOpts, rateLimiterBytesPerSecDBOptName)
+	if err != nil {
+		return err
+	}
+	if bytesPerSec > math.MaxInt64 {
+		return fmt.Errorf("%v is too large: %v", rateLimiterBytesPerSecDBOptName, bytesPerSec)
+	}
+	if bytesPerSec == 0 {
+		for _, optName := range []string{rateLimiterRefillPeriodUsDBOptName, rateLimiterFairnessDBOptName, rateLimiterAutoTunedDBOptName} {
+			if appOpts.Get(optName) != nil {
+				return fmt.Errorf("%v requires %v", optName, rateLimiterBytesPerSecDBOptName)
+			}
+		}
+
+		return nil
+	}
+
+	refillPeriod, _, err := optValue(appOpts, rateLimiterRefillPeriodUsDBOptName)
+	if err != nil {
+		return err
+	}
+	refillPeriodUs := refillPeriod.duration.Microseconds()
+	if refillPeriodUs <= 0 {
+		return fmt.Errorf("%v should be positive, got %v", rateLimiterRefillPeriodUsDBOptName, refillPeriodUs)
+	}
+
+	fairness, _, err := optValue(appOpts, rateLimiterFairnessDBOptName)
+	if err != nil {
+		return err
+	}
+	if fairness.intVal <= 0 || fairness.intVal > math.MaxInt32 {
+		return fmt.Errorf("%v should be positive 32-bit integer, got %v", rateLimiterFairnessDBOptName, fairness.intVal)
+	}
+
+	autoTuned, _, err := optValue(appOpts, rateLimiterAutoTunedDBOptName)
+	if err != nil {
+		return err
+	}
+	newRateLimiter := grocksdb.NewRateLimiter
+	if autoTuned.boolVal {
+		newRateLimiter = grocksdb.NewAutoTunedRateLimiter
+	}
+
+	// dbOpts takes ownership of rate limiter
+	dbOpts.SetRateLimiter(newRateLimiter(int64(bytesPerSec), refillPeriodUs, int32(fairness.intVal)))
+
+	return nil
+}
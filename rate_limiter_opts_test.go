@@ -0,0 +1,109 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverrideRateLimiterOpts(t *testing.T) {
+	for _, tc := range []struct {
+		desc           string
+		mockAppOptions *mockAppOptions
+		success        bool
+	}{
+		{
+			desc:           "rate limiter is disabled by default",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{}),
+			success:        true,
+		},
+		{
+			desc: "rate limiter",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				rateLimiterBytesPerSecDBOptName:    100 << 20,
+				rateLimiterRefillPeriodUsDBOptName: 50000,
+				rateLimiterFairnessDBOptName:       5,
+			}),
+			success: true,
+		},
+		{
+			desc: "auto-tuned rate limiter",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				rateLimiterBytesPerSecDBOptName: 100 << 20,
+				rateLimiterAutoTunedDBOptName:   true,
+			}),
+			success: true,
+		},
+		{
+			desc: "negative bytes per sec",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				rateLimiterBytesPerSecDBOptName: -1,
+			}),
+			success: false,
+		},
+		{
+			desc: "auto-tuned without bytes per sec",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				rateLimiterAutoTunedDBOptName: true,
+			}),
+			success: false,
+		},
+		{
+			desc: "zero refill period",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				rateLimiterBytesPerSecDBOptName:    100 << 20,
+				rateLimiterRefillPeriodUsDBOptName: 0,
+			}),
+			success: false,
+		},
+		{
+			desc: "zero fairness",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				rateLimiterBytesPerSecDBOptName: 100 << 20,
+				rateLimiterFairnessDBOptName:    0,
+			}),
+			success: false,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			dbOpts := newDefaultOptions()
+			defer dbOpts.Destroy()
+
+			err := overrideRateLimiterOpts(dbOpts, tc.mockAppOptions)
+			if !tc.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRateLimiterIsInstalled(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	mockAppOpts := newMockAppOptions(map[string]interface{}{
+		rateLimiterBytesPerSecDBOptName: 100 << 20,
+	})
+	db, err := openRocksdb(dir, defaultDBName, mockAppOpts, MetricsConfig{})
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// rocksdb persists installed rate limiter in OPTIONS file
+	file, err := loadLatestOptionsFile(filepath.Join(dir, defaultDBName+".db"))
+	require.NoError(t, err)
+	rateLimiter, ok := file.dbOptions()["rate_limiter"]
+	require.True(t, ok)
+	require.NotEqual(t, "nullptr", rateLimiter)
+}
@@ -0,0 +1,150 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"bytes"
+
+	dbm "github.com/cometbft/cometbft-db"
+	"github.com/linxGnu/grocksdb"
+)
+
+// rocksDBIterator implements dbm.Iterator interface, it's a copy of unexported cometbft-db iterator, see for details:
+// https://github.com/Kava-Labs/cometbft-db/blob/v0.9.1-kava.2/rocksdb_iterator.go
+// it's needed to iterate over column families other than default
+type rocksDBIterator struct {
+	source     *grocksdb.Iterator
+	start, end []byte
+	isReverse  bool
+	isInvalid  bool
+}
+
+var _ dbm.Iterator = (*rocksDBIterator)(nil)
+
+func newRocksDBIterator(source *grocksdb.Iterator, start, end []byte, isReverse bool) *rocksDBIterator {
+	if isReverse {
+		if end == nil {
+			source.SeekToLast()
+		} else {
+			source.Seek(end)
+			if source.Valid() {
+				eoakey := moveSliceToBytes(source.Key()) // end or after key
+				if bytes.Compare(end, eoakey) <= 0 {
+					source.Prev()
+				}
+			} else {
+				source.SeekToLast()
+			}
+		}
+	} else {
+		if start == nil {
+			source.SeekToFirst()
+		} else {
+			source.Seek(start)
+		}
+	}
+
+	return &rocksDBIterator{
+		source:    source,
+		start:     start,
+		end:       end,
+		isReverse: isReverse,
+		isInvalid: false,
+	}
+}
+
+// Domain implements dbm.Iterator.
+func (itr *rocksDBIterator) Domain() ([]byte, []byte) {
+	return itr.start, itr.end
+}
+
+// Valid implements dbm.Iterator.
+func (itr *rocksDBIterator) Valid() bool {
+	// Once invalid, forever invalid.
+	if itr.isInvalid {
+		return false
+	}
+
+	// If source has error, invalid.
+	if err := itr.source.Err(); err != nil {
+		itr.isInvalid = true
+		return false
+	}
+
+	// If source is invalid, invalid.
+	if !itr.source.Valid() {
+		itr.isInvalid = true
+		return false
+	}
+
+	// If key is end or past it, invalid.
+	key := moveSliceToBytes(itr.source.Key())
+	if itr.isReverse {
+		if itr.start != nil && bytes.Compare(key, itr.start) < 0 {
+			itr.isInvalid = true
+			return false
+		}
+	} else {
+		if itr.end != nil && bytes.Compare(itr.end, key) <= 0 {
+			itr.isInvalid = true
+			return false
+		}
+	}
+
+	// It's valid.
+	return true
+}
+
+// Key implements dbm.Iterator.
+func (itr *rocksDBIterator) Key() []byte {
+	itr.assertIsValid()
+	return moveSliceToBytes(itr.source.Key())
+}
+
+// Value implements dbm.Iterator.
+func (itr *rocksDBIterator) Value() []byte {
+	itr.assertIsValid()
+	return moveSliceToBytes(itr.source.Value())
+}
+
+// Next implements dbm.Iterator.
+func (itr *rocksDBIterator) Next() {
+	itr.assertIsValid()
+	if itr.isReverse {
+		itr.source.Prev()
+	} else {
+		itr.source.Next()
+	}
+}
+
+// Error implements dbm.Iterator.
+func (itr *rocksDBIterator) Error() error {
+	return itr.source.Err()
+}
+
+// Close implements dbm.Iterator.
+func (itr *rocksDBIterator) Close() error {
+	itr.source.Close()
+	return nil
+}
+
+func (itr *rocksDBIterator) assertIsValid() {
+	if !itr.Valid() {
+		panic("iterator is invalid")
+	}
+}
+
+// moveSliceToBytes will free the slice and copy out a go []byte
+// This function can be applied on *Slice returned from Key() and Value()
+// of an Iterator, because they are marked as freed.
+func moveSliceToBytes(s *grocksdb.Slice) []byte {
+	defer s.Free()
+	if !s.Exists() {
+		return nil
+	}
+	v := make([]byte, len(s.Data()))
+	copy(v, s.Data())
+
+	return v
+}
@@ -0,0 +1,256 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/linxGnu/grocksdb"
+)
+
+// ErrImmutableOptions is returned by SetOptions if options which can't be changed without restart are changed
+var ErrImmutableOptions = errors.New("options can't be changed without restart")
+
+// OptionChange is a change of rocksdb option applied at runtime,
+// Before and After are values of the option in rocksdb OPTIONS file before and after the change
+type OptionChange struct {
+	// ColumnFamily is a name of column family which option is changed
+	ColumnFamily string
+	Name         string
+	Before       string
+	After        string
+}
+
+// noAppOptions are applied appOpts of database opened without appOpts
+type noAppOptions struct{}
+
+func (noAppOptions) Get(string) interface{} { return nil }
+
+// compressionTypeNames maps rocksdb compression types to their names in rocksdb options string
+var compressionTypeNames = map[grocksdb.CompressionType]string{
+	grocksdb.NoCompression:     "kNoCompression",
+	grocksdb.SnappyCompression: "kSnappyCompression",
+	grocksdb.ZLibCompression:   "kZlibCompression",
+	grocksdb.Bz2Compression:    "kBZip2Compression",
+	grocksdb.LZ4Compression:    "kLZ4Compression",
+	grocksdb.LZ4HCCompression:  "kLZ4HCCompression",
+	grocksdb.XpressCompression: "kXpressCompression",
+	grocksdb.ZSTDCompression:   "kZSTD",
+}
+
+// formatCompressionType formats compression type as its name in rocksdb options string
+func formatCompressionType(value optionValue) (string, error) {
+	compressionType, err := parseCompressionType(value.stringVal)
+	if err != nil {
+		return "", err
+	}
+
+	return compressionTypeNames[compressionType], nil
+}
+
+func formatPrefixExtractor(value optionValue) (string, error) {
+	return parsePrefixExtractor(value.stringVal)
+}
+
+// optionChanged returns true if option is specified in newAppOpts and its value differs from oldAppOpts,
+// options which are removed from appOpts are ignored, because their previous values are unknown
+func optionChanged(oldAppOpts, newAppOpts AppOptions, name string) bool {
+	newValue := newAppOpts.Get(name)
+	if newValue == nil {
+		return false
+	}
+
+	return fmt.Sprint(oldAppOpts.Get(name)) != fmt.Sprint(newValue)
+}
+
+// changedMutableOpts returns keys and values of rocksdb options string for mutable options of scope which are changed
+func changedMutableOpts(scope optionScope, oldAppOpts, newAppOpts AppOptions) (keys []string, values []string, err error) {
+	var defs []optionDef
+	for _, def := range optionDefs {
+		if def.scope == scope && def.mutable {
+			defs = append(defs, def)
+		}
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].name < defs[j].name })
+
+	for _, def := range defs {
+		if !optionChanged(oldAppOpts, newAppOpts, def.name) {
+			continue
+		}
+
+		value, _, err := def.value(newAppOpts)
+		if err != nil {
+			return nil, nil, err
+		}
+		rocksdbValue, err := def.formatRocksDBValue(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %v: %w", def.name, err)
+		}
+		keys = append(keys, def.rocksdbName)
+		values = append(values, rocksdbValue)
+	}
+
+	return keys, values, nil
+}
+
+// SetOptions applies mutable options of appOpts to the opened database without restart,
+// appOpts has the same format as appOpts of OpenDB, only options which differ from currently applied are changed.
+// It returns ErrImmutableOptions if options which require restart are changed, in such case nothing is applied.
+// Options which are removed from appOpts aren't reset to defaults. Options are checked before any of them is applied,
+// if rocksdb still rejects options of column family, options of column families applied before it remain changed,
+// such changes are logged and returned together with error.
+func (db *RocksDB) SetOptions(appOpts AppOptions) ([]OptionChange, error) {
+	return db.setOptions(newRocksDBOptions(appOpts, db.name))
+}
+
+// setOptions applies mutable options of appOpts which are already wrapped with rocksDBOptions
+func (db *RocksDB) setOptions(appOpts AppOptions) ([]OptionChange, error) {
+	db.optsMtx.Lock()
+	defer db.optsMtx.Unlock()
+
+	if db.closed {
+		return nil, errDBClosed
+	}
+	if err := db.checkImmutableOpts(appOpts); err != nil {
+		return nil, err
+	}
+
+	cfKeys := make(map[string][]string, len(db.cfNames))
+	cfValues := make(map[string][]string, len(db.cfNames))
+	for _, cfName := range db.cfNames {
+		var err error
+		// column family specific options take precedence over database options
+		cfKeys[cfName], cfValues[cfName], err = changedMutableOpts(
+			cfScope,
+			newColumnFamilyOptions(db.appOpts, cfName),
+			newColumnFamilyOptions(appOpts, cfName),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("invalid options of column family %v: %w", cfName, err)
+		}
+		if err := checkRocksDBOpts(cfKeys[cfName], cfValues[cfName]); err != nil {
+			return nil, fmt.Errorf("invalid options of column family %v: %w", cfName, err)
+		}
+	}
+
+	before, err := loadLatestOptionsFile(db.path)
+	if err != nil {
+		return nil, fmt.Errorf("can't load options file: %w", err)
+	}
+
+	appliedCFNames := db.cfNames
+	var setErr error
+	for i, cfName := range db.cfNames {
+		if err := db.db.SetOptionsCF(db.cfHandles[cfName], cfKeys[cfName], cfValues[cfName]); err != nil {
+			appliedCFNames = db.cfNames[:i]
+			setErr = fmt.Errorf("can't set options of column family %v: %w", cfName, err)
+			break
+		}
+	}
+	if setErr == nil {
+		db.appOpts = appOpts
+	}
+
+	after, err := loadLatestOptionsFile(db.path)
+	if err != nil {
+		return nil, errors.Join(setErr, fmt.Errorf("can't load options file: %w", err))
+	}
+
+	var changes []OptionChange
+	for _, cfName := range appliedCFNames {
+		changes = append(changes, diffOptions(cfName, cfKeys[cfName], before.cfOptions(cfName), after.cfOptions(cfName))...)
+	}
+	for _, change := range changes {
+		db.logger.Info(
+			"rocksdb option changed",
+			"db", db.name,
+			"column_family", change.ColumnFamily,
+			"option", change.Name,
+			"before", change.Before,
+			"after", change.After,
+		)
+	}
+	if setErr != nil {
+		db.logger.Error("rocksdb options are partially applied", "db", db.name, "err", setErr)
+		return changes, setErr
+	}
+
+	return changes, nil
+}
+
+// checkImmutableOpts returns ErrImmutableOptions if any of immutable options is changed in appOpts,
+// read and write options are shared by all readers and writers, so they are immutable as well
+func (db *RocksDB) checkImmutableOpts(appOpts AppOptions) error {
+	var changed []string
+	for _, def := range optionDefs {
+		if def.mutable {
+			continue
+		}
+		if optionChanged(db.appOpts, appOpts, def.name) {
+			changed = append(changed, def.name)
+			continue
+		}
+
+		for _, cfName := range db.cfNames {
+			if optionChanged(newColumnFamilyOptions(db.appOpts, cfName), newColumnFamilyOptions(appOpts, cfName), def.name) {
+				changed = append(changed, fmt.Sprintf("%v.%v.%v", columnFamilyOptsPrefix, cfName, def.name))
+			}
+		}
+	}
+
+	if len(changed) != 0 {
+		return fmt.Errorf("%w: %v", ErrImmutableOptions, strings.Join(changed, ", "))
+	}
+
+	return nil
+}
+
+// checkRocksDBOpts returns error if rocksdb rejects keys and values of rocksdb options string,
+// options aren't applied to the database
+func checkRocksDBOpts(keys []string, values []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	pairs := make([]string, len(keys))
+	for i := range keys {
+		pairs[i] = keys[i] + "=" + values[i]
+	}
+	opts := grocksdb.NewDefaultOptions()
+	defer opts.Destroy()
+	newOpts, err := grocksdb.GetOptionsFromString(opts, strings.Join(pairs, ";"))
+	if err != nil {
+		return err
+	}
+	newOpts.Destroy()
+
+	return nil
+}
+
+// diffOptions returns changes of options with provided keys between before and after sections of OPTIONS file
+func diffOptions(cfName string, keys []string, before, after map[string]string) []OptionChange {
+	changes := make([]OptionChange, 0, len(keys))
+	for _, key := range keys {
+		if before[key] == after[key] {
+			continue
+		}
+		changes = append(changes, OptionChange{
+			ColumnFamily: cfName,
+			Name:         key,
+			Before:       before[key],
+			After:        after[key],
+		})
+	}
+
+	return changes
+}
+
+// SetLogger sets logger which logs runtime changes of options,
+// it should be called before SetOptions or WatchOptionsFile
+func (db *RocksDB) SetLogger(logger Logger) {
+	db.logger = logger
+}
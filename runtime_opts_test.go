@@ -0,0 +1,149 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// mockLogger records messages and key values of logged entries
+type mockLogger struct {
+	entries []string
+}
+
+func (l *mockLogger) Info(msg string, keyvals ...interface{}) {
+	l.entries = append(l.entries, fmt.Sprint(append([]interface{}{msg}, keyvals...)...))
+}
+
+func (l *mockLogger) Error(msg string, keyvals ...interface{}) {
+	l.entries = append(l.entries, fmt.Sprint(append([]interface{}{msg}, keyvals...)...))
+}
+
+func TestChangedMutableOpts(t *testing.T) {
+	for _, tc := range []struct {
+		desc           string
+		oldAppOpts     map[string]interface{}
+		newAppOpts     map[string]interface{}
+		expectedKeys   []string
+		expectedValues []string
+		success        bool
+	}{
+		{
+			desc:       "unchanged and removed options are skipped",
+			oldAppOpts: map[string]interface{}{writeBufferSizeCFOptName: 1_000, ttlCFOptName: 60},
+			newAppOpts: map[string]interface{}{writeBufferSizeCFOptName: "1000"},
+			success:    true,
+		},
+		{
+			desc:       "changed options are formatted",
+			oldAppOpts: map[string]interface{}{compressionCFOptName: "lz4"},
+			newAppOpts: map[string]interface{}{
+				compressionCFOptName:                  "zstd",
+				prefixExtractorCFOptName:              "capped:3",
+				memtablePrefixBloomSizeRatioCFOptName: 0.1,
+				enableBlobFilesCFOptName:              true,
+				maxBytesForLevelMultiplierCFOptName:   10,
+			},
+			expectedKeys:   []string{"compression", "enable_blob_files", "max_bytes_for_level_multiplier", "memtable_prefix_bloom_size_ratio", "prefix_extractor"},
+			expectedValues: []string{"kZSTD", "true", "10", "0.1", "capped:3"},
+			success:        true,
+		},
+		{
+			desc:       "invalid compression type",
+			oldAppOpts: map[string]interface{}{},
+			newAppOpts: map[string]interface{}{compressionCFOptName: "unknown"},
+			success:    false,
+		},
+		{
+			desc:       "out of range fraction",
+			oldAppOpts: map[string]interface{}{},
+			newAppOpts: map[string]interface{}{blobGarbageCollectionAgeCutoffCFOptName: 2},
+			success:    false,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			keys, values, err := changedMutableOpts(cfScope, newMockAppOptions(tc.oldAppOpts), newMockAppOptions(tc.newAppOpts))
+			if !tc.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedKeys, keys)
+			require.Equal(t, tc.expectedValues, values)
+		})
+	}
+}
+
+func TestSetOptions(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	db, err := openRocksdb(dir, defaultDBName, newMockAppOptions(map[string]interface{}{
+		columnFamiliesOptName:                []string{"nodes"},
+		maxBackgroundJobsDBOptName:           2,
+		level0SlowdownWritesTriggerCFOptName: 20,
+		blockSizeBBTOOptName:                 4096,
+	}), MetricsConfig{})
+	require.NoError(t, err)
+	logger := &mockLogger{}
+	db.SetLogger(logger)
+
+	// immutable options can't be changed, nothing is applied in such case,
+	// database options are immutable, because rocksdb can change only options of column families
+	_, err = db.setOptions(newMockAppOptions(map[string]interface{}{
+		maxBackgroundJobsDBOptName:           3,
+		level0SlowdownWritesTriggerCFOptName: 30,
+		blockSizeBBTOOptName:                 8192,
+		"cf.nodes." + numLevelsCFOptName:     5,
+	}))
+	require.ErrorIs(t, err, ErrImmutableOptions)
+	require.ErrorContains(t, err, fmt.Sprintf("%v, cf.nodes.%v, %v", maxBackgroundJobsDBOptName, numLevelsCFOptName, blockSizeBBTOOptName))
+
+	changes, err := db.setOptions(newMockAppOptions(map[string]interface{}{
+		columnFamiliesOptName:                              []string{"nodes"},
+		maxBackgroundJobsDBOptName:                         2,
+		level0SlowdownWritesTriggerCFOptName:               20,
+		"cf.nodes." + level0SlowdownWritesTriggerCFOptName: 30,
+		maxWriteBufferNumberCFOptName:                      4,
+		blockSizeBBTOOptName:                               4096,
+	}))
+	require.NoError(t, err)
+	require.Equal(t, []OptionChange{
+		{ColumnFamily: DefaultColumnFamilyName, Name: "max_write_buffer_number", Before: "6", After: "4"},
+		{ColumnFamily: "nodes", Name: "level0_slowdown_writes_trigger", Before: "20", After: "30"},
+		{ColumnFamily: "nodes", Name: "max_write_buffer_number", Before: "6", After: "4"},
+	}, changes)
+	require.Len(t, logger.entries, 3)
+
+	// applied options are remembered, so the same options don't change anything
+	changes, err = db.setOptions(newMockAppOptions(map[string]interface{}{
+		"cf.nodes." + level0SlowdownWritesTriggerCFOptName: 30,
+		maxWriteBufferNumberCFOptName:                      4,
+	}))
+	require.NoError(t, err)
+	require.Empty(t, changes)
+	require.NoError(t, db.Close())
+
+	// options of closed database can't be changed
+	_, err = db.setOptions(newMockAppOptions(map[string]interface{}{
+		maxWriteBufferNumberCFOptName: 5,
+	}))
+	require.ErrorIs(t, err, errDBClosed)
+
+	dbOpts, cfOpts, err := LoadLatestOptions(filepath.Join(dir, "application.db"))
+	require.NoError(t, err)
+	require.Equal(t, 2, dbOpts.GetMaxBackgroundJobs())
+	require.Equal(t, 4, cfOpts.GetMaxWriteBufferNumber())
+	require.Equal(t, 20, cfOpts.GetLevel0SlowdownWritesTrigger())
+}
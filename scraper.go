@@ -0,0 +1,71 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"sync"
+	"time"
+)
+
+// scraper reports metrics of one database at scrape time
+type scraper struct {
+	// report loads properties and statistics from rocksdb and reports them
+	report             func()
+	minRefreshInterval time.Duration
+	lastRefresh        time.Time
+}
+
+// scrapers refreshes metrics of databases which are reported at scrape time.
+// Every database is queried at most once per its minimum refresh interval to bound the overhead,
+// more frequent scrapes collect metrics cached since the last refresh.
+type scrapers struct {
+	mtx sync.Mutex
+	// scrapers contains scraper per database name
+	scrapers map[string]*scraper
+	now      func() time.Time
+}
+
+func newScrapers() *scrapers {
+	return &scrapers{
+		scrapers: make(map[string]*scraper),
+		now:      time.Now,
+	}
+}
+
+// add starts reporting metrics of the database at scrape time
+func (s *scrapers) add(dbName string, report func(), minRefreshInterval time.Duration) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.scrapers[dbName] = &scraper{
+		report:             report,
+		minRefreshInterval: minRefreshInterval,
+	}
+}
+
+// remove stops reporting metrics of the database, it waits until in-flight refresh is finished,
+// so it's safe to close database after remove returns
+func (s *scrapers) remove(dbName string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	delete(s.scrapers, dbName)
+}
+
+// refresh reports metrics of databases which weren't refreshed during their minimum refresh interval
+// it's called by collectors before collecting metrics
+func (s *scrapers) refresh() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := s.now()
+	for _, scraper := range s.scrapers {
+		if !scraper.lastRefresh.IsZero() && now.Sub(scraper.lastRefresh) < scraper.minRefreshInterval {
+			continue
+		}
+
+		scraper.report()
+		scraper.lastRefresh = now
+	}
+}
@@ -0,0 +1,51 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrapersRefresh(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	scrapers := newScrapers()
+	scrapers.now = func() time.Time { return now }
+
+	reports := make(map[string]int)
+	scrapers.add("application", func() { reports["application"]++ }, time.Second)
+	scrapers.add("blockstore", func() { reports["blockstore"]++ }, 10*time.Second)
+
+	// first refresh reports every database
+	scrapers.refresh()
+	require.Equal(t, map[string]int{"application": 1, "blockstore": 1}, reports)
+
+	// databases aren't queried more often than their minimum refresh interval
+	now = now.Add(500 * time.Millisecond)
+	scrapers.refresh()
+	require.Equal(t, map[string]int{"application": 1, "blockstore": 1}, reports)
+
+	now = now.Add(500 * time.Millisecond)
+	scrapers.refresh()
+	require.Equal(t, map[string]int{"application": 2, "blockstore": 1}, reports)
+
+	now = now.Add(10 * time.Second)
+	scrapers.refresh()
+	require.Equal(t, map[string]int{"application": 3, "blockstore": 2}, reports)
+}
+
+func TestScrapersRemove(t *testing.T) {
+	scrapers := newScrapers()
+
+	reports := 0
+	scrapers.add("application", func() { reports++ }, 0)
+	scrapers.refresh()
+	require.Equal(t, 1, reports)
+
+	scrapers.remove("application")
+	scrapers.refresh()
+	require.Equal(t, 1, reports)
+}
@@ -0,0 +1,243 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/linxGnu/grocksdb"
+)
+
+const (
+	// sharedBlockCacheSizeOptName is a size of block cache shared by all databases opened in the process,
+	// 0 disables shared block cache, so every database creates its own block cache of block_cache_size
+	sharedBlockCacheSizeOptName = "shared_block_cache_size"
+	// sharedWriteBufferManagerSizeOptName is a limit of memory used by memtables of all databases opened in the process,
+	// 0 disables shared write buffer manager
+	sharedWriteBufferManagerSizeOptName = "shared_write_buffer_manager_size"
+	// sharedWriteBufferManagerAllowStallOptName enables stalling of writes when memtables exceed the limit
+	sharedWriteBufferManagerAllowStallOptName = "shared_write_buffer_manager_allow_stall"
+	// sharedWriteBufferManagerCostToCacheOptName charges memory used by memtables to the shared block cache
+	sharedWriteBufferManagerCostToCacheOptName = "shared_write_buffer_manager_cost_to_cache"
+
+	// useSharedBlockCacheOptName allows database to opt out of shared block cache, it's true by default
+	useSharedBlockCacheOptName = "use_shared_block_cache"
+	// useSharedWriteBufferManagerOptName allows database to opt out of shared write buffer manager, it's true by default
+	useSharedWriteBufferManagerOptName = "use_shared_write_buffer_manager"
+)
+
+var errCostToCacheWithoutSharedBlockCache = errors.New("shared write buffer manager can't cost memory to cache if shared block cache is disabled")
+
+// processSharedResources contains block cache and write buffer manager shared by all databases opened in the process
+var processSharedResources = &sharedResources{}
+
+// sharedResourcesOpts contains options of resources shared by databases
+type sharedResourcesOpts struct {
+	blockCacheSize uint64
+	// blockCacheOpts contains type and sharding of shared block cache
+	blockCacheOpts                blockCacheOpts
+	writeBufferManagerSize        uint64
+	writeBufferManagerAllowStall  bool
+	writeBufferManagerCostToCache bool
+	useSharedBlockCache           bool
+	useSharedWriteBufferManager   bool
+}
+
+func sharedResourcesOptsFromAppOpts(appOpts AppOptions) (sharedResourcesOpts, error) {
+	cacheOpts, err := blockCacheOptsFromAppOpts(appOpts)
+	if err != nil {
+		return sharedResourcesOpts{}, err
+	}
+
+	// every option has default, so values are always set
+	values := make(map[string]optionValue)
+	for _, name := range []string{
+		sharedBlockCacheSizeOptName,
+		sharedWriteBufferManagerSizeOptName,
+		sharedWriteBufferManagerAllowStallOptName,
+		sharedWriteBufferManagerCostToCacheOptName,
+		useSharedBlockCacheOptName,
+		useSharedWriteBufferManagerOptName,
+	} {
+		values[name], _, err = optValue(appOpts, name)
+		if err != nil {
+			return sharedResourcesOpts{}, err
+		}
+	}
+
+	opts := sharedResourcesOpts{
+		blockCacheOpts:                cacheOpts,
+		blockCacheSize:                values[sharedBlockCacheSizeOptName].uintVal,
+		writeBufferManagerSize:        values[sharedWriteBufferManagerSizeOptName].uintVal,
+		writeBufferManagerAllowStall:  values[sharedWriteBufferManagerAllowStallOptName].boolVal,
+		writeBufferManagerCostToCache: values[sharedWriteBufferManagerCostToCacheOptName].boolVal,
+		useSharedBlockCache:           values[useSharedBlockCacheOptName].boolVal,
+		useSharedWriteBufferManager:   values[useSharedWriteBufferManagerOptName].boolVal,
+	}
+
+	return opts, nil
+}
+
+// sharedResources contains reference counted block cache and write buffer manager,
+// resources are created when the first database acquires them and destroyed when the last database releases them
+type sharedResources struct {
+	mtx sync.Mutex
+
+	blockCache     *grocksdb.Cache
+	blockCacheSize uint64
+	blockCacheOpts blockCacheOpts
+	blockCacheRefs int
+
+	writeBufferManager     *grocksdb.WriteBufferManager
+	writeBufferManagerOpts sharedResourcesOpts
+	writeBufferManagerRefs int
+}
+
+// acquiredResources contains shared resources used by one database
+type acquiredResources struct {
+	// blockCache is nil if database doesn't use shared block cache
+	blockCache *grocksdb.Cache
+	// writeBufferManager is nil if database doesn't use shared write buffer manager
+	writeBufferManager *grocksdb.WriteBufferManager
+}
+
+// acquire returns shared resources requested by opts, creating them if needed
+// all databases should request resources of equal size, otherwise error is returned
+// release should be called after database which uses acquired resources is closed
+func (s *sharedResources) acquire(opts sharedResourcesOpts) (*acquiredResources, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	useBlockCache := opts.blockCacheSize != 0 && opts.useSharedBlockCache
+	useWriteBufferManager := opts.writeBufferManagerSize != 0 && opts.useSharedWriteBufferManager
+
+	// write buffer manager which costs memory to cache needs shared block cache even if database doesn't use it
+	needsBlockCache := useBlockCache || (useWriteBufferManager && opts.writeBufferManagerCostToCache)
+	if needsBlockCache && s.blockCache != nil &&
+		(s.blockCacheSize != opts.blockCacheSize || s.blockCacheOpts != opts.blockCacheOpts) {
+		return nil, fmt.Errorf(
+			"shared block cache is already created with size %v and options %+v, requested size %v and options %+v",
+			s.blockCacheSize, s.blockCacheOpts, opts.blockCacheSize, opts.blockCacheOpts,
+		)
+	}
+	if useWriteBufferManager {
+		if opts.writeBufferManagerCostToCache && opts.blockCacheSize == 0 {
+			return nil, errCostToCacheWithoutSharedBlockCache
+		}
+		if s.writeBufferManager != nil && !s.writeBufferManagerOpts.equalWriteBufferManager(opts) {
+			return nil, fmt.Errorf(
+				"shared write buffer manager is already created with size %v, allow stall %v, cost to cache %v",
+				s.writeBufferManagerOpts.writeBufferManagerSize,
+				s.writeBufferManagerOpts.writeBufferManagerAllowStall,
+				s.writeBufferManagerOpts.writeBufferManagerCostToCache,
+			)
+		}
+	}
+
+	acquired := &acquiredResources{}
+	if useBlockCache {
+		if s.blockCache == nil {
+			s.newBlockCache(opts)
+		}
+		s.blockCacheRefs++
+		acquired.blockCache = s.blockCache
+	}
+	if useWriteBufferManager {
+		if s.writeBufferManager == nil {
+			s.writeBufferManager = s.newWriteBufferManager(opts)
+			s.writeBufferManagerOpts = opts
+		}
+		s.writeBufferManagerRefs++
+		acquired.writeBufferManager = s.writeBufferManager
+	}
+
+	return acquired, nil
+}
+
+// newBlockCache creates block cache, it should be called with locked mutex
+func (s *sharedResources) newBlockCache(opts sharedResourcesOpts) {
+	s.blockCache = opts.blockCacheOpts.newCache(opts.blockCacheSize)
+	s.blockCacheSize = opts.blockCacheSize
+	s.blockCacheOpts = opts.blockCacheOpts
+}
+
+// newWriteBufferManager creates write buffer manager, it should be called with locked mutex
+func (s *sharedResources) newWriteBufferManager(opts sharedResourcesOpts) *grocksdb.WriteBufferManager {
+	if !opts.writeBufferManagerCostToCache {
+		return grocksdb.NewWriteBufferManager(int(opts.writeBufferManagerSize), opts.writeBufferManagerAllowStall)
+	}
+
+	// write buffer manager keeps its own reference to the cache, so cache can be destroyed before it
+	if s.blockCache == nil {
+		s.newBlockCache(opts)
+	}
+	return grocksdb.NewWriteBufferManagerWithCache(int(opts.writeBufferManagerSize), s.blockCache, opts.writeBufferManagerAllowStall)
+}
+
+// release releases resources acquired by database, resources which aren't used anymore are destroyed
+func (s *sharedResources) release(acquired *acquiredResources) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if acquired.writeBufferManager != nil {
+		s.writeBufferManagerRefs--
+		if s.writeBufferManagerRefs == 0 {
+			s.writeBufferManager.Destroy()
+			s.writeBufferManager = nil
+			s.writeBufferManagerOpts = sharedResourcesOpts{}
+		}
+	}
+	if acquired.blockCache != nil {
+		s.blockCacheRefs--
+	}
+	// block cache may be created for write buffer manager only, so it's destroyed when neither of them is used
+	if s.blockCache != nil && s.blockCacheRefs == 0 && s.writeBufferManager == nil {
+		s.blockCache.Destroy()
+		s.blockCache = nil
+		s.blockCacheSize = 0
+		s.blockCacheOpts = blockCacheOpts{}
+	}
+}
+
+// usage returns memory usage of shared resources
+func (s *sharedResources) usage() sharedResourcesUsage {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var usage sharedResourcesUsage
+	if s.blockCache != nil {
+		usage.blockCacheEnabled = true
+		usage.blockCacheCapacity = s.blockCache.GetCapacity()
+		usage.blockCacheUsage = s.blockCache.GetUsage()
+		usage.blockCachePinnedUsage = s.blockCache.GetPinnedUsage()
+	}
+	if s.writeBufferManager != nil {
+		usage.writeBufferManagerEnabled = true
+		usage.writeBufferManagerBufferSize = s.writeBufferManager.BufferSize()
+		usage.writeBufferManagerMemoryUsage = s.writeBufferManager.MemoryUsage()
+	}
+
+	return usage
+}
+
+// equalWriteBufferManager returns true if both options request equal write buffer manager
+func (o sharedResourcesOpts) equalWriteBufferManager(other sharedResourcesOpts) bool {
+	return o.writeBufferManagerSize == other.writeBufferManagerSize &&
+		o.writeBufferManagerAllowStall == other.writeBufferManagerAllowStall &&
+		o.writeBufferManagerCostToCache == other.writeBufferManagerCostToCache
+}
+
+// sharedResourcesUsage contains memory usage of shared resources
+type sharedResourcesUsage struct {
+	blockCacheEnabled     bool
+	blockCacheCapacity    uint64
+	blockCacheUsage       uint64
+	blockCachePinnedUsage uint64
+
+	writeBufferManagerEnabled     bool
+	writeBufferManagerBufferSize  int
+	writeBufferManagerMemoryUsage int
+}
@@ -0,0 +1,121 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"sync"
+
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	resourceMetricLabelName = "resource"
+
+	blockCacheResource         = "block_cache"
+	writeBufferManagerResource = "write_buffer_manager"
+)
+
+// sharedResourcesCollector is a prometheus collector which exposes memory usage of resources shared by all databases
+// opened in the process and resources used by every database
+type sharedResourcesCollector struct {
+	resources *sharedResources
+
+	blockCacheCapacityDesc            *stdprometheus.Desc
+	blockCacheUsageDesc               *stdprometheus.Desc
+	blockCachePinnedUsageDesc         *stdprometheus.Desc
+	writeBufferManagerBufferSizeDesc  *stdprometheus.Desc
+	writeBufferManagerMemoryUsageDesc *stdprometheus.Desc
+	inUseDesc                         *stdprometheus.Desc
+
+	mtx sync.RWMutex
+	// dbs contains shared resources acquired by database per database name
+	dbs map[string]*acquiredResources
+}
+
+var _ stdprometheus.Collector = (*sharedResourcesCollector)(nil)
+
+func newSharedResourcesCollector(namespace string, constLabels stdprometheus.Labels, resources *sharedResources) *sharedResourcesCollector {
+	newDesc := func(name, help string, labelNames []string) *stdprometheus.Desc {
+		return stdprometheus.NewDesc(
+			stdprometheus.BuildFQName(namespace, "shared", name),
+			help,
+			labelNames,
+			constLabels,
+		)
+	}
+
+	return &sharedResourcesCollector{
+		resources: resources,
+
+		blockCacheCapacityDesc:            newDesc("block_cache_capacity", "capacity of block cache shared by databases", nil),
+		blockCacheUsageDesc:               newDesc("block_cache_usage", "memory size for the entries residing in shared block cache", nil),
+		blockCachePinnedUsageDesc:         newDesc("block_cache_pinned_usage", "memory size for the entries being pinned in shared block cache", nil),
+		writeBufferManagerBufferSizeDesc:  newDesc("write_buffer_manager_buffer_size", "limit of memory used by memtables of databases sharing write buffer manager", nil),
+		writeBufferManagerMemoryUsageDesc: newDesc("write_buffer_manager_memory_usage", "memory used by memtables of databases sharing write buffer manager", nil),
+		inUseDesc: newDesc(
+			"resource_in_use",
+			"1 if database uses shared resource, otherwise 0",
+			[]string{dbNameMetricLabelName, resourceMetricLabelName},
+		),
+
+		dbs: make(map[string]*acquiredResources),
+	}
+}
+
+// update sets shared resources used by the database
+func (c *sharedResourcesCollector) update(dbName string, acquired *acquiredResources) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.dbs[dbName] = acquired
+}
+
+// remove removes shared resources of the database, it should be called when database is closed
+func (c *sharedResourcesCollector) remove(dbName string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	delete(c.dbs, dbName)
+}
+
+// Describe implements prometheus.Collector.
+func (c *sharedResourcesCollector) Describe(ch chan<- *stdprometheus.Desc) {
+	ch <- c.blockCacheCapacityDesc
+	ch <- c.blockCacheUsageDesc
+	ch <- c.blockCachePinnedUsageDesc
+	ch <- c.writeBufferManagerBufferSizeDesc
+	ch <- c.writeBufferManagerMemoryUsageDesc
+	ch <- c.inUseDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *sharedResourcesCollector) Collect(ch chan<- stdprometheus.Metric) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	// shared resources are reported only while at least one database reported by the collector uses them
+	if len(c.dbs) == 0 {
+		return
+	}
+
+	usage := c.resources.usage()
+	if usage.blockCacheEnabled {
+		ch <- stdprometheus.MustNewConstMetric(c.blockCacheCapacityDesc, stdprometheus.GaugeValue, float64(usage.blockCacheCapacity))
+		ch <- stdprometheus.MustNewConstMetric(c.blockCacheUsageDesc, stdprometheus.GaugeValue, float64(usage.blockCacheUsage))
+		ch <- stdprometheus.MustNewConstMetric(c.blockCachePinnedUsageDesc, stdprometheus.GaugeValue, float64(usage.blockCachePinnedUsage))
+	}
+	if usage.writeBufferManagerEnabled {
+		ch <- stdprometheus.MustNewConstMetric(c.writeBufferManagerBufferSizeDesc, stdprometheus.GaugeValue, float64(usage.writeBufferManagerBufferSize))
+		ch <- stdprometheus.MustNewConstMetric(c.writeBufferManagerMemoryUsageDesc, stdprometheus.GaugeValue, float64(usage.writeBufferManagerMemoryUsage))
+	}
+
+	for dbName, acquired := range c.dbs {
+		ch <- stdprometheus.MustNewConstMetric(
+			c.inUseDesc, stdprometheus.GaugeValue, boolToFloat64(acquired.blockCache != nil), dbName, blockCacheResource,
+		)
+		ch <- stdprometheus.MustNewConstMetric(
+			c.inUseDesc, stdprometheus.GaugeValue, boolToFloat64(acquired.writeBufferManager != nil), dbName, writeBufferManagerResource,
+		)
+	}
+}
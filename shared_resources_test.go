@@ -0,0 +1,226 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharedResourcesOptsFromAppOpts(t *testing.T) {
+	defaultCacheOpts := blockCacheOpts{
+		cacheType:            lruBlockCacheType,
+		numShardBits:         defaultBlockCacheNumShardBits,
+		estimatedEntryCharge: defaultBlockSize,
+	}
+
+	for _, tc := range []struct {
+		desc           string
+		mockAppOptions *mockAppOptions
+		expectedOpts   sharedResourcesOpts
+	}{
+		{
+			desc:           "default options",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{}),
+			expectedOpts: sharedResourcesOpts{
+				blockCacheOpts:              defaultCacheOpts,
+				useSharedBlockCache:         true,
+				useSharedWriteBufferManager: true,
+			},
+		},
+		{
+			desc: "shared resources",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				sharedBlockCacheSizeOptName:                8 << 30,
+				sharedWriteBufferManagerSizeOptName:        2 << 30,
+				sharedWriteBufferManagerAllowStallOptName:  true,
+				sharedWriteBufferManagerCostToCacheOptName: true,
+			}),
+			expectedOpts: sharedResourcesOpts{
+				blockCacheSize:                8 << 30,
+				blockCacheOpts:                defaultCacheOpts,
+				writeBufferManagerSize:        2 << 30,
+				writeBufferManagerAllowStall:  true,
+				writeBufferManagerCostToCache: true,
+				useSharedBlockCache:           true,
+				useSharedWriteBufferManager:   true,
+			},
+		},
+		{
+			desc: "opt out of shared resources",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				sharedBlockCacheSizeOptName:         8 << 30,
+				sharedWriteBufferManagerSizeOptName: 2 << 30,
+				useSharedBlockCacheOptName:          false,
+				useSharedWriteBufferManagerOptName:  false,
+			}),
+			expectedOpts: sharedResourcesOpts{
+				blockCacheSize:         8 << 30,
+				blockCacheOpts:         defaultCacheOpts,
+				writeBufferManagerSize: 2 << 30,
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			opts, err := sharedResourcesOptsFromAppOpts(tc.mockAppOptions)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedOpts, opts)
+		})
+	}
+}
+
+func TestSharedResourcesAcquireInvalidOpts(t *testing.T) {
+	resources := &sharedResources{}
+
+	_, err := resources.acquire(sharedResourcesOpts{
+		writeBufferManagerSize:        1 << 30,
+		writeBufferManagerCostToCache: true,
+		useSharedWriteBufferManager:   true,
+	})
+	require.ErrorIs(t, err, errCostToCacheWithoutSharedBlockCache)
+
+	acquired, err := resources.acquire(sharedResourcesOpts{useSharedBlockCache: true, useSharedWriteBufferManager: true})
+	require.NoError(t, err)
+	require.Nil(t, acquired.blockCache)
+	require.Nil(t, acquired.writeBufferManager)
+}
+
+func TestSharedResourcesAcquireAndRelease(t *testing.T) {
+	resources := &sharedResources{}
+	opts := sharedResourcesOpts{
+		blockCacheSize:              1 << 20,
+		writeBufferManagerSize:      1 << 20,
+		useSharedBlockCache:         true,
+		useSharedWriteBufferManager: true,
+	}
+
+	acquired1, err := resources.acquire(opts)
+	require.NoError(t, err)
+	require.NotNil(t, acquired1.blockCache)
+	require.NotNil(t, acquired1.writeBufferManager)
+
+	// databases share resources
+	acquired2, err := resources.acquire(opts)
+	require.NoError(t, err)
+	require.Same(t, acquired1.blockCache, acquired2.blockCache)
+	require.Same(t, acquired1.writeBufferManager, acquired2.writeBufferManager)
+
+	// database can opt out of shared resources
+	optOut := opts
+	optOut.useSharedBlockCache = false
+	optOut.useSharedWriteBufferManager = false
+	acquired3, err := resources.acquire(optOut)
+	require.NoError(t, err)
+	require.Nil(t, acquired3.blockCache)
+	require.Nil(t, acquired3.writeBufferManager)
+
+	// all databases should request resources of equal size
+	invalidOpts := opts
+	invalidOpts.blockCacheSize = 2 << 20
+	_, err = resources.acquire(invalidOpts)
+	require.Error(t, err)
+	invalidOpts = opts
+	invalidOpts.blockCacheOpts.cacheType = hyperClockCacheType
+	_, err = resources.acquire(invalidOpts)
+	require.Error(t, err)
+	invalidOpts = opts
+	invalidOpts.writeBufferManagerAllowStall = true
+	_, err = resources.acquire(invalidOpts)
+	require.Error(t, err)
+
+	usage := resources.usage()
+	require.True(t, usage.blockCacheEnabled)
+	require.Equal(t, uint64(1<<20), usage.blockCacheCapacity)
+	require.True(t, usage.writeBufferManagerEnabled)
+	require.Equal(t, 1<<20, usage.writeBufferManagerBufferSize)
+
+	// resources are destroyed when the last database releases them
+	resources.release(acquired3)
+	resources.release(acquired1)
+	require.NotNil(t, resources.blockCache)
+	require.NotNil(t, resources.writeBufferManager)
+	resources.release(acquired2)
+	require.Nil(t, resources.blockCache)
+	require.Nil(t, resources.writeBufferManager)
+	require.Equal(t, sharedResourcesUsage{}, resources.usage())
+}
+
+func TestSharedResourcesCostToCache(t *testing.T) {
+	resources := &sharedResources{}
+
+	// block cache is created for write buffer manager even if database doesn't use it
+	acquired, err := resources.acquire(sharedResourcesOpts{
+		blockCacheSize:                1 << 20,
+		writeBufferManagerSize:        1 << 20,
+		writeBufferManagerCostToCache: true,
+		useSharedBlockCache:           false,
+		useSharedWriteBufferManager:   true,
+	})
+	require.NoError(t, err)
+	require.Nil(t, acquired.blockCache)
+	require.NotNil(t, acquired.writeBufferManager)
+	require.True(t, acquired.writeBufferManager.CostToCache())
+	require.NotNil(t, resources.blockCache)
+
+	resources.release(acquired)
+	require.Nil(t, resources.blockCache)
+	require.Nil(t, resources.writeBufferManager)
+}
+
+func TestOpenRocksdbWithSharedResources(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	appOpts := newMockAppOptions(map[string]interface{}{
+		enableMetricsOptName:                true,
+		sharedBlockCacheSizeOptName:         1 << 20,
+		sharedWriteBufferManagerSizeOptName: 1 << 20,
+	})
+	registry := stdprometheus.NewRegistry()
+	metricsConfig := MetricsConfig{Registerer: registry}
+
+	db1, err := openRocksdb(dir, "application", appOpts, metricsConfig)
+	require.NoError(t, err)
+	db2, err := openRocksdb(dir, "blockstore", appOpts, metricsConfig)
+	require.NoError(t, err)
+
+	capacity1, ok := db1.db.GetIntProperty("rocksdb.block-cache-capacity")
+	require.True(t, ok)
+	require.Equal(t, uint64(1<<20), capacity1)
+	capacity2, ok := db2.db.GetIntProperty("rocksdb.block-cache-capacity")
+	require.True(t, ok)
+	require.Equal(t, uint64(1<<20), capacity2)
+
+	expected := `
+# HELP rocksdb_v2_shared_block_cache_capacity capacity of block cache shared by databases
+# TYPE rocksdb_v2_shared_block_cache_capacity gauge
+rocksdb_v2_shared_block_cache_capacity 1.048576e+06
+# HELP rocksdb_v2_shared_resource_in_use 1 if database uses shared resource, otherwise 0
+# TYPE rocksdb_v2_shared_resource_in_use gauge
+rocksdb_v2_shared_resource_in_use{db_name="application",resource="block_cache"} 1
+rocksdb_v2_shared_resource_in_use{db_name="application",resource="write_buffer_manager"} 1
+rocksdb_v2_shared_resource_in_use{db_name="blockstore",resource="block_cache"} 1
+rocksdb_v2_shared_resource_in_use{db_name="blockstore",resource="write_buffer_manager"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(
+		registry,
+		strings.NewReader(expected),
+		"rocksdb_v2_shared_block_cache_capacity",
+		"rocksdb_v2_shared_resource_in_use",
+	))
+
+	require.NoError(t, db1.Close())
+	require.NoError(t, db2.Close())
+	require.Nil(t, processSharedResources.blockCache)
+	require.Nil(t, processSharedResources.writeBufferManager)
+}
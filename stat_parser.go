@@ -20,29 +20,42 @@ type stat struct {
 	props map[string]string
 }
 
-// parseSerializedStats parses serialisedStats into map of stat objects
+// parseSerializedStats parses serialisedStats into map of stat objects, it fails if any stat is invalid
 // example of serializedStats:
 // rocksdb.block.cache.miss COUNT : 5
 // rocksdb.compaction.times.micros P50 : 21112 P95 : 21112 P99 : 21112 P100 : 21112 COUNT : 1 SUM : 21112
 func parseSerializedStats(serializedStats string) (map[string]*stat, error) {
+	stats, errs := parseSerializedStatsBestEffort(serializedStats)
+	if len(errs) != 0 {
+		return nil, errs[0]
+	}
+
+	return stats, nil
+}
+
+// parseSerializedStatsBestEffort parses serialisedStats into map of stat objects,
+// invalid stats are skipped and corresponding errors are returned
+func parseSerializedStatsBestEffort(serializedStats string) (map[string]*stat, []error) {
 	stats := make(map[string]*stat, 0)
 
 	serializedStatList := strings.Split(serializedStats, "\n")
 	if len(serializedStatList) == 0 {
-		return nil, errors.New("serializedStats is empty")
+		return nil, []error{errors.New("serializedStats is empty")}
 	}
 	serializedStatList = serializedStatList[:len(serializedStatList)-1]
 	// iterate over stats line by line
+	var errs []error
 	for _, serializedStat := range serializedStatList {
 		stat, err := parseSerializedStat(serializedStat)
 		if err != nil {
-			return nil, err
+			errs = append(errs, err)
+			continue
 		}
 
 		stats[stat.name] = stat
 	}
 
-	return stats, nil
+	return stats, errs
 }
 
 // parseSerializedStat parses serialisedStat into stat object
@@ -119,6 +119,31 @@ rocksdb.compaction.times.cpu_micros P50 : 7 P95 : 8 P99 : 9 P100 : 10 COUNT : 11
 	}
 }
 
+func TestParseSerializedStatsBestEffort(t *testing.T) {
+	serializedStats := `rocksdb.block.cache.miss COUNT : 1
+rocksdb.compaction.times.micros P50 : 1 P95 :
+ COUNT : 1
+rocksdb.block.cache.hit COUNT : 2
+`
+
+	actualStatMap, errs := parseSerializedStatsBestEffort(serializedStats)
+	require.Len(t, errs, 2)
+	require.Equal(t, map[string]*stat{
+		"rocksdb.block.cache.miss": {
+			name: "rocksdb.block.cache.miss",
+			props: map[string]string{
+				"COUNT": "1",
+			},
+		},
+		"rocksdb.block.cache.hit": {
+			name: "rocksdb.block.cache.hit",
+			props: map[string]string{
+				"COUNT": "2",
+			},
+		},
+	}, actualStatMap)
+}
+
 func TestValidateTokens(t *testing.T) {
 	for _, tc := range []struct {
 		desc   string
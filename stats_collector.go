@@ -0,0 +1,190 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	statsSubsystem = "stats"
+)
+
+// quantiles maps percentile properties of rocksdb histogram to prometheus quantiles
+var quantiles = map[string]float64{
+	p50:  0.5,
+	p95:  0.95,
+	p99:  0.99,
+	p100: 1,
+}
+
+var invalidMetricNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// registeredStatsCollectors contains stats collectors registered by registerStatsCollector() per MetricsConfig,
+// databases opened with equal configs share stats collector, it's guarded by registeredMetricsMtx
+var registeredStatsCollectors = make(map[metricsKey]*statsCollector)
+
+// statsCollector is a prometheus collector which exposes every rocksdb statistic returned by parseSerializedStats:
+// - tickers (stats with COUNT property only) are exposed as counters
+// - histograms (stats with percentiles) are exposed as summaries
+// so statistics added in new rocksdb versions are reported without code changes
+type statsCollector struct {
+	namespace   string
+	constLabels stdprometheus.Labels
+	// scrapers refreshes statistics of databases which are reported at scrape time
+	scrapers *scrapers
+
+	mtx sync.RWMutex
+	// statMaps contains latest statistics per database name
+	statMaps map[string]map[string]*stat
+}
+
+var _ stdprometheus.Collector = (*statsCollector)(nil)
+
+func newStatsCollector(namespace string, constLabels stdprometheus.Labels, scrapers *scrapers) *statsCollector {
+	return &statsCollector{
+		namespace:   namespace,
+		constLabels: constLabels,
+		scrapers:    scrapers,
+		statMaps:    make(map[string]map[string]*stat),
+	}
+}
+
+// registerStatsCollector registers stats collector in prometheus according to config,
+// if stats collector is already registered for equal config, registered collector is returned
+// scrapers should be the same as used by metrics registered for config
+// NOTE: collector is unchecked, so registry can't detect that it's registered twice, it's prevented by registeredStatsCollectors
+func registerStatsCollector(config MetricsConfig, scrapers *scrapers) (*statsCollector, error) {
+	registeredMetricsMtx.Lock()
+	defer registeredMetricsMtx.Unlock()
+
+	key := config.key()
+	if collector, ok := registeredStatsCollectors[key]; ok {
+		return collector, nil
+	}
+
+	collector := newStatsCollector(config.namespace(), config.ConstLabels, scrapers)
+	if err := config.registerer().Register(collector); err != nil {
+		return nil, err
+	}
+	registeredStatsCollectors[key] = collector
+
+	return collector, nil
+}
+
+// update replaces statistics of the database, they will be exposed on the next scrape
+func (c *statsCollector) update(dbName string, statMap map[string]*stat) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.statMaps[dbName] = statMap
+}
+
+// remove removes statistics of the database, it should be called when database is closed
+func (c *statsCollector) remove(dbName string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	delete(c.statMaps, dbName)
+}
+
+// Describe implements prometheus.Collector.
+// It doesn't send any descriptors, which makes collector unchecked, because set of statistics isn't known in advance.
+func (c *statsCollector) Describe(chan<- *stdprometheus.Desc) {}
+
+// Collect implements prometheus.Collector.
+// Statistics which can't be parsed are skipped.
+func (c *statsCollector) Collect(ch chan<- stdprometheus.Metric) {
+	if c.scrapers != nil {
+		c.scrapers.refresh()
+	}
+
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	for dbName, statMap := range c.statMaps {
+		for _, stat := range statMap {
+			metric, ok := c.statToMetric(dbName, stat)
+			if !ok {
+				continue
+			}
+
+			ch <- metric
+		}
+	}
+}
+
+// statToMetric converts histogram into summary and ticker into counter
+func (c *statsCollector) statToMetric(dbName string, stat *stat) (stdprometheus.Metric, bool) {
+	if _, ok := stat.props[p50]; ok {
+		return c.histogramToSummary(dbName, stat)
+	}
+
+	return c.tickerToCounter(dbName, stat)
+}
+
+func (c *statsCollector) tickerToCounter(dbName string, stat *stat) (stdprometheus.Metric, bool) {
+	value, err := strconv.ParseFloat(stat.props[count], 64)
+	if err != nil {
+		return nil, false
+	}
+
+	desc := c.newDesc(statMetricName(stat.name)+"_total", stat.name)
+	metric, err := stdprometheus.NewConstMetric(desc, stdprometheus.CounterValue, value, dbName)
+	if err != nil {
+		return nil, false
+	}
+
+	return metric, true
+}
+
+func (c *statsCollector) histogramToSummary(dbName string, stat *stat) (stdprometheus.Metric, bool) {
+	histogramCount, err := strconv.ParseFloat(stat.props[count], 64)
+	if err != nil {
+		return nil, false
+	}
+	histogramSum, err := strconv.ParseFloat(stat.props[sum], 64)
+	if err != nil {
+		return nil, false
+	}
+
+	summaryQuantiles := make(map[float64]float64, len(quantiles))
+	for propName, quantile := range quantiles {
+		value, err := strconv.ParseFloat(stat.props[propName], 64)
+		if err != nil {
+			return nil, false
+		}
+		summaryQuantiles[quantile] = value
+	}
+
+	desc := c.newDesc(statMetricName(stat.name), stat.name)
+	metric, err := stdprometheus.NewConstSummary(desc, uint64(histogramCount), histogramSum, summaryQuantiles, dbName)
+	if err != nil {
+		return nil, false
+	}
+
+	return metric, true
+}
+
+func (c *statsCollector) newDesc(name, statName string) *stdprometheus.Desc {
+	return stdprometheus.NewDesc(
+		stdprometheus.BuildFQName(c.namespace, statsSubsystem, name),
+		fmt.Sprintf("rocksdb %v statistic", statName),
+		[]string{dbNameMetricLabelName},
+		c.constLabels,
+	)
+}
+
+// statMetricName converts rocksdb stat name into valid prometheus metric name, for example:
+// rocksdb.block.cache.miss -> block_cache_miss
+func statMetricName(statName string) string {
+	name := strings.TrimPrefix(statName, "rocksdb.")
+	return invalidMetricNameChars.ReplaceAllString(name, "_")
+}
@@ -0,0 +1,49 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsCollector(t *testing.T) {
+	statMap, err := parseSerializedStats(`rocksdb.block.cache.miss COUNT : 1
+rocksdb.compaction.times.micros P50 : 1 P95 : 2 P99 : 3 P100 : 4 COUNT : 5 SUM : 6
+rocksdb.unknown.stat COUNT : unknown
+`)
+	require.NoError(t, err)
+
+	collector := newStatsCollector("rocksdb_v2", nil, nil)
+	collector.update("application", statMap)
+
+	expected := `
+# HELP rocksdb_v2_stats_block_cache_miss_total rocksdb rocksdb.block.cache.miss statistic
+# TYPE rocksdb_v2_stats_block_cache_miss_total counter
+rocksdb_v2_stats_block_cache_miss_total{db_name="application"} 1
+# HELP rocksdb_v2_stats_compaction_times_micros rocksdb rocksdb.compaction.times.micros statistic
+# TYPE rocksdb_v2_stats_compaction_times_micros summary
+rocksdb_v2_stats_compaction_times_micros{db_name="application",quantile="0.5"} 1
+rocksdb_v2_stats_compaction_times_micros{db_name="application",quantile="0.95"} 2
+rocksdb_v2_stats_compaction_times_micros{db_name="application",quantile="0.99"} 3
+rocksdb_v2_stats_compaction_times_micros{db_name="application",quantile="1"} 4
+rocksdb_v2_stats_compaction_times_micros_sum{db_name="application"} 6
+rocksdb_v2_stats_compaction_times_micros_count{db_name="application"} 5
+`
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+
+	// statistics of closed database aren't reported anymore
+	collector.remove("application")
+	require.Equal(t, 0, testutil.CollectAndCount(collector))
+}
+
+func TestStatMetricName(t *testing.T) {
+	require.Equal(t, "block_cache_miss", statMetricName("rocksdb.block.cache.miss"))
+	require.Equal(t, "compaction_times_cpu_micros", statMetricName("rocksdb.compaction.times.cpu_micros"))
+	require.Equal(t, "blobdb_num_put", statMetricName("rocksdb.blobdb.num.put"))
+	require.Equal(t, "db_write_wal_time_micros", statMetricName("rocksdb.db-write-wal-time.micros"))
+}
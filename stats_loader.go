@@ -5,6 +5,7 @@ package opendb
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 )
 
@@ -26,14 +27,22 @@ type statLoader struct {
 	// #2 case will be cast into float64Histogram
 	statMap map[string]*stat
 
+	// strict makes load fail if any stat can't be loaded, it's intended to use in tests,
+	// otherwise stats which can't be loaded are left zero and reported as failed
+	strict bool
+
 	// NOTE: some methods accumulate errors instead of returning them, these methods are private and not intended to use outside
 	errors []error
+	// failedStats contains names of stats which can't be loaded
+	failedStats map[string]struct{}
 }
 
-func newStatLoader(statMap map[string]*stat) *statLoader {
+func newStatLoader(statMap map[string]*stat, strict bool) *statLoader {
 	return &statLoader{
-		statMap: statMap,
-		errors:  make([]error, 0),
+		statMap:     statMap,
+		strict:      strict,
+		errors:      make([]error, 0),
+		failedStats: make(map[string]struct{}),
 	}
 }
 
@@ -75,8 +84,21 @@ type stats struct {
 	BlockCacheDataHit           int64
 	BlockCacheDataBytesInsert   int64
 
+	// Total background I/O, it isn't limited to bytes requested from or throttled by rate limiter
 	CompactReadBytes  int64 // Bytes read during compaction
 	CompactWriteBytes int64 // Bytes written during compaction
+	FlushWriteBytes   int64 // Bytes written during flush
+
+	// Number of refill intervals where rate limiter's bytes are fully consumed.
+	// It's the only rate limiter statistic, requested and throttled bytes aren't reported by rocksdb statistics.
+	RateLimiterDrains int64
+
+	// Number of times WAL sync is done
+	WALFileSynced int64
+	// Number of bytes written to WAL
+	WALFileBytes int64
+	// Time spent syncing WAL files
+	WALFileSyncMicros *float64Histogram
 
 	CompactionTimesMicros      *float64Histogram
 	CompactionTimesCPUMicros   *float64Histogram
@@ -143,6 +165,22 @@ type stats struct {
 
 	// Time spent flushing memtable to disk
 	FlushMicros *float64Histogram
+
+	// # of bytes written to and read from blob files
+	BlobFileBytesWritten int64
+	BlobFileBytesRead    int64
+	// # of keys and bytes relocated to new blob files by garbage collection
+	BlobGCNumKeysRelocated int64
+	BlobGCBytesRelocated   int64
+
+	// failed contains names of stats which can't be loaded, their values are left zero
+	failed map[string]struct{}
+}
+
+// loaded returns true if stat is loaded, stats which can't be loaded shouldn't be reported as zero
+func (s *stats) loaded(statName string) bool {
+	_, failed := s.failed[statName]
+	return !failed
 }
 
 type float64Histogram struct {
@@ -182,6 +220,11 @@ func (l *statLoader) load() (*stats, error) {
 		BlockCacheDataBytesInsert:   l.getInt64StatValue("rocksdb.block.cache.data.bytes.insert", count),
 		CompactReadBytes:            l.getInt64StatValue("rocksdb.compact.read.bytes", count),
 		CompactWriteBytes:           l.getInt64StatValue("rocksdb.compact.write.bytes", count),
+		FlushWriteBytes:             l.getInt64StatValue("rocksdb.flush.write.bytes", count),
+		RateLimiterDrains:           l.getInt64StatValue("rocksdb.number.rate_limiter.drains", count),
+		WALFileSynced:               l.getInt64StatValue("rocksdb.wal.synced", count),
+		WALFileBytes:                l.getInt64StatValue("rocksdb.wal.bytes", count),
+		WALFileSyncMicros:           l.getFloat64HistogramStatValue("rocksdb.wal.file.sync.micros"),
 		CompactionTimesMicros:       l.getFloat64HistogramStatValue("rocksdb.compaction.times.micros"),
 		CompactionTimesCPUMicros:    l.getFloat64HistogramStatValue("rocksdb.compaction.times.cpu_micros"),
 		NumFilesInSingleCompaction:  l.getFloat64HistogramStatValue("rocksdb.numfiles.in.singlecompaction"),
@@ -211,19 +254,36 @@ func (l *statLoader) load() (*stats, error) {
 		BytesPerWrite:               l.getFloat64HistogramStatValue("rocksdb.bytes.per.write"),
 		BytesPerMultiget:            l.getFloat64HistogramStatValue("rocksdb.bytes.per.multiget"),
 		FlushMicros:                 l.getFloat64HistogramStatValue("rocksdb.db.flush.micros"),
+		BlobFileBytesWritten:        l.getInt64StatValue("rocksdb.blobdb.blob.file.bytes.written", count),
+		BlobFileBytesRead:           l.getInt64StatValue("rocksdb.blobdb.blob.file.bytes.read", count),
+		BlobGCNumKeysRelocated:      l.getInt64StatValue("rocksdb.blobdb.gc.num.keys.relocated", count),
+		BlobGCBytesRelocated:        l.getInt64StatValue("rocksdb.blobdb.gc.bytes.relocated", count),
+		failed:                      l.failedStats,
 	}
 
 	err := l.error()
-	if err != nil {
+	if l.strict && err != nil {
 		return nil, err
 	}
 
 	return stats, nil
 }
 
-// getFloat64HistogramStatValue converts stat object into float64Histogram
+// failures returns sorted names of stats which can't be loaded
+func (l *statLoader) failures() []string {
+	failures := make([]string, 0, len(l.failedStats))
+	for statName := range l.failedStats {
+		failures = append(failures, statName)
+	}
+	sort.Strings(failures)
+
+	return failures
+}
+
+// getFloat64HistogramStatValue converts stat object into float64Histogram,
+// it returns nil if any property of stat object can't be loaded
 func (l *statLoader) getFloat64HistogramStatValue(statName string) *float64Histogram {
-	return &float64Histogram{
+	histogram := &float64Histogram{
 		Sum:   l.getFloat64StatValue(statName, sum),
 		Count: l.getFloat64StatValue(statName, count),
 		P50:   l.getFloat64StatValue(statName, p50),
@@ -231,6 +291,11 @@ func (l *statLoader) getFloat64HistogramStatValue(statName string) *float64Histo
 		P99:   l.getFloat64StatValue(statName, p99),
 		P100:  l.getFloat64StatValue(statName, p100),
 	}
+	if _, failed := l.failedStats[statName]; failed {
+		return nil
+	}
+
+	return histogram
 }
 
 // getInt64StatValue converts property of stat object into int64
@@ -244,6 +309,7 @@ func (l *statLoader) getInt64StatValue(statName, propName string) int64 {
 	intVal, err := strconv.ParseInt(stringVal, 10, 64)
 	if err != nil {
 		l.errors = append(l.errors, fmt.Errorf("can't parse int: %v", err))
+		l.failedStats[statName] = struct{}{}
 		return 0
 	}
 
@@ -261,6 +327,7 @@ func (l *statLoader) getFloat64StatValue(statName, propName string) float64 {
 	floatVal, err := strconv.ParseFloat(stringVal, 64)
 	if err != nil {
 		l.errors = append(l.errors, fmt.Errorf("can't parse float: %v", err))
+		l.failedStats[statName] = struct{}{}
 		return 0
 	}
 
@@ -272,11 +339,13 @@ func (l *statLoader) getStatValue(statName, propName string) string {
 	stat, ok := l.statMap[statName]
 	if !ok {
 		l.errors = append(l.errors, fmt.Errorf("stat %v doesn't exist", statName))
+		l.failedStats[statName] = struct{}{}
 		return ""
 	}
 	prop, ok := stat.props[propName]
 	if !ok {
 		l.errors = append(l.errors, fmt.Errorf("stat %v doesn't have %v property", statName, propName))
+		l.failedStats[statName] = struct{}{}
 		return ""
 	}
 
@@ -44,6 +44,11 @@ func TestStatsLoader(t *testing.T) {
 		"rocksdb.block.cache.data.bytes.insert":   &defaultStat,
 		"rocksdb.compact.read.bytes":              &defaultStat,
 		"rocksdb.compact.write.bytes":             &defaultStat,
+		"rocksdb.flush.write.bytes":               &defaultStat,
+		"rocksdb.number.rate_limiter.drains":      &defaultStat,
+		"rocksdb.wal.synced":                      &defaultStat,
+		"rocksdb.wal.bytes":                       &defaultStat,
+		"rocksdb.wal.file.sync.micros":            &defaultHistogramStat,
 		"rocksdb.compaction.times.micros":         &defaultHistogramStat,
 		"rocksdb.compaction.times.cpu_micros":     &defaultHistogramStat,
 		"rocksdb.numfiles.in.singlecompaction":    &defaultHistogramStat,
@@ -73,9 +78,13 @@ func TestStatsLoader(t *testing.T) {
 		"rocksdb.bytes.per.write":                 &defaultHistogramStat,
 		"rocksdb.bytes.per.multiget":              &defaultHistogramStat,
 		"rocksdb.db.flush.micros":                 &defaultHistogramStat,
+		"rocksdb.blobdb.blob.file.bytes.written":  &defaultStat,
+		"rocksdb.blobdb.blob.file.bytes.read":     &defaultStat,
+		"rocksdb.blobdb.gc.num.keys.relocated":    &defaultStat,
+		"rocksdb.blobdb.gc.bytes.relocated":       &defaultStat,
 	}
 
-	statLoader := newStatLoader(defaultStatMap)
+	statLoader := newStatLoader(defaultStatMap, true)
 	stats, err := statLoader.load()
 	require.NoError(t, err)
 
@@ -88,3 +97,46 @@ func TestStatsLoader(t *testing.T) {
 	require.Equal(t, stats.CompactionTimesMicros.Count, float64(5))
 	require.Equal(t, stats.CompactionTimesMicros.Sum, float64(6))
 }
+
+func TestStatsLoaderBestEffort(t *testing.T) {
+	statMap := map[string]*stat{
+		"rocksdb.number.keys.written": {
+			name:  "rocksdb.number.keys.written",
+			props: map[string]string{"COUNT": "1"},
+		},
+		"rocksdb.number.keys.read": {
+			name:  "rocksdb.number.keys.read",
+			props: map[string]string{"COUNT": "invalid"},
+		},
+		"rocksdb.db.get.micros": {
+			name:  "rocksdb.db.get.micros",
+			props: map[string]string{"P50": "1", "P95": "2", "P99": "3", "P100": "4", "COUNT": "5", "SUM": "6"},
+		},
+		"rocksdb.db.write.micros": {
+			name:  "rocksdb.db.write.micros",
+			props: map[string]string{"COUNT": "5", "SUM": "6"},
+		},
+	}
+
+	_, err := newStatLoader(statMap, true).load()
+	require.Error(t, err)
+
+	statLoader := newStatLoader(statMap, false)
+	stats, err := statLoader.load()
+	require.NoError(t, err)
+
+	require.Equal(t, int64(1), stats.NumberKeysWritten)
+	require.Equal(t, int64(0), stats.NumberKeysRead)
+	require.Equal(t, &float64Histogram{Sum: 6, Count: 5, P50: 1, P95: 2, P99: 3, P100: 4}, stats.DBGetMicros)
+	require.Nil(t, stats.DBWriteMicros)
+	require.True(t, stats.loaded("rocksdb.number.keys.written"))
+	require.False(t, stats.loaded("rocksdb.number.keys.read"))
+
+	failures := statLoader.failures()
+	require.Contains(t, failures, "rocksdb.number.keys.read")
+	require.Contains(t, failures, "rocksdb.db.write.micros")
+	require.Contains(t, failures, "rocksdb.compaction.times.micros")
+	require.NotContains(t, failures, "rocksdb.number.keys.written")
+	require.NotContains(t, failures, "rocksdb.db.get.micros")
+	require.IsIncreasing(t, failures)
+}
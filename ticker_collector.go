@@ -0,0 +1,219 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"sync"
+	"time"
+
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// totalMetricSuffix is a suffix of counter names required by prometheus naming conventions
+	totalMetricSuffix = "total"
+	rateMetricSuffix  = "rate"
+)
+
+// tickerMetric describes rocksdb ticker which is reported as prometheus counter
+type tickerMetric struct {
+	desc *stdprometheus.Desc
+	// rateDesc describes per second rate of ticker over the last report interval
+	rateDesc *stdprometheus.Desc
+	// statName is a name of rocksdb statistic, ticker isn't reported if the statistic can't be loaded
+	statName string
+	// ticker returns ticker from predefined statistics
+	ticker func(stats *stats) int64
+}
+
+// tickerSnapshot contains statistics loaded at specific time
+type tickerSnapshot struct {
+	stats    *stats
+	loadedAt time.Time
+}
+
+// dbTickers contains two latest snapshots of database statistics, they are needed to calculate rates
+type dbTickers struct {
+	previous *tickerSnapshot
+	latest   *tickerSnapshot
+	// reportRates enables reporting of per second rates in addition to counters
+	reportRates bool
+}
+
+// tickerCollector is a prometheus collector which exposes rocksdb tickers as counters,
+// tickers are cumulative since database is opened, so prometheus rate() handles database restarts as counter resets.
+// Optionally it exposes per second rates calculated between report intervals as gauges,
+// they are intended for push-based setups which can't calculate rates themselves.
+type tickerCollector struct {
+	tickers []tickerMetric
+
+	mtx sync.RWMutex
+	// dbs contains latest statistics per database name
+	dbs map[string]*dbTickers
+}
+
+var _ stdprometheus.Collector = (*tickerCollector)(nil)
+
+func newTickerCollector(namespace string, constLabels stdprometheus.Labels) *tickerCollector {
+	newTicker := func(subsystem, name, statName, help string, ticker func(stats *stats) int64) tickerMetric {
+		return tickerMetric{
+			desc: stdprometheus.NewDesc(
+				stdprometheus.BuildFQName(namespace, subsystem, name+"_"+totalMetricSuffix),
+				help,
+				[]string{dbNameMetricLabelName},
+				constLabels,
+			),
+			rateDesc: stdprometheus.NewDesc(
+				stdprometheus.BuildFQName(namespace, subsystem, name+"_"+rateMetricSuffix),
+				"per second rate of "+name+" over the last report interval",
+				[]string{dbNameMetricLabelName},
+				constLabels,
+			),
+			statName: statName,
+			ticker:   ticker,
+		}
+	}
+
+	return &tickerCollector{
+		tickers: []tickerMetric{
+			// Keys
+			newTicker("key", "number_keys_written", "rocksdb.number.keys.written", "number of keys written to the database via Put and Write calls", func(stats *stats) int64 { return stats.NumberKeysWritten }),
+			newTicker("key", "number_keys_read", "rocksdb.number.keys.read", "number of keys read from the database", func(stats *stats) int64 { return stats.NumberKeysRead }),
+			newTicker("key", "number_keys_updated", "rocksdb.number.keys.updated", "number of keys updated, if inplace update is enabled", func(stats *stats) int64 { return stats.NumberKeysUpdated }),
+
+			// Files
+			newTicker("file", "number_file_opens", "rocksdb.no.file.opens", "number of file opens", func(stats *stats) int64 { return stats.NumberFileOpens }),
+			newTicker("file", "number_file_errors", "rocksdb.no.file.errors", "number of file open errors", func(stats *stats) int64 { return stats.NumberFileErrors }),
+
+			// Cache
+			newTicker("cache", "block_cache_miss", "rocksdb.block.cache.miss", "block_cache_miss == block_cache_index_miss + block_cache_filter_miss + block_cache_data_miss", func(stats *stats) int64 { return stats.BlockCacheMiss }),
+			newTicker("cache", "block_cache_hit", "rocksdb.block.cache.hit", "block_cache_hit == block_cache_index_hit + block_cache_filter_hit + block_cache_data_hit", func(stats *stats) int64 { return stats.BlockCacheHit }),
+			newTicker("cache", "block_cache_add", "rocksdb.block.cache.add", "number of blocks added to block cache", func(stats *stats) int64 { return stats.BlockCacheAdd }),
+			newTicker("cache", "block_cache_add_failures", "rocksdb.block.cache.add.failures", "number of failures when adding blocks to block cache", func(stats *stats) int64 { return stats.BlockCacheAddFailures }),
+
+			// Detailed Cache
+			newTicker("detailed_cache", "block_cache_index_miss", "rocksdb.block.cache.index.miss", "number of times cache miss when accessing index block from block cache", func(stats *stats) int64 { return stats.BlockCacheIndexMiss }),
+			newTicker("detailed_cache", "block_cache_index_hit", "rocksdb.block.cache.index.hit", "number of times cache hit when accessing index block from block cache", func(stats *stats) int64 { return stats.BlockCacheIndexHit }),
+			newTicker("detailed_cache", "block_cache_index_bytes_insert", "rocksdb.block.cache.index.bytes.insert", "number of bytes of index blocks inserted into block cache", func(stats *stats) int64 { return stats.BlockCacheIndexBytesInsert }),
+			newTicker("detailed_cache", "block_cache_filter_miss", "rocksdb.block.cache.filter.miss", "number of times cache miss when accessing filter block from block cache", func(stats *stats) int64 { return stats.BlockCacheFilterMiss }),
+			newTicker("detailed_cache", "block_cache_filter_hit", "rocksdb.block.cache.filter.hit", "number of times cache hit when accessing filter block from block cache", func(stats *stats) int64 { return stats.BlockCacheFilterHit }),
+			newTicker("detailed_cache", "block_cache_filter_bytes_insert", "rocksdb.block.cache.filter.bytes.insert", "number of bytes of filter blocks inserted into block cache", func(stats *stats) int64 { return stats.BlockCacheFilterBytesInsert }),
+			newTicker("detailed_cache", "block_cache_data_miss", "rocksdb.block.cache.data.miss", "number of times cache miss when accessing data block from block cache", func(stats *stats) int64 { return stats.BlockCacheDataMiss }),
+			newTicker("detailed_cache", "block_cache_data_hit", "rocksdb.block.cache.data.hit", "number of times cache hit when accessing data block from block cache", func(stats *stats) int64 { return stats.BlockCacheDataHit }),
+			newTicker("detailed_cache", "block_cache_data_bytes_insert", "rocksdb.block.cache.data.bytes.insert", "number of bytes of data blocks inserted into block cache", func(stats *stats) int64 { return stats.BlockCacheDataBytesInsert }),
+
+			// Write Stall
+			newTicker("stall", "stall_micros", "rocksdb.stall.micros", "Writer has to wait for compaction or flush to finish.", func(stats *stats) int64 { return stats.StallMicros }),
+
+			// Bloom Filter
+			newTicker("filter", "bloom_filter_useful", "rocksdb.bloom.filter.useful", "number of times bloom filter has avoided file reads, i.e., negatives.", func(stats *stats) int64 { return stats.BloomFilterUseful }),
+			newTicker("filter", "bloom_filter_full_positive", "rocksdb.bloom.filter.full.positive", "number of times bloom FullFilter has not avoided the reads.", func(stats *stats) int64 { return stats.BloomFilterFullPositive }),
+			newTicker("filter", "bloom_filter_full_true_positive", "rocksdb.bloom.filter.full.true.positive", "number of times bloom FullFilter has not avoided the reads and data actually exist.", func(stats *stats) int64 { return stats.BloomFilterFullTruePositive }),
+
+			// LSM Tree Stats
+			newTicker("lsm", "last_level_read_bytes", "rocksdb.last.level.read.bytes", "number of bytes read from the last level", func(stats *stats) int64 { return stats.LastLevelReadBytes }),
+			newTicker("lsm", "last_level_read_count", "rocksdb.last.level.read.count", "number of reads from the last level", func(stats *stats) int64 { return stats.LastLevelReadCount }),
+			newTicker("lsm", "non_last_level_read_bytes", "rocksdb.non.last.level.read.bytes", "number of bytes read from levels other than the last one", func(stats *stats) int64 { return stats.NonLastLevelReadBytes }),
+			newTicker("lsm", "non_last_level_read_count", "rocksdb.non.last.level.read.count", "number of reads from levels other than the last one", func(stats *stats) int64 { return stats.NonLastLevelReadCount }),
+			newTicker("lsm", "get_hit_l0", "rocksdb.l0.hit", "number of Get() queries served by L0", func(stats *stats) int64 { return stats.GetHitL0 }),
+			newTicker("lsm", "get_hit_l1", "rocksdb.l1.hit", "number of Get() queries served by L1", func(stats *stats) int64 { return stats.GetHitL1 }),
+			newTicker("lsm", "get_hit_l2_and_up", "rocksdb.l2andup.hit", "number of Get() queries served by L2 and up", func(stats *stats) int64 { return stats.GetHitL2AndUp }),
+
+			// Background I/O, it includes I/O which isn't throttled by rate limiter, for example compaction reads
+			newTicker("compaction", "compact_read_bytes", "rocksdb.compact.read.bytes", "number of bytes read during compaction", func(stats *stats) int64 { return stats.CompactReadBytes }),
+			newTicker("compaction", "compact_write_bytes", "rocksdb.compact.write.bytes", "number of bytes written during compaction", func(stats *stats) int64 { return stats.CompactWriteBytes }),
+			newTicker("flush", "flush_write_bytes", "rocksdb.flush.write.bytes", "number of bytes written during flush", func(stats *stats) int64 { return stats.FlushWriteBytes }),
+			newTicker("rate_limiter", "rate_limiter_drains", "rocksdb.number.rate_limiter.drains", "number of refill intervals where rate limiter's bytes are fully consumed, i.e. background writes have to wait", func(stats *stats) int64 { return stats.RateLimiterDrains }),
+
+			// WAL
+			newTicker("wal", "wal_file_synced", "rocksdb.wal.synced", "number of times WAL sync is done", func(stats *stats) int64 { return stats.WALFileSynced }),
+			newTicker("wal", "wal_file_bytes", "rocksdb.wal.bytes", "number of bytes written to WAL", func(stats *stats) int64 { return stats.WALFileBytes }),
+
+			// Blob Files
+			newTicker("blob", "blob_file_bytes_written", "rocksdb.blobdb.blob.file.bytes.written", "number of bytes written to blob files", func(stats *stats) int64 { return stats.BlobFileBytesWritten }),
+			newTicker("blob", "blob_file_bytes_read", "rocksdb.blobdb.blob.file.bytes.read", "number of bytes read from blob files", func(stats *stats) int64 { return stats.BlobFileBytesRead }),
+			newTicker("blob", "gc_num_keys_relocated", "rocksdb.blobdb.gc.num.keys.relocated", "number of keys relocated to new blob files by garbage collection", func(stats *stats) int64 { return stats.BlobGCNumKeysRelocated }),
+			newTicker("blob", "gc_bytes_relocated", "rocksdb.blobdb.gc.bytes.relocated", "number of bytes relocated to new blob files by garbage collection", func(stats *stats) int64 { return stats.BlobGCBytesRelocated }),
+		},
+		dbs: make(map[string]*dbTickers),
+	}
+}
+
+// update adds statistics of the database loaded at loadedAt, they will be exposed on the next scrape
+func (c *tickerCollector) update(dbName string, stats *stats, loadedAt time.Time, reportRates bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	tickers, ok := c.dbs[dbName]
+	if !ok {
+		tickers = &dbTickers{}
+		c.dbs[dbName] = tickers
+	}
+
+	tickers.previous = tickers.latest
+	tickers.latest = &tickerSnapshot{
+		stats:    stats,
+		loadedAt: loadedAt,
+	}
+	tickers.reportRates = reportRates
+}
+
+// remove removes statistics of the database, it should be called when database is closed
+func (c *tickerCollector) remove(dbName string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	delete(c.dbs, dbName)
+}
+
+// Describe implements prometheus.Collector.
+func (c *tickerCollector) Describe(ch chan<- *stdprometheus.Desc) {
+	for _, ticker := range c.tickers {
+		ch <- ticker.desc
+		ch <- ticker.rateDesc
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *tickerCollector) Collect(ch chan<- stdprometheus.Metric) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	for dbName, tickers := range c.dbs {
+		for _, ticker := range c.tickers {
+			// failed statistic would be reported as zero which looks like a counter reset,
+			// so it's skipped, its failures are reported by load failures counter
+			if !tickers.latest.stats.loaded(ticker.statName) {
+				continue
+			}
+			value := ticker.ticker(tickers.latest.stats)
+			ch <- stdprometheus.MustNewConstMetric(ticker.desc, stdprometheus.CounterValue, float64(value), dbName)
+
+			if !tickers.reportRates || tickers.previous == nil || !tickers.previous.stats.loaded(ticker.statName) {
+				continue
+			}
+			rate, ok := tickerRate(ticker.ticker(tickers.previous.stats), tickers.previous.loadedAt, value, tickers.latest.loadedAt)
+			if !ok {
+				continue
+			}
+			ch <- stdprometheus.MustNewConstMetric(ticker.rateDesc, stdprometheus.GaugeValue, rate, dbName)
+		}
+	}
+}
+
+// tickerRate calculates per second rate of ticker between two snapshots,
+// if ticker decreased database was reopened, so ticker is counted from zero
+func tickerRate(previous int64, previousLoadedAt time.Time, latest int64, latestLoadedAt time.Time) (float64, bool) {
+	interval := latestLoadedAt.Sub(previousLoadedAt).Seconds()
+	if interval <= 0 {
+		return 0, false
+	}
+
+	delta := latest - previous
+	if delta < 0 {
+		delta = latest
+	}
+
+	return float64(delta) / interval, true
+}
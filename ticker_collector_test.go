@@ -0,0 +1,132 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTickerCollector(t *testing.T) {
+	collector := newTickerCollector("rocksdb_v2", stdprometheus.Labels{"chain_id": "kava_2222-10"})
+	loadedAt := time.Now()
+
+	collector.update("application", &stats{NumberKeysRead: 10}, loadedAt, true)
+	expected := `
+# HELP rocksdb_v2_key_number_keys_read_total number of keys read from the database
+# TYPE rocksdb_v2_key_number_keys_read_total counter
+rocksdb_v2_key_number_keys_read_total{chain_id="kava_2222-10",db_name="application"} 10
+`
+	// rate can't be calculated from a single report
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rocksdb_v2_key_number_keys_read_total", "rocksdb_v2_key_number_keys_read_rate"))
+
+	collector.update("application", &stats{NumberKeysRead: 40}, loadedAt.Add(15*time.Second), true)
+	expected = `
+# HELP rocksdb_v2_key_number_keys_read_total number of keys read from the database
+# TYPE rocksdb_v2_key_number_keys_read_total counter
+rocksdb_v2_key_number_keys_read_total{chain_id="kava_2222-10",db_name="application"} 40
+# HELP rocksdb_v2_key_number_keys_read_rate per second rate of number_keys_read over the last report interval
+# TYPE rocksdb_v2_key_number_keys_read_rate gauge
+rocksdb_v2_key_number_keys_read_rate{chain_id="kava_2222-10",db_name="application"} 2
+`
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rocksdb_v2_key_number_keys_read_total", "rocksdb_v2_key_number_keys_read_rate"))
+
+	// rates aren't reported if they are disabled
+	collector.update("application", &stats{NumberKeysRead: 70}, loadedAt.Add(30*time.Second), false)
+	expected = `
+# HELP rocksdb_v2_key_number_keys_read_total number of keys read from the database
+# TYPE rocksdb_v2_key_number_keys_read_total counter
+rocksdb_v2_key_number_keys_read_total{chain_id="kava_2222-10",db_name="application"} 70
+`
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rocksdb_v2_key_number_keys_read_total", "rocksdb_v2_key_number_keys_read_rate"))
+
+	// statistics of closed database aren't reported anymore
+	collector.remove("application")
+	require.Equal(t, 0, testutil.CollectAndCount(collector))
+}
+
+func TestTickerCollectorSkipsFailedStats(t *testing.T) {
+	collector := newTickerCollector("rocksdb_v2", nil)
+	loadedAt := time.Now()
+
+	collector.update("application", &stats{NumberKeysRead: 10, NumberKeysWritten: 20}, loadedAt, true)
+	// failed statistic isn't reported as zero, otherwise it looks like a counter reset
+	collector.update("application", &stats{
+		NumberKeysWritten: 50,
+		failed:            map[string]struct{}{"rocksdb.number.keys.read": {}},
+	}, loadedAt.Add(15*time.Second), true)
+	expected := `
+# HELP rocksdb_v2_key_number_keys_written_total number of keys written to the database via Put and Write calls
+# TYPE rocksdb_v2_key_number_keys_written_total counter
+rocksdb_v2_key_number_keys_written_total{db_name="application"} 50
+# HELP rocksdb_v2_key_number_keys_written_rate per second rate of number_keys_written over the last report interval
+# TYPE rocksdb_v2_key_number_keys_written_rate gauge
+rocksdb_v2_key_number_keys_written_rate{db_name="application"} 2
+`
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rocksdb_v2_key_number_keys_read_total", "rocksdb_v2_key_number_keys_read_rate",
+		"rocksdb_v2_key_number_keys_written_total", "rocksdb_v2_key_number_keys_written_rate"))
+
+	// rate isn't reported if statistic failed in the previous snapshot
+	collector.update("application", &stats{NumberKeysRead: 40, NumberKeysWritten: 80}, loadedAt.Add(30*time.Second), true)
+	expected = `
+# HELP rocksdb_v2_key_number_keys_read_total number of keys read from the database
+# TYPE rocksdb_v2_key_number_keys_read_total counter
+rocksdb_v2_key_number_keys_read_total{db_name="application"} 40
+`
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"rocksdb_v2_key_number_keys_read_total", "rocksdb_v2_key_number_keys_read_rate"))
+}
+
+func TestTickerRate(t *testing.T) {
+	loadedAt := time.Now()
+
+	for _, tc := range []struct {
+		desc             string
+		previous         int64
+		latest           int64
+		interval         time.Duration
+		expectedRate     float64
+		expectedRateIsOK bool
+	}{
+		{
+			desc:             "ticker increased",
+			previous:         10,
+			latest:           40,
+			interval:         15 * time.Second,
+			expectedRate:     2,
+			expectedRateIsOK: true,
+		},
+		{
+			desc:             "database reopened",
+			previous:         100,
+			latest:           30,
+			interval:         15 * time.Second,
+			expectedRate:     2,
+			expectedRateIsOK: true,
+		},
+		{
+			desc:             "empty interval",
+			previous:         10,
+			latest:           40,
+			interval:         0,
+			expectedRate:     0,
+			expectedRateIsOK: false,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			rate, ok := tickerRate(tc.previous, loadedAt, tc.latest, loadedAt.Add(tc.interval))
+			require.Equal(t, tc.expectedRateIsOK, ok)
+			require.Equal(t, tc.expectedRate, rate)
+		})
+	}
+}
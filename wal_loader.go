@@ -0,0 +1,78 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// walFilesStatName is reported as failed stat if WAL files can't be loaded
+	walFilesStatName = "wal_files"
+	// walFileSuffix is a suffix of rocksdb WAL files, for example 000123.log
+	walFileSuffix = ".log"
+	// walArchiveDirName is a subdirectory of WAL directory which contains obsolete WAL files
+	// kept according to wal_ttl_seconds and wal_size_limit_mb
+	walArchiveDirName = "archive"
+)
+
+// walFiles contains number and total size of WAL files,
+// rocksdb C API doesn't expose them, so they are calculated by listing WAL directory
+type walFiles struct {
+	NumFiles  uint64
+	TotalSize uint64
+
+	NumArchivedFiles  uint64
+	TotalArchivedSize uint64
+}
+
+// loadWALFiles lists live and archived WAL files in walDir
+func loadWALFiles(walDir string) (*walFiles, error) {
+	files := &walFiles{}
+
+	var err error
+	files.NumFiles, files.TotalSize, err = listWALFiles(walDir)
+	if err != nil {
+		return nil, err
+	}
+
+	files.NumArchivedFiles, files.TotalArchivedSize, err = listWALFiles(filepath.Join(walDir, walArchiveDirName))
+	// archive directory is created only if obsolete WAL files are kept
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// listWALFiles returns number and total size of WAL files in dir
+func listWALFiles(dir string) (numFiles uint64, totalSize uint64, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), walFileSuffix) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			// WAL file may be deleted or archived after directory is listed
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return 0, 0, err
+		}
+		numFiles++
+		totalSize += uint64(info.Size())
+	}
+
+	return numFiles, totalSize, nil
+}
@@ -0,0 +1,43 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadWALFiles(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	writeFile := func(name string, size int) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), make([]byte, size), 0o644))
+	}
+
+	// archive directory doesn't exist if obsolete WAL files aren't kept
+	writeFile("000010.log", 10)
+	writeFile("000011.log", 20)
+	writeFile("000012.sst", 100)
+	writeFile("LOG", 100)
+	files, err := loadWALFiles(dir)
+	require.NoError(t, err)
+	require.Equal(t, &walFiles{NumFiles: 2, TotalSize: 30}, files)
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, walArchiveDirName), 0o755))
+	writeFile(filepath.Join(walArchiveDirName, "000005.log"), 5)
+	files, err = loadWALFiles(dir)
+	require.NoError(t, err)
+	require.Equal(t, &walFiles{NumFiles: 2, TotalSize: 30, NumArchivedFiles: 1, TotalArchivedSize: 5}, files)
+
+	_, err = loadWALFiles(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}
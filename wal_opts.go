@@ -0,0 +1,113 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/linxGnu/grocksdb"
+)
+
+const (
+	// walDirDBOptName is a directory of write-ahead logs, it allows to put WAL on a separate disk,
+	// WAL is stored in database directory by default
+	walDirDBOptName = "wal_dir"
+	// maxTotalWALSizeDBOptName is a total size of WAL files which forces flush of the oldest memtables,
+	// 0 (default) sets it to 4 * sum of write buffers of all column families
+	maxTotalWALSizeDBOptName = "max_total_wal_size"
+	// walTTLSecondsDBOptName and walSizeLimitMBDBOptName control how long obsolete WAL files are kept in archive
+	walTTLSecondsDBOptName  = "wal_ttl_seconds"
+	walSizeLimitMBDBOptName = "wal_size_limit_mb"
+	// manualWALFlushDBOptName disables flushing WAL buffer after every write, buffer is flushed by sync writes,
+	// so writes which aren't synced may be lost on crash
+	manualWALFlushDBOptName = "manual_wal_flush"
+	// walRecoveryModeDBOptName is a WAL recovery mode: tolerate_corrupted_tail_records, absolute_consistency,
+	// point_in_time (default) or skip_any_corrupted_records
+	walRecoveryModeDBOptName = "wal_recovery_mode"
+	// walCompressionDBOptName is a compression type of WAL records, rocksdb supports only none and zstd
+	walCompressionDBOptName = "wal_compression"
+
+	// disableWALWriteOptName disables WAL for writes which aren't synced, synced writes always use WAL,
+	// because rocksdb doesn't allow synced writes without WAL
+	disableWALWriteOptName = "disable_wal"
+)
+
+// walRecoveryModes maps WAL recovery mode names accepted in appOpts to rocksdb WAL recovery modes
+var walRecoveryModes = map[string]grocksdb.WALRecoveryMode{
+	"tolerate_corrupted_tail_records": grocksdb.TolerateCorruptedTailRecordsRecovery,
+	"absolute_consistency":            grocksdb.AbsoluteConsistencyRecovery,
+	"point_in_time":                   grocksdb.PointInTimeRecovery,
+	"skip_any_corrupted_records":      grocksdb.SkipAnyCorruptedRecordsRecovery,
+}
+
+// parseWALRecoveryMode converts WAL recovery mode name into rocksdb WAL recovery mode
+func parseWALRecoveryMode(name string) (grocksdb.WALRecoveryMode, error) {
+	walRecoveryMode, ok := walRecoveryModes[name]
+	if !ok {
+		names := make([]string, 0, len(walRecoveryModes))
+		for name := range walRecoveryModes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return 0, fmt.Errorf("unknown wal recovery mode: %v, should be one of: %v", name, strings.Join(names, ", "))
+	}
+
+	return walRecoveryMode, nil
+}
+
+// walRecoveryModeNames maps rocksdb WAL recovery modes to their names in rocksdb options string
+var walRecoveryModeNames = map[grocksdb.WALRecoveryMode]string{
+	grocksdb.TolerateCorruptedTailRecordsRecovery: "kTolerateCorruptedTailRecords",
+	grocksdb.AbsoluteConsistencyRecovery:          "kAbsoluteConsistency",
+	grocksdb.PointInTimeRecovery:                  "kPointInTimeRecovery",
+	grocksdb.SkipAnyCorruptedRecordsRecovery:      "kSkipAnyCorruptedRecords",
+}
+
+// formatWALRecoveryMode formats WAL recovery mode as its name in rocksdb options string
+func formatWALRecoveryMode(value optionValue) (string, error) {
+	walRecoveryMode, err := parseWALRecoveryMode(value.stringVal)
+	if err != nil {
+		return "", err
+	}
+
+	return walRecoveryModeNames[walRecoveryMode], nil
+}
+
+func setWALRecoveryMode(dbOpts *grocksdb.Options, value optionValue) error {
+	walRecoveryMode, err := parseWALRecoveryMode(value.stringVal)
+	if err != nil {
+		return err
+	}
+	dbOpts.SetWALRecoveryMode(walRecoveryMode)
+
+	return nil
+}
+
+// validateWALCompression checks that WAL compression is none or zstd, because rocksdb supports only them
+func validateWALCompression(value optionValue) error {
+	walCompression, err := parseCompressionType(value.stringVal)
+	if err != nil {
+		return err
+	}
+	if walCompression != grocksdb.NoCompression && walCompression != grocksdb.ZSTDCompression {
+		return fmt.Errorf("%v isn't supported, rocksdb supports only none and zstd", value.stringVal)
+	}
+
+	return nil
+}
+
+// writeOptsFromAppOpts creates write options of writes which aren't synced
+// it returns error if appOpts contain invalid value
+func writeOptsFromAppOpts(appOpts AppOptions) (*grocksdb.WriteOptions, error) {
+	wo := grocksdb.NewDefaultWriteOptions()
+	if err := applyOptionDefs(wo, appOpts, optionDef.writeSetter); err != nil {
+		wo.Destroy()
+		return nil, err
+	}
+
+	return wo, nil
+}
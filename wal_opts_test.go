@@ -0,0 +1,216 @@
+//go:build rocksdb
+// +build rocksdb
+
+package opendb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/linxGnu/grocksdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWALRecoveryMode(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		walRecoveryMode grocksdb.WALRecoveryMode
+		success         bool
+	}{
+		{name: "tolerate_corrupted_tail_records", walRecoveryMode: grocksdb.TolerateCorruptedTailRecordsRecovery, success: true},
+		{name: "absolute_consistency", walRecoveryMode: grocksdb.AbsoluteConsistencyRecovery, success: true},
+		{name: "point_in_time", walRecoveryMode: grocksdb.PointInTimeRecovery, success: true},
+		{name: "skip_any_corrupted_records", walRecoveryMode: grocksdb.SkipAnyCorruptedRecordsRecovery, success: true},
+		{name: "point-in-time", success: false},
+		{name: "", success: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			walRecoveryMode, err := parseWALRecoveryMode(tc.name)
+			if !tc.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.walRecoveryMode, walRecoveryMode)
+		})
+	}
+}
+
+func TestOverrideWALOpts(t *testing.T) {
+	defaultOpts := newDefaultOptions()
+
+	for _, tc := range []struct {
+		desc            string
+		mockAppOptions  *mockAppOptions
+		maxTotalWALSize uint64
+		walRecoveryMode grocksdb.WALRecoveryMode
+		walCompression  grocksdb.CompressionType
+		manualWALFlush  bool
+		success         bool
+	}{
+		{
+			desc:            "override nothing",
+			mockAppOptions:  newMockAppOptions(map[string]interface{}{}),
+			maxTotalWALSize: defaultOpts.GetMaxTotalWalSize(),
+			walRecoveryMode: defaultOpts.GetWALRecoveryMode(),
+			walCompression:  defaultOpts.GetWALCompression(),
+			manualWALFlush:  defaultOpts.IsManualWALFlush(),
+			success:         true,
+		},
+		{
+			desc: "override wal options",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				maxTotalWALSizeDBOptName: 1 << 30,
+				walTTLSecondsDBOptName:   3600,
+				walSizeLimitMBDBOptName:  1024,
+				manualWALFlushDBOptName:  true,
+				walRecoveryModeDBOptName: "absolute_consistency",
+				walCompressionDBOptName:  "zstd",
+			}),
+			maxTotalWALSize: 1 << 30,
+			walRecoveryMode: grocksdb.AbsoluteConsistencyRecovery,
+			walCompression:  grocksdb.ZSTDCompression,
+			manualWALFlush:  true,
+			success:         true,
+		},
+		{
+			desc: "invalid wal recovery mode",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				walRecoveryModeDBOptName: "point-in-time",
+			}),
+			success: false,
+		},
+		{
+			desc: "unsupported wal compression",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				walCompressionDBOptName: "lz4",
+			}),
+			success: false,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			dbOpts, err := overrideDBOpts(newDefaultOptions(), tc.mockAppOptions)
+			if !tc.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.maxTotalWALSize, dbOpts.GetMaxTotalWalSize())
+			require.Equal(t, tc.walRecoveryMode, dbOpts.GetWALRecoveryMode())
+			require.Equal(t, tc.walCompression, dbOpts.GetWALCompression())
+			require.Equal(t, tc.manualWALFlush, dbOpts.IsManualWALFlush())
+		})
+	}
+}
+
+func TestWriteOptsFromAppOpts(t *testing.T) {
+	for _, tc := range []struct {
+		desc           string
+		mockAppOptions *mockAppOptions
+		disableWAL     bool
+		success        bool
+	}{
+		{
+			desc:           "default options",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{}),
+			disableWAL:     false,
+			success:        true,
+		},
+		{
+			desc: "disable wal",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				disableWALWriteOptName: true,
+			}),
+			disableWAL: true,
+			success:    true,
+		},
+		{
+			desc: "invalid disable wal",
+			mockAppOptions: newMockAppOptions(map[string]interface{}{
+				disableWALWriteOptName: "sometimes",
+			}),
+			success: false,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			writeOpts, err := writeOptsFromAppOpts(tc.mockAppOptions)
+			if !tc.success {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			defer writeOpts.Destroy()
+
+			require.Equal(t, tc.disableWAL, writeOpts.IsDisableWAL())
+		})
+	}
+}
+
+func TestOpenRocksdbWithWALDir(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	walDir := filepath.Join(dir, "wal")
+	mockAppOpts := newMockAppOptions(map[string]interface{}{
+		walDirDBOptName:          walDir,
+		walRecoveryModeDBOptName: "point_in_time",
+	})
+	db, err := openRocksdb(dir, defaultDBName, mockAppOpts, MetricsConfig{})
+	require.NoError(t, err)
+	require.NoError(t, db.SetSync([]byte("key"), []byte("value")))
+
+	walFiles, err := loadWALFiles(walDir)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), walFiles.NumFiles)
+	require.NotZero(t, walFiles.TotalSize)
+	require.NoError(t, db.Close())
+
+	dbOpts, _, err := LoadLatestOptions(filepath.Join(dir, "application.db"))
+	require.NoError(t, err)
+	require.Equal(t, grocksdb.PointInTimeRecovery, dbOpts.GetWALRecoveryMode())
+}
+
+func TestOpenRocksdbWithFallbackWALDir(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rocksdb")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		require.NoError(t, err)
+	}()
+
+	walDir := filepath.Join(dir, "wal")
+	mockAppOpts := newMockAppOptions(map[string]interface{}{
+		"rocksdb." + walDirDBOptName: walDir,
+	})
+
+	dbNames := []string{"application", "blockstore"}
+	for _, dbName := range dbNames {
+		db, err := openRocksdb(dir, dbName, newRocksDBOptions(mockAppOpts, dbName), MetricsConfig{})
+		require.NoError(t, err)
+		require.NoError(t, db.SetSync([]byte("key"), []byte(dbName)))
+		require.NoError(t, db.Close())
+
+		// every database has its own WAL files
+		walFiles, err := loadWALFiles(filepath.Join(walDir, dbName+".db"))
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), walFiles.NumFiles)
+	}
+
+	// databases recover only their own writes
+	for _, dbName := range dbNames {
+		db, err := openRocksdb(dir, dbName, newRocksDBOptions(mockAppOpts, dbName), MetricsConfig{})
+		require.NoError(t, err)
+		value, err := db.Get([]byte("key"))
+		require.NoError(t, err)
+		require.Equal(t, []byte(dbName), value)
+		require.NoError(t, db.Close())
+	}
+}